@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
@@ -12,10 +13,21 @@ import (
 	"github.com/tsaron/anansi"
 	"github.com/tsaron/anansi/middleware"
 	"github.com/tsaron/anansi/tokens"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/events"
+	"tsaron.com/godview-starter/pkg/health"
+	"tsaron.com/godview-starter/pkg/i18n"
+	"tsaron.com/godview-starter/pkg/inbox"
+	"tsaron.com/godview-starter/pkg/maillog"
 	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/preferences"
+	"tsaron.com/godview-starter/pkg/problems"
 	"tsaron.com/godview-starter/pkg/rest"
 	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/stream"
+	"tsaron.com/godview-starter/pkg/tracing"
+	"tsaron.com/godview-starter/pkg/webhooks"
 	"tsaron.com/godview-starter/pkg/workspaces"
 )
 
@@ -32,11 +44,23 @@ func main() {
 	ctx, cancel := anansi.WithCancel(context.Background())
 	defer cancel()
 
+	// setup tracing
+	var tp *sdktrace.TracerProvider
+	if tp, err = config.SetupTracing(ctx, env); err != nil {
+		panic(err)
+	}
+	defer func() {
+		if err := tp.Shutdown(context.Background()); err != nil {
+			log.Err(err).Msg("failed to flush traces cleanly")
+		}
+	}()
+
 	// connect to postgresql
 	var db *pg.DB
 	if db, err = config.SetupDB(env); err != nil {
 		panic(err)
 	}
+	db.AddQueryHook(tracing.NewQueryHook(tp))
 	defer func() {
 		if err := db.Close(); err != nil {
 			log.Err(err).Msg("failed to disconnect from postgres cleanly")
@@ -51,6 +75,7 @@ func main() {
 	if redisClient, err = config.SetupRedis(startupCtx, env); err != nil {
 		panic(err)
 	}
+	redisClient.AddHook(tracing.NewRedisHook(tp))
 	defer func() {
 		if err := redisClient.Close(); err != nil {
 			log.Err(err).Msg("failed to disconnect from redis cleanly")
@@ -58,10 +83,21 @@ func main() {
 	}()
 	log.Info().Msg("successfully connected to redis")
 
+	var drainPeriod time.Duration
+	if drainPeriod, err = time.ParseDuration(env.DrainPeriod); err != nil {
+		panic(err)
+	}
+
 	var sessionTimeout time.Duration
 	if sessionTimeout, err = time.ParseDuration(env.SessionTimeout); err != nil {
 		panic(err)
 	}
+
+	var mailRetention time.Duration
+	if mailRetention, err = time.ParseDuration(env.MailRetention); err != nil {
+		panic(err)
+	}
+
 	app := &config.App{
 		DB:     db,
 		Env:    &env,
@@ -70,6 +106,13 @@ func main() {
 	}
 	app.Auth = anansi.NewSessionStore(env.Secret, env.Scheme, sessionTimeout, app.Tokens)
 
+	// workspace events and their subscribers
+	whRepo := webhooks.NewRepo(db)
+	broker := stream.NewBroker(redisClient)
+	prefRepo := preferences.NewRepo(db)
+	app.Events = events.NewBus(webhooks.NewDispatcher(whRepo), broker, inbox.New(inbox.NewRepo(db), prefRepo, broker))
+	go webhooks.NewWorker(whRepo, log).Run(ctx)
+
 	// API router
 	router := chi.NewRouter()
 
@@ -82,30 +125,77 @@ func main() {
 			"http://localhost:8080",
 		},
 	})
-	router.NotFound(func(w http.ResponseWriter, _ *http.Request) {
-		http.Error(w, "Whoops!! This route doesn't exist", http.StatusNotFound)
-	})
+	router.Use(tracing.Middleware(tp))
+	router.Use(i18n.Middleware)
+	router.Use(problems.Recoverer(env.AppEnv))
+	router.NotFound(problems.NotFoundHandler)
+	router.MethodNotAllowed(problems.MethodNotAllowedHandler)
 
 	// dependency factory
 	sStore := sessions.NewStore(app.Tokens, workspaces.NewRepo(db))
-	noty := notification.New(notification.MailOpts{
+	templates, err := notification.LoadTemplates(notification.Sources(env.TemplateDir)...)
+	if err != nil {
+		panic(err)
+	}
+	if err := templates.Validate(); err != nil {
+		panic(err)
+	}
+	if env.AppEnv == "dev" && env.TemplateDir != "" {
+		go templates.Watch(ctx, env.TemplateDir, time.Second, log)
+	}
+	mlRepo := maillog.NewRepo(db)
+	go maillog.NewRedactor(mlRepo, mailRetention, log).Run(ctx)
+	mailer, err := notification.New(notification.MailOpts{
 		Key:             env.SendgridKey,
 		Sender:          env.MailSender,
 		NotifyEmail:     env.NotifyEmail,
 		PostmasterEmail: env.PostmasterEmail,
-		TemplatePath:    env.TemplateDir,
+	}, templates, mlRepo)
+	if err != nil {
+		panic(err)
+	}
+	noty := preferences.Mailer(maillog.Mailer(tracing.Mailer(mailer, tp), mlRepo), prefRepo, preferences.NewSigner(env.Secret), preferences.Links{
+		API:  env.PublicURL + "/unsubscribe",
+		Page: env.ClientUnsubscribePage,
 	})
 
+	smsSender, err := config.SetupSMS(env)
+	if err != nil {
+		panic(err)
+	}
+	sms := preferences.SMS(tracing.SMS(smsSender, tp), prefRepo)
+
+	blob, err := config.SetupStorage(env)
+	if err != nil {
+		panic(err)
+	}
+
+	checker := health.New(
+		config.PostgresCheck(db),
+		config.RedisCheck(redisClient),
+		config.MigrationCheck(db, env.PostgresMigrationDir),
+		// mail costs an API call, so it's checked at most once a minute and doesn't
+		// take every pod out of rotation when SendGrid is down
+		health.Check{
+			Name:     "mail",
+			Timeout:  time.Second * 5,
+			Every:    time.Minute,
+			Optional: true,
+			Run: func(ctx context.Context) error {
+				return notification.Ping(ctx, env.SendgridKey)
+			},
+		},
+	)
+
 	// setup routes
-	rest.Invitations(router, app, sStore, noty)
+	rest.Routes(router, app, sStore, noty, templates, sms, blob, broker, net.DefaultResolver)
 
 	// mount API on app router
 	appRouter := chi.NewRouter()
 	appRouter.Mount("/api/v1", router)
-	appRouter.Get("/", config.HealthChecker(app))
-	appRouter.NotFound(func(w http.ResponseWriter, _ *http.Request) {
-		http.Error(w, "Whoops!! This route doesn't exist", http.StatusNotFound)
-	})
+	appRouter.Get("/livez", checker.Livez())
+	appRouter.Get("/readyz", checker.Readyz())
+	appRouter.NotFound(problems.NotFoundHandler)
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", env.Port),
@@ -116,6 +206,10 @@ func main() {
 		l := log.With().Logger()
 		<-ctx.Done()
 
+		// fail readiness and give the load balancer time to notice before we stop accepting
+		checker.Drain()
+		time.Sleep(drainPeriod)
+
 		// shutdown server in 5s
 		shutCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 		defer cancel()
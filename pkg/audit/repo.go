@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+const (
+	ActionInvitationSent   = "invitation.sent"
+	ActionInvitationExtend = "invitation.extended"
+	ActionUserRegistered   = "user.registered"
+	ActionUserLogin        = "user.login"
+	ActionUserLoginFailed  = "user.login_failed"
+	ActionUserRoleChanged  = "user.role_changed"
+	ActionPhoneVerified    = "user.phone_verified"
+
+	ActionEmailChangeRequested = "user.email_change_requested"
+	ActionEmailChangeCancelled = "user.email_change_cancelled"
+	ActionEmailChanged         = "user.email_changed"
+	ActionPasswordChanged      = "user.password_changed"
+
+	ActionWorkspaceRenamed = "workspace.renamed"
+	ActionWorkspaceRegion  = "workspace.region_changed"
+	ActionWorkspaceBrand   = "workspace.branding_changed"
+	ActionWorkspaceLocale  = "workspace.locale_changed"
+
+	ActionWorkspaceInvitePolicy = "workspace.invitation_policy_changed"
+
+	ActionMailResent = "mail.resent"
+
+	ActionJoinLinkCreated = "join_link.created"
+	ActionJoinLinkRevoked = "join_link.revoked"
+	ActionJoinLinkUsed    = "join_link.used"
+
+	ActionDomainAdded       = "domain.added"
+	ActionDomainVerified    = "domain.verified"
+	ActionDomainJoinChanged = "domain.join_changed"
+	ActionDomainRemoved     = "domain.removed"
+	ActionDomainJoined      = "domain.joined"
+
+	ActionJoinRequested       = "join_request.created"
+	ActionJoinRequestApproved = "join_request.approved"
+	ActionJoinRequestDenied   = "join_request.denied"
+)
+
+type Event struct {
+	tableName struct{} `pg:"audit_events"`
+
+	ID        uint                   `json:"id"`
+	CreatedAt time.Time              `json:"created_at"`
+	Workspace uint                   `json:"workspace"`
+	Actor     uint                   `json:"actor,omitempty"`
+	Action    string                 `json:"action"`
+	Target    string                 `json:"target,omitempty"`
+	IPAddress string                 `json:"ip_address,omitempty"`
+	UserAgent string                 `json:"user_agent,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata"`
+}
+
+// Filter narrows down the events of a workspace. Zero values are ignored.
+type Filter struct {
+	Workspace uint
+	Actor     uint
+	Action    string
+	Target    string
+	From      time.Time
+	To        time.Time
+}
+
+type Repo struct {
+	db *pg.DB
+}
+
+func NewRepo(db *pg.DB) *Repo {
+	return &Repo{db}
+}
+
+// Record saves an event.
+func (r *Repo) Record(ctx context.Context, e Event) (*Event, error) {
+	if e.Metadata == nil {
+		e.Metadata = map[string]interface{}{}
+	}
+
+	_, err := r.db.
+		ModelContext(ctx, &e).
+		Returning("*").
+		Insert(&e)
+
+	return &e, err
+}
+
+// List returns a page of events matching the filter, newest first.
+func (r *Repo) List(ctx context.Context, f Filter, offset, limit int) ([]Event, error) {
+	var events []Event
+
+	err := r.db.
+		ModelContext(ctx, &events).
+		Apply(f.apply).
+		Order("created_at desc", "id desc").
+		Offset(offset).
+		Limit(limit).
+		Select()
+
+	return events, err
+}
+
+// Each calls fn for every event matching the filter, newest first, without loading
+// them all into memory.
+func (r *Repo) Each(ctx context.Context, f Filter, fn func(*Event) error) error {
+	return r.db.
+		ModelContext(ctx, (*Event)(nil)).
+		Apply(f.apply).
+		Order("created_at desc", "id desc").
+		ForEach(fn)
+}
+
+func (f Filter) apply(q *orm.Query) (*orm.Query, error) {
+	q = q.Where("workspace = ?", f.Workspace)
+
+	if f.Actor != 0 {
+		q = q.Where("actor = ?", f.Actor)
+	}
+
+	if f.Action != "" {
+		q = q.Where("action = ?", f.Action)
+	}
+
+	if f.Target != "" {
+		q = q.Where("target = ?", f.Target)
+	}
+
+	if !f.From.IsZero() {
+		q = q.Where("created_at >= ?", f.From)
+	}
+
+	if !f.To.IsZero() {
+		q = q.Where("created_at < ?", f.To)
+	}
+
+	return q, nil
+}
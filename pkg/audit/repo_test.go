@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/tsaron/anansi"
+	"github.com/tsaron/anansi/postgres"
+	"syreclabs.com/go/faker"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+var testDB *pg.DB
+
+func afterEach(t *testing.T) {
+	if err := postgres.CleanUpTables(testDB, "audit_events", "workspaces"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	var err error
+
+	var env config.Env
+	if err = anansi.LoadEnv(&env); err != nil {
+		panic(err)
+	}
+
+	log := anansi.NewLogger(env.Name)
+
+	if testDB, err = config.SetupDB(env); err != nil {
+		panic(err)
+	}
+	log.Info().Msg("Successfully connected to postgres")
+
+	code := m.Run()
+
+	if err := testDB.Close(); err != nil {
+		log.Err(err).Msg("Failed to disconnect from postgres cleanly")
+	}
+
+	os.Exit(code)
+}
+
+func TestRepoList(t *testing.T) {
+	defer afterEach(t)
+
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+
+	wkRepo := workspaces.NewRepo(testDB)
+	wk, err := wkRepo.Create(ctx, faker.Company().Name(), faker.Internet().Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := faker.Internet().Email()
+	for _, action := range []string{ActionInvitationSent, ActionUserRegistered, ActionInvitationSent} {
+		if _, err := repo.Record(ctx, Event{Workspace: wk.ID, Action: action, Target: target}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("filters by action", func(t *testing.T) {
+		events, err := repo.List(ctx, Filter{Workspace: wk.ID, Action: ActionInvitationSent}, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(events) != 2 {
+			t.Errorf("Expected 2 invitation events, got %d", len(events))
+		}
+	})
+
+	t.Run("paginates newest first", func(t *testing.T) {
+		events, err := repo.List(ctx, Filter{Workspace: wk.ID}, 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(events) != 1 || events[0].Action != ActionUserRegistered {
+			t.Errorf("Expected the second newest event to be %s, got %v", ActionUserRegistered, events)
+		}
+	})
+
+	t.Run("streams every matching event", func(t *testing.T) {
+		var count int
+		err := repo.Each(ctx, Filter{Workspace: wk.ID, Target: target}, func(e *Event) error {
+			count++
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if count != 3 {
+			t.Errorf("Expected to stream 3 events, got %d", count)
+		}
+	})
+}
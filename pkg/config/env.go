@@ -8,28 +8,64 @@ type Env struct {
 	Scheme string `required:"true"`
 	Secret []byte `required:"true"`
 
-	TemplateDir string `required:"true" split_words:"true"`
+	// TemplateDir holds mail templates that override the embedded ones
+	TemplateDir string `default:"" split_words:"true"`
 
-	PostgresHost       string `required:"true" split_words:"true"`
-	PostgresPort       int    `required:"true" split_words:"true"`
-	PostgresSecureMode bool   `required:"true" split_words:"true"`
-	PostgresUser       string `required:"true" split_words:"true"`
-	PostgresPassword   string `required:"true" split_words:"true"`
-	PostgresDatabase   string `required:"true" split_words:"true"`
+	PostgresHost         string `required:"true" split_words:"true"`
+	PostgresPort         int    `required:"true" split_words:"true"`
+	PostgresSecureMode   bool   `required:"true" split_words:"true"`
+	PostgresUser         string `required:"true" split_words:"true"`
+	PostgresPassword     string `required:"true" split_words:"true"`
+	PostgresDatabase     string `required:"true" split_words:"true"`
+	PostgresMigrationDir string `default:"sql" split_words:"true"`
 
 	RedisHost     string `required:"true" split_words:"true"`
 	RedisPort     int    `required:"true" split_words:"true"`
 	RedisPassword string `default:"" split_words:"true"`
 
+	TracingEndpoint string `default:"" split_words:"true"`
+	TracingInsecure bool   `default:"false" split_words:"true"`
+
 	SendgridKey     string `required:"true" split_words:"true"`
 	MailSender      string `required:"true" split_words:"true"`
 	NotifyEmail     string `required:"true" split_words:"true"`
 	PostmasterEmail string `required:"true" split_words:"true"`
+	// SendgridEventKey verifies the event webhook, which is off without it
+	SendgridEventKey string `default:"" split_words:"true"`
+	// MailRetention is how long the mail log keeps receivers and template data
+	MailRetention string `default:"720h" split_words:"true"`
+
+	StorageBackend string `default:"local" split_words:"true"`
+	StorageDir     string `default:"uploads" split_words:"true"`
+	StorageURL     string `default:"/api/v1/files" split_words:"true"`
+	S3Endpoint     string `default:"" split_words:"true"`
+	S3Region       string `default:"" split_words:"true"`
+	S3Bucket       string `default:"" split_words:"true"`
+	S3AccessKey    string `default:"" split_words:"true"`
+	S3SecretKey    string `default:"" split_words:"true"`
+	S3Secure       bool   `default:"true" split_words:"true"`
 
+	SMSProvider   string `default:"log" split_words:"true"`
+	SMSGatewayURL string `default:"" split_words:"true"`
+	SMSKey        string `default:"" split_words:"true"`
+	SMSSender     string `default:"" split_words:"true"`
+	SMSLogFile    string `default:"" split_words:"true"`
+
+	DrainPeriod     string `default:"0s" split_words:"true"`
 	SessionTimeout  string `required:"true" split_words:"true"`
 	HeadlessTimeout string `required:"true" split_words:"true"`
 
+	// PublicURL is where clients reach the API, including the version prefix
+	PublicURL string `required:"true" split_words:"true"`
+
 	ClientOwnerPage string `required:"true" split_words:"true"`
 	ClientUserPage  string `required:"true" split_words:"true"`
 	ClientResetPage string `required:"true" split_words:"true"`
+	ClientEmailPage string `required:"true" split_words:"true"`
+	// ClientUnsubscribePage confirms unsubscribes from the links in emails
+	ClientUnsubscribePage string `required:"true" split_words:"true"`
+	// ClientRequestPage is where admins review a join request, by its ID
+	ClientRequestPage string `required:"true" split_words:"true"`
+	// ClientSignupPage is where people confirm their address before joining without an invitation
+	ClientSignupPage string `required:"true" split_words:"true"`
 }
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-redis/redis/v8"
+	"tsaron.com/godview-starter/pkg/health"
+)
+
+var migrationFile = regexp.MustCompile(`^([0-9]+)_.*\.up\.sql$`)
+
+func PostgresCheck(db *pg.DB) health.Check {
+	return health.Check{
+		Name: "postgres",
+		Run:  db.Ping,
+	}
+}
+
+func RedisCheck(client *redis.Client) health.Check {
+	return health.Check{
+		Name: "redis",
+		Run: func(ctx context.Context) error {
+			return client.Ping(ctx).Err()
+		},
+	}
+}
+
+// MigrationCheck fails when the database is behind the newest migration in dir
+// or a previous migration was left dirty.
+func MigrationCheck(db *pg.DB, dir string) health.Check {
+	return health.Check{
+		Name: "migrations",
+		Run: func(ctx context.Context) error {
+			latest, err := LatestMigration(dir)
+			if err != nil {
+				return err
+			}
+
+			var current struct {
+				Version uint
+				Dirty   bool
+			}
+			_, err = db.QueryOneContext(ctx, &current, "select version, dirty from schema_migrations limit 1")
+			if err == pg.ErrNoRows {
+				return fmt.Errorf("no migrations have been applied, expected version %d", latest)
+			} else if err != nil {
+				return err
+			}
+
+			if current.Dirty {
+				return fmt.Errorf("migration %d is dirty", current.Version)
+			}
+
+			if current.Version < latest {
+				return fmt.Errorf("database is at version %d, expected version %d", current.Version, latest)
+			}
+
+			return nil
+		},
+	}
+}
+
+// LatestMigration returns the highest version among the go-migrate files in dir.
+func LatestMigration(dir string) (uint, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, f := range files {
+		match := migrationFile.FindStringSubmatch(f.Name())
+		if match == nil {
+			continue
+		}
+
+		v, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil {
+			return 0, err
+		}
+
+		if uint(v) > latest {
+			latest = uint(v)
+		}
+	}
+
+	return latest, nil
+}
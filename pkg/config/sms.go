@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"tsaron.com/godview-starter/pkg/notification"
+)
+
+// SetupSMS creates the SMS sender chosen by SMS_PROVIDER. The "log" provider writes
+// messages to SMS_LOG_FILE, or stdout when no file is set, instead of sending them.
+func SetupSMS(env Env) (notification.SMSSender, error) {
+	switch env.SMSProvider {
+	case "http":
+		if env.SMSGatewayURL == "" {
+			return nil, fmt.Errorf("SMS_GATEWAY_URL is required for the http sms provider")
+		}
+
+		return notification.NewHTTPSender(notification.SMSOpts{
+			URL:    env.SMSGatewayURL,
+			Key:    env.SMSKey,
+			Sender: env.SMSSender,
+		}), nil
+	case "log":
+		if env.SMSLogFile == "" {
+			return notification.NewLogSender(os.Stdout), nil
+		}
+
+		f, err := os.OpenFile(env.SMSLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+
+		return notification.NewLogSender(f), nil
+	default:
+		return nil, fmt.Errorf("unknown sms provider %q", env.SMSProvider)
+	}
+}
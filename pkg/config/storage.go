@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+
+	"tsaron.com/godview-starter/pkg/storage"
+)
+
+// SetupStorage creates the blob store chosen by STORAGE_BACKEND. The "local" backend
+// keeps files in STORAGE_DIR and signs URLs under STORAGE_URL, which should point at
+// the API's /files route.
+func SetupStorage(env Env) (storage.Blob, error) {
+	switch env.StorageBackend {
+	case "local":
+		return storage.NewLocal(env.StorageDir, env.StorageURL, env.Secret)
+	case "s3":
+		if env.S3Endpoint == "" || env.S3Bucket == "" {
+			return nil, fmt.Errorf("S3_ENDPOINT and S3_BUCKET are required for the s3 storage backend")
+		}
+
+		return storage.NewS3(storage.S3Opts{
+			Endpoint:  env.S3Endpoint,
+			Region:    env.S3Region,
+			Bucket:    env.S3Bucket,
+			AccessKey: env.S3AccessKey,
+			SecretKey: env.S3SecretKey,
+			Secure:    env.S3Secure,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", env.StorageBackend)
+	}
+}
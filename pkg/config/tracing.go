@@ -0,0 +1,43 @@
+package config
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"tsaron.com/godview-starter/pkg/tracing"
+)
+
+// SetupTracing creates the process wide tracer provider. Spans are exported over OTLP/HTTP
+// when a tracing endpoint is configured, otherwise they are only used for log correlation.
+func SetupTracing(ctx context.Context, env Env) (*sdktrace.TracerProvider, error) {
+	res := resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(env.Name),
+		semconv.DeploymentEnvironment(env.AppEnv),
+	)
+
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+
+	if env.TracingEndpoint != "" {
+		exOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(env.TracingEndpoint)}
+		if env.TracingInsecure {
+			exOpts = append(exOpts, otlptracehttp.WithInsecure())
+		}
+
+		exporter, err := otlptracehttp.New(ctx, exOpts...)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(tracing.Propagator)
+
+	return tp, nil
+}
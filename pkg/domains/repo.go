@@ -0,0 +1,214 @@
+package domains
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/tsaron/anansi/postgres"
+	"tsaron.com/godview-starter/pkg/users"
+)
+
+const (
+	// JoinAuto lets anyone at the domain join straight away
+	JoinAuto = "auto"
+	// JoinApproval makes people at the domain wait for an admin
+	JoinApproval = "approval"
+)
+
+var (
+	ErrAdded            = errors.New("domain has already been added to the workspace")
+	ErrClaimed          = errors.New("domain has been verified by another workspace")
+	ErrNoWorkspace      = errors.New("no workspace has verified the domain")
+	ErrApprovalRequired = errors.New("workspace approves people at the domain before they join")
+)
+
+// Domain is an email domain a workspace claims. People with addresses at a verified
+// domain can join the workspace without an invitation.
+type Domain struct {
+	tableName struct{} `pg:"workspace_domains"`
+
+	ID         uint       `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Workspace  uint       `json:"workspace"`
+	Name       string     `json:"name"`
+	Token      string     `json:"-"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	JoinMode   string     `json:"join_mode"`
+	Role       string     `json:"role"`
+}
+
+type Repo struct {
+	db *pg.DB
+}
+
+func NewRepo(db *pg.DB) *Repo {
+	return &Repo{db}
+}
+
+// Create claims a domain for a workspace, to be verified with the token.
+func (r *Repo) Create(ctx context.Context, workspace uint, name, token string) (*Domain, error) {
+	d := &Domain{
+		Workspace: workspace,
+		Name:      Normalize(name),
+		Token:     token,
+		JoinMode:  JoinApproval,
+		Role:      users.RoleMember,
+	}
+
+	_, err := r.db.
+		ModelContext(ctx, d).
+		Returning("*").
+		Insert(d)
+
+	if err != nil && postgres.ErrDuplicate.MatchString(err.Error()) {
+		return nil, ErrAdded
+	}
+
+	return d, err
+}
+
+// List returns the domains a workspace claims.
+func (r *Repo) List(ctx context.Context, workspace uint) ([]Domain, error) {
+	var domains []Domain
+
+	err := r.db.
+		ModelContext(ctx, &domains).
+		Where("workspace = ?", workspace).
+		Order("name").
+		Select()
+
+	return domains, err
+}
+
+// Get returns a domain of a workspace. Returns nil if the domain doesn't exist
+func (r *Repo) Get(ctx context.Context, workspace, id uint) (*Domain, error) {
+	d := new(Domain)
+	err := r.db.
+		ModelContext(ctx, d).
+		Where("id = ?", id).
+		Where("workspace = ?", workspace).
+		Select()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return d, err
+}
+
+// Verified returns the verified domain an email address is at. Returns nil if no
+// workspace has verified it
+func (r *Repo) Verified(ctx context.Context, email string) (*Domain, error) {
+	d := new(Domain)
+	err := r.db.
+		ModelContext(ctx, d).
+		Where("name = ?", domainOf(email)).
+		Where("verified_at is not null").
+		Select()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return d, err
+}
+
+// MarkVerified records that a workspace proved it owns a domain. Returns nil if the
+// domain doesn't exist
+func (r *Repo) MarkVerified(ctx context.Context, workspace, id uint) (*Domain, error) {
+	d := new(Domain)
+	_, err := r.db.
+		ModelContext(ctx, d).
+		Set("verified_at = coalesce(verified_at, now())").
+		Where("id = ?", id).
+		Where("workspace = ?", workspace).
+		Returning("*").
+		Update()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil && postgres.ErrDuplicate.MatchString(err.Error()) {
+		return nil, ErrClaimed
+	}
+
+	return d, err
+}
+
+// SetJoin changes how people at a domain join the workspace. Returns nil if the domain
+// doesn't exist
+func (r *Repo) SetJoin(ctx context.Context, workspace, id uint, mode, role string) (*Domain, error) {
+	d := new(Domain)
+	_, err := r.db.
+		ModelContext(ctx, d).
+		Set("join_mode = ?", mode).
+		Set("role = ?", role).
+		Where("id = ?", id).
+		Where("workspace = ?", workspace).
+		Returning("*").
+		Update()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return d, err
+}
+
+// Delete removes a domain from a workspace. Returns nil if the domain doesn't exist
+func (r *Repo) Delete(ctx context.Context, workspace, id uint) (*Domain, error) {
+	d := new(Domain)
+	_, err := r.db.
+		ModelContext(ctx, d).
+		Where("id = ?", id).
+		Where("workspace = ?", workspace).
+		Returning("*").
+		Delete()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return d, err
+}
+
+// Claim lets email join the workspace that verified its domain with the domain's role,
+// for users.Repo.Join.
+func (r *Repo) Claim(ctx context.Context, email string) users.Claim {
+	return func(tx *pg.Tx) (uint, string, error) {
+		d := new(Domain)
+		err := tx.
+			ModelContext(ctx, d).
+			Where("name = ?", domainOf(email)).
+			Where("verified_at is not null").
+			For("SHARE").
+			Select()
+
+		if err == pg.ErrNoRows {
+			return 0, "", ErrNoWorkspace
+		}
+
+		if err != nil {
+			return 0, "", err
+		}
+
+		if d.JoinMode != JoinAuto {
+			return 0, "", ErrApprovalRequired
+		}
+
+		return d.Workspace, d.Role, nil
+	}
+}
+
+// Normalize turns a domain into the form it's stored in.
+func Normalize(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
+
+func domainOf(email string) string {
+	return Normalize(email[strings.LastIndex(email, "@")+1:])
+}
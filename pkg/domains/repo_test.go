@@ -0,0 +1,141 @@
+package domains
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/tsaron/anansi"
+	"github.com/tsaron/anansi/postgres"
+	"syreclabs.com/go/faker"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/users"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+var testDB *pg.DB
+
+func afterEach(t *testing.T) {
+	if err := postgres.CleanUpTables(testDB, "workspace_domains", "users", "workspaces"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	var err error
+
+	var env config.Env
+	if err = anansi.LoadEnv(&env); err != nil {
+		panic(err)
+	}
+
+	log := anansi.NewLogger(env.Name)
+
+	if testDB, err = config.SetupDB(env); err != nil {
+		panic(err)
+	}
+	log.Info().Msg("Successfully connected to postgres")
+
+	code := m.Run()
+
+	if err := testDB.Close(); err != nil {
+		log.Err(err).Msg("Failed to disconnect from postgres cleanly")
+	}
+
+	os.Exit(code)
+}
+
+func TestRepoMarkVerified(t *testing.T) {
+	repo := NewRepo(testDB)
+	wRepo := workspaces.NewRepo(testDB)
+	ctx := context.TODO()
+
+	defer afterEach(t)
+
+	var claims []*Domain
+	for i := 0; i < 2; i++ {
+		wk, err := wRepo.Create(ctx, faker.Company().Name(), faker.Internet().Email())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		d, err := repo.Create(ctx, wk.ID, "Example.com.", faker.RandomString(32))
+		if err != nil {
+			t.Fatal(err)
+		}
+		claims = append(claims, d)
+	}
+
+	if claims[0].Name != "example.com" {
+		t.Errorf("Expected the domain to be normalised, got %s", claims[0].Name)
+	}
+
+	if _, err := repo.Create(ctx, claims[0].Workspace, "example.com", faker.RandomString(32)); err != ErrAdded {
+		t.Errorf("Expected %v, got %v", ErrAdded, err)
+	}
+
+	d, err := repo.MarkVerified(ctx, claims[0].Workspace, claims[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.VerifiedAt == nil {
+		t.Error("Expected the domain to be verified")
+	}
+
+	if _, err := repo.MarkVerified(ctx, claims[1].Workspace, claims[1].ID); err != ErrClaimed {
+		t.Errorf("Expected %v, got %v", ErrClaimed, err)
+	}
+
+	// the domain belongs to another workspace
+	if d, err := repo.MarkVerified(ctx, claims[1].Workspace, claims[0].ID); err != nil || d != nil {
+		t.Errorf("Expected no domain, got %v, %v", d, err)
+	}
+}
+
+func TestRepoClaim(t *testing.T) {
+	repo := NewRepo(testDB)
+	uRepo := users.NewRepo(testDB)
+	ctx := context.TODO()
+
+	wk, err := workspaces.NewRepo(testDB).Create(ctx, faker.Company().Name(), faker.Internet().Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer afterEach(t)
+
+	d, err := repo.Create(ctx, wk.ID, "example.com", faker.RandomString(32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	join := func(email string) (*users.User, error) {
+		return uRepo.Join(ctx, email, repo.Claim(ctx, email))
+	}
+
+	if _, err := join("jane@example.com"); err != ErrNoWorkspace {
+		t.Errorf("Expected unverified domains to be ignored, got %v", err)
+	}
+
+	if _, err := repo.MarkVerified(ctx, wk.ID, d.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := join("jane@example.com"); err != ErrApprovalRequired {
+		t.Errorf("Expected %v, got %v", ErrApprovalRequired, err)
+	}
+
+	if _, err := repo.SetJoin(ctx, wk.ID, d.ID, JoinAuto, users.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := join("jane@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.Workspace != wk.ID || user.Role != users.RoleAdmin {
+		t.Errorf("Expected an admin of the workspace, got %v", user)
+	}
+}
@@ -0,0 +1,46 @@
+package domains
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+)
+
+// RecordPrefix starts the TXT record that proves a workspace owns a domain.
+const RecordPrefix = "godview-verification="
+
+// ErrUnverified is returned when a domain doesn't have the workspace's TXT record.
+var ErrUnverified = errors.New("domain doesn't have the verification record")
+
+// Resolver looks up DNS TXT records. net.DefaultResolver is one.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// Record is the TXT record the owner of a domain adds to prove it.
+func (d *Domain) Record() string {
+	return RecordPrefix + d.Token
+}
+
+// Verify checks that a domain has its verification record.
+func Verify(ctx context.Context, resolver Resolver, d *Domain) error {
+	records, err := resolver.LookupTXT(ctx, d.Name)
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return ErrUnverified
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		if strings.TrimSpace(r) == d.Record() {
+			return nil
+		}
+	}
+
+	return ErrUnverified
+}
@@ -0,0 +1,59 @@
+package domains
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+type fakeResolver map[string][]string
+
+func (f fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	records, ok := f[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+
+	return records, nil
+}
+
+func TestVerify(t *testing.T) {
+	d := &Domain{Name: "example.com", Token: "abc123"}
+	ctx := context.TODO()
+
+	t.Run("finds the record among others", func(t *testing.T) {
+		resolver := fakeResolver{"example.com": {"v=spf1 -all", " godview-verification=abc123 "}}
+		if err := Verify(ctx, resolver, d); err != nil {
+			t.Errorf("Expected the domain to be verified, got %v", err)
+		}
+	})
+
+	t.Run("rejects another workspace's record", func(t *testing.T) {
+		resolver := fakeResolver{"example.com": {"godview-verification=xyz789"}}
+		if err := Verify(ctx, resolver, d); err != ErrUnverified {
+			t.Errorf("Expected %v, got %v", ErrUnverified, err)
+		}
+	})
+
+	t.Run("treats missing domains as unverified", func(t *testing.T) {
+		if err := Verify(ctx, fakeResolver{}, d); err != ErrUnverified {
+			t.Errorf("Expected %v, got %v", ErrUnverified, err)
+		}
+	})
+
+	t.Run("passes on lookup failures", func(t *testing.T) {
+		failure := errors.New("server misbehaving")
+		if err := Verify(ctx, failingResolver{failure}, d); err != failure {
+			t.Errorf("Expected %v, got %v", failure, err)
+		}
+	})
+}
+
+type failingResolver struct {
+	err error
+}
+
+func (f failingResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return nil, f.err
+}
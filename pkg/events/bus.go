@@ -0,0 +1,75 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tsaron/anansi"
+)
+
+const (
+	InvitationSent     = "invitation.sent"
+	InvitationAccepted = "invitation.accepted"
+	MemberJoined       = "member.joined"
+	MemberRoleChanged  = "member.role_changed"
+	JoinRequested      = "member.join_requested"
+)
+
+// Types lists every event a workspace can subscribe to.
+var Types = []string{InvitationSent, InvitationAccepted, MemberJoined, MemberRoleChanged, JoinRequested}
+
+// Event is something that happened in a workspace.
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Workspace uint        `json:"workspace"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Handler reacts to published events.
+type Handler interface {
+	Handle(ctx context.Context, e Event) error
+}
+
+// HandlerFunc allows plain functions to be used as handlers.
+type HandlerFunc func(ctx context.Context, e Event) error
+
+func (f HandlerFunc) Handle(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+// Bus fans events out to every subscribed handler.
+type Bus struct {
+	handlers []Handler
+}
+
+func NewBus(handlers ...Handler) *Bus {
+	return &Bus{handlers}
+}
+
+// Subscribe adds a handler to the bus. It is not safe to call once the bus is in use.
+func (b *Bus) Subscribe(h Handler) {
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish hands a new event to every handler. The action behind an event has already
+// happened by the time it is published, so handler errors are logged rather than returned.
+func (b *Bus) Publish(ctx context.Context, eventType string, workspace uint, data interface{}) Event {
+	e := Event{
+		ID:        anansi.UUID(),
+		Type:      eventType,
+		Workspace: workspace,
+		CreatedAt: time.Now(),
+		Data:      data,
+	}
+
+	for _, h := range b.handlers {
+		if err := h.Handle(ctx, e); err != nil {
+			zerolog.Ctx(ctx).Err(err).Str("event", e.Type).Msg("failed to handle event")
+		}
+	}
+
+	return e
+}
@@ -0,0 +1,180 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultTimeout = time.Second * 2
+
+// Check is a single dependency the app needs before it can serve traffic.
+type Check struct {
+	Name    string
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+
+	// Every reuses the last result for that long, for checks that cost something to run
+	Every time.Duration
+	// Optional checks are reported but don't take the app out of rotation when they fail
+	Optional bool
+}
+
+// Result is the outcome of running a check.
+type Result struct {
+	Status  string  `json:"status"`
+	Latency float64 `json:"latency_ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Report is the body of a probe's response.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+type Checker struct {
+	checks   []Check
+	draining int32
+
+	mu   sync.Mutex
+	last map[string]cached
+}
+
+type cached struct {
+	at  time.Time
+	res Result
+}
+
+func New(checks ...Check) *Checker {
+	return &Checker{checks: checks, last: make(map[string]cached)}
+}
+
+// Drain marks the app as not ready so load balancers stop sending it traffic
+// while it shuts down.
+func (c *Checker) Drain() {
+	atomic.StoreInt32(&c.draining, 1)
+}
+
+// Run executes all checks concurrently, each with its own timeout.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: "ok", Checks: make(map[string]Result)}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			res := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = res
+			if res.Status != "ok" && !check.Optional {
+				report.Status = "unavailable"
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	if atomic.LoadInt32(&c.draining) == 1 {
+		report.Status = "draining"
+	}
+
+	return report
+}
+
+// Livez reports whether the process is up. It never checks dependencies, so a
+// flaky database doesn't get the pod restarted.
+func (c *Checker) Livez() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		send(w, http.StatusOK, Report{Status: "ok"})
+	}
+}
+
+// Readyz reports whether the app can serve traffic, with the result of every check.
+func (c *Checker) Readyz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := c.Run(r.Context())
+
+		code := http.StatusOK
+		if report.Status != "ok" {
+			code = http.StatusServiceUnavailable
+		}
+
+		send(w, code, report)
+	}
+}
+
+// run runs the check unless it has a result younger than check.Every.
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	if check.Every == 0 {
+		return run(ctx, check)
+	}
+
+	c.mu.Lock()
+	last, ok := c.last[check.Name]
+	c.mu.Unlock()
+
+	if ok && time.Since(last.at) < check.Every {
+		return last.res
+	}
+
+	res := run(ctx, check)
+
+	c.mu.Lock()
+	c.last[check.Name] = cached{time.Now(), res}
+	c.mu.Unlock()
+
+	return res
+}
+
+func run(ctx context.Context, check Check) Result {
+	timeout := check.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// don't let a check that ignores its context hold up the probe
+	done := make(chan error, 1)
+	go func() { done <- check.Run(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := Result{
+		Status:  "ok",
+		Latency: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		res.Status = "failing"
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+func send(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+
+	// we don't have a plan for when writes fail
+	_ = json.NewEncoder(w).Encode(report)
+}
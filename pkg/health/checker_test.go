@@ -0,0 +1,128 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func okCheck(name string) Check {
+	return Check{Name: name, Run: func(ctx context.Context) error { return nil }}
+}
+
+func readyz(t *testing.T, c *Checker) (int, Report) {
+	res := httptest.NewRecorder()
+	c.Readyz()(res, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report Report
+	if err := json.Unmarshal(res.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	return res.Code, report
+}
+
+func TestReadyz(t *testing.T) {
+	t.Run("reports every check when healthy", func(t *testing.T) {
+		code, report := readyz(t, New(okCheck("postgres"), okCheck("redis")))
+
+		if code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", code)
+		}
+
+		if len(report.Checks) != 2 || report.Checks["redis"].Status != "ok" {
+			t.Errorf("Expected both checks to be reported as ok, got %v", report.Checks)
+		}
+	})
+
+	t.Run("fails when a dependency fails", func(t *testing.T) {
+		failing := Check{Name: "redis", Run: func(ctx context.Context) error {
+			return errors.New("connection refused")
+		}}
+		code, report := readyz(t, New(okCheck("postgres"), failing))
+
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503, got %d", code)
+		}
+
+		if report.Checks["redis"].Error != "connection refused" {
+			t.Errorf("Expected redis error to be reported, got %q", report.Checks["redis"].Error)
+		}
+	})
+
+	t.Run("times out slow checks", func(t *testing.T) {
+		slow := Check{Name: "mail", Timeout: time.Millisecond * 10, Run: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}}
+		code, report := readyz(t, New(slow))
+
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503, got %d", code)
+		}
+
+		if report.Checks["mail"].Error != context.DeadlineExceeded.Error() {
+			t.Errorf("Expected mail check to time out, got %q", report.Checks["mail"].Error)
+		}
+	})
+
+	t.Run("ignores failing optional checks", func(t *testing.T) {
+		mail := Check{Name: "mail", Optional: true, Run: func(ctx context.Context) error {
+			return errors.New("service unavailable")
+		}}
+		code, report := readyz(t, New(okCheck("postgres"), mail))
+
+		if code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", code)
+		}
+
+		if report.Checks["mail"].Status != "failing" {
+			t.Errorf("Expected mail check to be reported as failing, got %v", report.Checks["mail"])
+		}
+	})
+
+	t.Run("reuses results of checks that run every so often", func(t *testing.T) {
+		runs := 0
+		mail := Check{Name: "mail", Every: time.Minute, Run: func(ctx context.Context) error {
+			runs++
+			return nil
+		}}
+		c := New(mail)
+
+		readyz(t, c)
+		readyz(t, c)
+
+		if runs != 1 {
+			t.Errorf("Expected mail check to run once, got %d", runs)
+		}
+	})
+
+	t.Run("fails once draining", func(t *testing.T) {
+		c := New(okCheck("postgres"))
+		c.Drain()
+
+		code, report := readyz(t, c)
+		if code != http.StatusServiceUnavailable || report.Status != "draining" {
+			t.Errorf("Expected a draining 503, got %d %s", code, report.Status)
+		}
+	})
+}
+
+func TestLivez(t *testing.T) {
+	failing := Check{Name: "postgres", Run: func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}}
+	c := New(failing)
+	c.Drain()
+
+	res := httptest.NewRecorder()
+	c.Livez()(res, httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+	if res.Code != http.StatusOK {
+		t.Errorf("Expected liveness to ignore dependencies, got %d", res.Code)
+	}
+}
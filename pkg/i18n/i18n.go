@@ -0,0 +1,182 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"golang.org/x/text/language"
+)
+
+// Default is the locale used when nothing else matches. Every key must exist in its
+// catalogue.
+const Default = "en"
+
+//go:embed catalogues/*.json
+var files embed.FS
+
+var (
+	catalogues = load()
+	matcher    = language.NewMatcher(tags())
+)
+
+// load reads every catalogue, keyed by the locale in its file name.
+func load() map[string]map[string]string {
+	paths, err := fs.Glob(files, "catalogues/*.json")
+	if err != nil {
+		panic(err)
+	}
+
+	cats := make(map[string]map[string]string)
+	for _, p := range paths {
+		raw, err := files.ReadFile(p)
+		if err != nil {
+			panic(err)
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(raw, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: %s: %v", p, err))
+		}
+		cats[strings.TrimSuffix(path.Base(p), ".json")] = messages
+	}
+
+	if _, ok := cats[Default]; !ok {
+		panic("i18n: there is no catalogue for the default locale")
+	}
+
+	return cats
+}
+
+// tags lists the supported locales with the default first, which is what the
+// matcher falls back to.
+func tags() []language.Tag {
+	result := []language.Tag{language.Make(Default)}
+	for _, l := range Locales() {
+		if l != Default {
+			result = append(result, language.Make(l))
+		}
+	}
+
+	return result
+}
+
+// Locales lists every locale with a catalogue, sorted.
+func Locales() []string {
+	var locales []string
+	for l := range catalogues {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+
+	return locales
+}
+
+// Supported checks whether there's a catalogue for locale.
+func Supported(locale string) bool {
+	_, ok := catalogues[locale]
+	return ok
+}
+
+// Resolve picks the supported locale for a list of preferences, most important
+// first. Each one can be a language tag such as "fr-CA" or a whole Accept-Language
+// header. Empty and unsupported preferences are skipped, and Default is used if
+// none are left.
+func Resolve(preferences ...string) string {
+	for _, p := range preferences {
+		if p == "" {
+			continue
+		}
+
+		wanted, _, err := language.ParseAcceptLanguage(p)
+		if err != nil || len(wanted) == 0 {
+			continue
+		}
+
+		if tag, _, confidence := matcher.Match(wanted...); confidence != language.No {
+			base, _ := tag.Base()
+			return base.String()
+		}
+	}
+
+	return Default
+}
+
+// Translate finds the message for key in locale's catalogue, falling back to the
+// default catalogue. Messages can refer to params like text templates, e.g.
+// "must be at least {{.min}}".
+func Translate(locale, key string, params map[string]interface{}) (string, bool) {
+	msg, ok := catalogues[locale][key]
+	if !ok {
+		if msg, ok = catalogues[Default][key]; !ok {
+			return "", false
+		}
+	}
+
+	if len(params) == 0 || !strings.Contains(msg, "{{") {
+		return msg, true
+	}
+
+	tmpl, err := template.New(key).Parse(msg)
+	if err != nil {
+		return msg, true
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, params); err != nil {
+		return msg, true
+	}
+
+	return b.String(), true
+}
+
+// T is Translate for messages without params, giving back the key when there's no
+// message for it.
+func T(locale, key string) string {
+	if msg, ok := Translate(locale, key, nil); ok {
+		return msg
+	}
+
+	return key
+}
+
+// Catalogue returns the keys and messages for locale.
+func Catalogue(locale string) map[string]string {
+	return catalogues[locale]
+}
+
+type contextKey struct{}
+
+// WithLocale records the locale for a request.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// FromContext returns the locale for a request, or Default if there isn't one.
+func FromContext(ctx context.Context) string {
+	if l, ok := ctx.Value(contextKey{}).(string); ok {
+		return l
+	}
+
+	return Default
+}
+
+// Middleware picks the locale of every request from its Accept-Language header.
+// Clients should send the locale of the user's session.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale := Resolve(r.Header.Get("Accept-Language"))
+
+		w.Header().Add("Vary", "Accept-Language")
+		w.Header().Set("Content-Language", locale)
+
+		next.ServeHTTP(w, r.WithContext(WithLocale(r.Context(), locale)))
+	})
+}
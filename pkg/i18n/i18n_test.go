@@ -0,0 +1,81 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCatalogues(t *testing.T) {
+	for _, locale := range Locales() {
+		for key := range Catalogue(Default) {
+			if _, ok := Catalogue(locale)[key]; !ok {
+				t.Errorf("Expected %s to have a message for %s", locale, key)
+			}
+		}
+
+		for key := range Catalogue(locale) {
+			if _, ok := Catalogue(Default)[key]; !ok {
+				t.Errorf("Expected %s in %s to also be in %s", key, locale, Default)
+			}
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	cases := []struct {
+		preferences []string
+		want        string
+	}{
+		{nil, "en"},
+		{[]string{"fr"}, "fr"},
+		{[]string{"fr-CA"}, "fr"},
+		{[]string{"", "fr"}, "fr"},
+		{[]string{"de", "fr"}, "fr"},
+		{[]string{"de;q=0.9, fr;q=0.8"}, "fr"},
+		{[]string{"de"}, "en"},
+		{[]string{"not a tag"}, "en"},
+	}
+
+	for _, c := range cases {
+		if got := Resolve(c.preferences...); got != c.want {
+			t.Errorf("Expected %v to resolve to %s, got %s", c.preferences, c.want, got)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	msg, ok := Translate("fr", "field.validation_length_out_of_range", map[string]interface{}{"min": 2, "max": 20})
+	if !ok || msg != "la longueur doit être comprise entre 2 et 20" {
+		t.Errorf("Expected the params in the message, got %q", msg)
+	}
+
+	if msg, ok := Translate("de", "mail.from", nil); !ok || msg != "From" {
+		t.Errorf("Expected unsupported locales to fall back to %s, got %q", Default, msg)
+	}
+
+	if _, ok := Translate("fr", "missing.key", nil); ok {
+		t.Error("Expected missing keys not to be found")
+	}
+
+	if msg := T("fr", "missing.key"); msg != "missing.key" {
+		t.Errorf("Expected T to give back missing keys, got %q", msg)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var locale string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "fr-FR,fr;q=0.9,en;q=0.8")
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	if locale != "fr" || res.Header().Get("Content-Language") != "fr" {
+		t.Errorf("Expected the request to be in fr, got %q", locale)
+	}
+}
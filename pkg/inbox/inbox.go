@@ -0,0 +1,184 @@
+package inbox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"tsaron.com/godview-starter/pkg/events"
+	"tsaron.com/godview-starter/pkg/joinrequests"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/preferences"
+	"tsaron.com/godview-starter/pkg/users"
+)
+
+// EventCreated is the type of the message pushed to a user for a new notification.
+const EventCreated = "notification.created"
+
+// Pusher delivers messages to the connected clients of a user.
+type Pusher interface {
+	Push(ctx context.Context, user uint, eventType string, data interface{}) error
+}
+
+// categories says which preferences apply to each kind of notification.
+var categories = map[string]string{
+	KindMemberJoined:  notification.CategoryMembers,
+	KindJoinRequested: notification.CategoryMembers,
+}
+
+// Inbox creates notifications and pushes them to their recipients.
+type Inbox struct {
+	repo   *Repo
+	prefs  *preferences.Repo
+	pusher Pusher
+}
+
+// New creates an inbox. pusher can be nil, in which case clients only see new
+// notifications when they next list them.
+func New(repo *Repo, prefs *preferences.Repo, pusher Pusher) *Inbox {
+	return &Inbox{repo, prefs, pusher}
+}
+
+// Notify creates a notification for n.Recipient. It returns nil if they turned off
+// notifications like n.
+func (i *Inbox) Notify(ctx context.Context, n Notification) (*Notification, error) {
+	created, err := i.create(ctx, []Notification{n})
+	if err != nil || len(created) == 0 {
+		return nil, err
+	}
+
+	return &created[0], nil
+}
+
+// NotifyAdmins creates a copy of n for every admin of its workspace, except the ones
+// listed in skip.
+func (i *Inbox) NotifyAdmins(ctx context.Context, n Notification, skip ...uint) ([]Notification, error) {
+	admins, err := i.repo.Admins(ctx, n.Workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	var ns []Notification
+	for _, id := range admins {
+		if contains(skip, id) {
+			continue
+		}
+
+		each := n
+		each.Recipient = id
+		ns = append(ns, each)
+	}
+
+	return i.create(ctx, ns)
+}
+
+// Handle turns workspace events admins should know about into notifications.
+func (i *Inbox) Handle(ctx context.Context, e events.Event) error {
+	switch e.Type {
+	case events.MemberJoined:
+		user, ok := e.Data.(*users.User)
+		if !ok {
+			return nil
+		}
+
+		name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+		if name == "" {
+			name = user.EmailAddress
+		}
+
+		_, err := i.NotifyAdmins(ctx, Notification{
+			Workspace: e.Workspace,
+			Kind:      KindMemberJoined,
+			Title:     fmt.Sprintf("%s joined the workspace", name),
+			Link:      fmt.Sprintf("/users/%d", user.ID),
+			Data:      map[string]interface{}{"user": user.ID},
+		}, user.ID)
+
+		return err
+	case events.JoinRequested:
+		req, ok := e.Data.(*joinrequests.Request)
+		if !ok {
+			return nil
+		}
+
+		_, err := i.NotifyAdmins(ctx, Notification{
+			Workspace: e.Workspace,
+			Kind:      KindJoinRequested,
+			Title:     fmt.Sprintf("%s asked to join the workspace", req.Name()),
+			Body:      req.EmailAddress,
+			Link:      fmt.Sprintf("/join-requests/%d", req.ID),
+			Data:      map[string]interface{}{"join_request": req.ID},
+		})
+
+		return err
+	}
+
+	return nil
+}
+
+// create saves notifications and pushes them, leaving out recipients who opted out
+// of them. They already exist once saved, so push errors are logged rather than
+// returned.
+func (i *Inbox) create(ctx context.Context, ns []Notification) ([]Notification, error) {
+	ns, err := i.wanted(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+
+	ns, err = i.repo.Create(ctx, ns)
+	if err != nil || i.pusher == nil {
+		return ns, err
+	}
+
+	for _, n := range ns {
+		if err := i.pusher.Push(ctx, n.Recipient, EventCreated, n); err != nil {
+			zerolog.Ctx(ctx).Err(err).Uint("recipient", n.Recipient).Msg("failed to push notification")
+		}
+	}
+
+	return ns, nil
+}
+
+// wanted drops the notifications whose recipients turned off their category in the
+// app.
+func (i *Inbox) wanted(ctx context.Context, ns []Notification) ([]Notification, error) {
+	var recipients []uint
+	for _, n := range ns {
+		recipients = append(recipients, n.Recipient)
+	}
+
+	var result []Notification
+	byCategory := make(map[string][]uint)
+	for _, n := range ns {
+		category := categories[n.Kind]
+		if category == "" {
+			result = append(result, n)
+			continue
+		}
+
+		if _, ok := byCategory[category]; !ok {
+			disabled, err := i.prefs.Disabled(ctx, recipients, category, notification.ChannelInApp)
+			if err != nil {
+				return nil, err
+			}
+			byCategory[category] = disabled
+		}
+
+		if !contains(byCategory[category], n.Recipient) {
+			result = append(result, n)
+		}
+	}
+
+	return result, nil
+}
+
+func contains(ids []uint, id uint) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,154 @@
+package inbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"tsaron.com/godview-starter/pkg/users"
+)
+
+const (
+	KindMemberJoined  = "member.joined"
+	KindJoinRequested = "member.join_requested"
+)
+
+// Notification is a message shown to a single user inside the app.
+type Notification struct {
+	tableName struct{} `pg:"notifications"`
+
+	ID        uint                   `json:"id"`
+	CreatedAt time.Time              `json:"created_at"`
+	Workspace uint                   `json:"workspace"`
+	Recipient uint                   `json:"recipient"`
+	Kind      string                 `json:"kind"`
+	Title     string                 `json:"title"`
+	Body      string                 `json:"body,omitempty"`
+	Link      string                 `json:"link,omitempty"`
+	Data      map[string]interface{} `json:"data"`
+	ReadAt    *time.Time             `json:"read_at"`
+}
+
+type Repo struct {
+	db *pg.DB
+}
+
+func NewRepo(db *pg.DB) *Repo {
+	return &Repo{db}
+}
+
+// Create saves notifications, filling in their IDs.
+func (r *Repo) Create(ctx context.Context, ns []Notification) ([]Notification, error) {
+	if len(ns) == 0 {
+		return ns, nil
+	}
+
+	for i := range ns {
+		if ns[i].Data == nil {
+			ns[i].Data = map[string]interface{}{}
+		}
+	}
+
+	_, err := r.db.
+		ModelContext(ctx, &ns).
+		Returning("*").
+		Insert(&ns)
+
+	return ns, err
+}
+
+// List returns a page of a user's notifications, unread ones first and then newest
+// first.
+func (r *Repo) List(ctx context.Context, recipient uint, offset, limit int) ([]Notification, error) {
+	var ns []Notification
+
+	err := r.db.
+		ModelContext(ctx, &ns).
+		Where("recipient = ?", recipient).
+		OrderExpr("read_at is not null").
+		Order("created_at desc", "id desc").
+		Offset(offset).
+		Limit(limit).
+		Select()
+
+	return ns, err
+}
+
+// Unread counts a user's unread notifications.
+func (r *Repo) Unread(ctx context.Context, recipient uint) (int, error) {
+	return r.db.
+		ModelContext(ctx, (*Notification)(nil)).
+		Where("recipient = ?", recipient).
+		Where("read_at is null").
+		Count()
+}
+
+// MarkRead marks one of a user's notifications as read. Returns nil if the user has no
+// such notification
+func (r *Repo) MarkRead(ctx context.Context, recipient, id uint) (*Notification, error) {
+	n := new(Notification)
+
+	_, err := r.db.
+		ModelContext(ctx, n).
+		Set("read_at = coalesce(read_at, now())").
+		Where("id = ?", id).
+		Where("recipient = ?", recipient).
+		Returning("*").
+		Update()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return n, err
+}
+
+// MarkAllRead marks every unread notification of a user as read, returning how many
+// there were.
+func (r *Repo) MarkAllRead(ctx context.Context, recipient uint) (int, error) {
+	res, err := r.db.
+		ModelContext(ctx, (*Notification)(nil)).
+		Set("read_at = now()").
+		Where("recipient = ?", recipient).
+		Where("read_at is null").
+		Update()
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// Delete removes one of a user's notifications. Returns nil if the user has no such
+// notification
+func (r *Repo) Delete(ctx context.Context, recipient, id uint) (*Notification, error) {
+	n := new(Notification)
+
+	_, err := r.db.
+		ModelContext(ctx, n).
+		Where("id = ?", id).
+		Where("recipient = ?", recipient).
+		Returning("*").
+		Delete()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return n, err
+}
+
+// Admins lists the IDs of the admins and owners of a workspace.
+func (r *Repo) Admins(ctx context.Context, workspace uint) ([]uint, error) {
+	var ids []uint
+
+	err := r.db.
+		ModelContext(ctx, (*users.User)(nil)).
+		Column("id").
+		Where("workspace = ?", workspace).
+		Where("role in (?)", pg.In([]string{users.RoleAdmin, users.RoleOwner})).
+		Order("id").
+		Select(&ids)
+
+	return ids, err
+}
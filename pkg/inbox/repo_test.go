@@ -0,0 +1,191 @@
+package inbox
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/tsaron/anansi"
+	"github.com/tsaron/anansi/postgres"
+	"syreclabs.com/go/faker"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/events"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/preferences"
+	"tsaron.com/godview-starter/pkg/users"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+var testDB *pg.DB
+
+func afterEach(t *testing.T) {
+	if err := postgres.CleanUpTables(testDB, "notifications", "users", "workspaces"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	var err error
+
+	var env config.Env
+	if err = anansi.LoadEnv(&env); err != nil {
+		panic(err)
+	}
+
+	log := anansi.NewLogger(env.Name)
+
+	if testDB, err = config.SetupDB(env); err != nil {
+		panic(err)
+	}
+	log.Info().Msg("Successfully connected to postgres")
+
+	code := m.Run()
+
+	if err := testDB.Close(); err != nil {
+		log.Err(err).Msg("Failed to disconnect from postgres cleanly")
+	}
+
+	os.Exit(code)
+}
+
+type pushed struct {
+	user uint
+	n    Notification
+}
+
+type recorder []pushed
+
+func (r *recorder) Push(ctx context.Context, user uint, eventType string, data interface{}) error {
+	*r = append(*r, pushed{user, data.(Notification)})
+	return nil
+}
+
+func setup(t *testing.T, roles ...string) (*workspaces.Workspace, []users.User) {
+	ctx := context.TODO()
+
+	wk, err := workspaces.NewRepo(testDB).Create(ctx, faker.Company().Name(), faker.Internet().Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reqs []users.UserRequest
+	for _, role := range roles {
+		reqs = append(reqs, users.UserRequest{EmailAddress: faker.Internet().Email(), Role: role})
+	}
+
+	members, err := users.NewRepo(testDB).CreateMany(ctx, wk.ID, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return wk, members
+}
+
+func TestRepoList(t *testing.T) {
+	defer afterEach(t)
+
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+
+	wk, members := setup(t, users.RoleMember)
+	recipient := members[0].ID
+
+	var ns []Notification
+	for _, title := range []string{"first", "second", "third"} {
+		ns = append(ns, Notification{Workspace: wk.ID, Recipient: recipient, Kind: "test", Title: title})
+	}
+
+	ns, err := repo.Create(ctx, ns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.MarkRead(ctx, recipient, ns[2].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := repo.List(ctx, recipient, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 3 || list[0].Title != "second" || list[1].Title != "first" || list[2].Title != "third" {
+		t.Errorf("Expected unread notifications first, newest first, got %v", list)
+	}
+
+	unread, err := repo.Unread(ctx, recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if unread != 2 {
+		t.Errorf("Expected 2 unread notifications, got %d", unread)
+	}
+
+	count, err := repo.MarkAllRead(ctx, recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 2 {
+		t.Errorf("Expected 2 notifications to be marked read, got %d", count)
+	}
+}
+
+func TestRepoOwnership(t *testing.T) {
+	defer afterEach(t)
+
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+
+	wk, members := setup(t, users.RoleMember, users.RoleMember)
+
+	ns, err := repo.Create(ctx, []Notification{{Workspace: wk.ID, Recipient: members[0].ID, Kind: "test", Title: "mine"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := repo.MarkRead(ctx, members[1].ID, ns[0].ID); err != nil || n != nil {
+		t.Errorf("Expected other users not to mark the notification read, got %v, %v", n, err)
+	}
+
+	if n, err := repo.Delete(ctx, members[1].ID, ns[0].ID); err != nil || n != nil {
+		t.Errorf("Expected other users not to delete the notification, got %v, %v", n, err)
+	}
+
+	if n, err := repo.Delete(ctx, members[0].ID, ns[0].ID); err != nil || n == nil {
+		t.Errorf("Expected the recipient to delete the notification, got %v, %v", n, err)
+	}
+}
+
+func TestInboxNotifyAdmins(t *testing.T) {
+	defer afterEach(t)
+
+	ctx := context.TODO()
+	var pushes recorder
+	prefs := preferences.NewRepo(testDB)
+	box := New(NewRepo(testDB), prefs, &pushes)
+
+	wk, members := setup(t, users.RoleOwner, users.RoleAdmin, users.RoleAdmin, users.RoleMember)
+
+	err := prefs.Set(ctx, members[2].ID, []preferences.Preference{
+		{Category: notification.CategoryMembers, Channel: notification.ChannelInApp, Enabled: false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = box.Handle(ctx, events.Event{Type: events.MemberJoined, Workspace: wk.ID, Data: &members[3]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pushes) != 2 || pushes[0].user != members[0].ID || pushes[1].user != members[1].ID {
+		t.Fatalf("Expected the admins who didn't opt out to be notified, got %v", pushes)
+	}
+
+	if pushes[0].n.ID == 0 || pushes[0].n.Kind != KindMemberJoined {
+		t.Errorf("Expected the saved notification to be pushed, got %v", pushes[0].n)
+	}
+}
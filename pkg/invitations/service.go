@@ -1,25 +1,53 @@
 package invitations
 
 import (
-	"fmt"
+	"context"
 
 	"tsaron.com/godview-starter/pkg/notification"
 )
 
-func SendInvitation(mailer notification.Mailer, route string, iv Invitation) error {
-	data := struct {
-		Route       string
-		Token       string
-		CompanyName string
-	}{
-		route,
-		iv.Token,
-		iv.CompanyName,
+// InvitationMail is the data of the invitation template.
+type InvitationMail struct {
+	Route       string
+	Token       string
+	CompanyName string
+}
+
+// RequestMail is the data of the request template, which asks the admins of a
+// workspace to let someone in.
+type RequestMail struct {
+	Route        string
+	Name         string
+	EmailAddress string
+	CompanyName  string
+}
+
+func init() {
+	notification.Declare("invitation", InvitationMail{
+		Route:       "https://app.example.com/onboarding/invitations",
+		Token:       "sample",
+		CompanyName: "Acme",
+	})
+	notification.Declare("request", RequestMail{
+		Route:        "https://app.example.com/requests/1",
+		Name:         "Jane Doe",
+		EmailAddress: "jane@example.com",
+		CompanyName:  "Acme",
+	})
+}
+
+func SendInvitation(ctx context.Context, mailer notification.Mailer, route string, iv Invitation, brand notification.Branding, locale string) error {
+	data := InvitationMail{
+		Route:       route,
+		Token:       iv.Token,
+		CompanyName: iv.CompanyName,
 	}
 
-	return mailer.Send(notification.TemplateMail{
+	return mailer.Send(ctx, notification.TemplateMail{
 		Sender:        notification.SenderPostmaster,
-		Subject:       fmt.Sprintf("Invitation to %s", iv.CompanyName),
+		Branding:      brand,
+		Locale:        locale,
+		Workspace:     iv.Workspace,
 		ReceiverName:  "",
 		ReceiverEmail: iv.EmailAddress,
 		Template:      "invitation",
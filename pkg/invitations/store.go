@@ -2,29 +2,45 @@ package invitations
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/tsaron/anansi/tokens"
 )
 
-var ErrExpired = tokens.ErrTokenNotFound
+var (
+	ErrExpired         = errors.New("invitation has expired")
+	ErrExtendedTooMuch = errors.New("invitation can't be extended again")
+)
 
 type Invitation struct {
 	Workspace    uint   `json:"workspace"`
 	CompanyName  string `json:"company_name"`
 	EmailAddress string `json:"email_address"`
 	Token        string `json:"token"`
+	Extensions   int    `json:"extensions"`
 }
 
+// extend counts an extension of an invitation, keeping the count for as long as the
+// invitation lives.
+var extend = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+redis.call("PEXPIRE", KEYS[1], ARGV[1])
+return n
+`)
+
 type Store struct {
 	tStore *tokens.Store
+	redis  *redis.Client
 }
 
-func NewStore(tStore *tokens.Store) *Store {
-	return &Store{tStore}
+func NewStore(tStore *tokens.Store, redis *redis.Client) *Store {
+	return &Store{tStore, redis}
 }
 
-func (s *Store) Create(ctx context.Context, wkpID uint, wkpName string, email string) (Invitation, error) {
+// Create invites email to a workspace for the given time.
+func (s *Store) Create(ctx context.Context, wkpID uint, wkpName string, email string, ttl time.Duration) (Invitation, error) {
 	iv := Invitation{
 		Workspace:    wkpID,
 		CompanyName:  wkpName,
@@ -32,7 +48,7 @@ func (s *Store) Create(ctx context.Context, wkpID uint, wkpName string, email st
 	}
 
 	var err error
-	iv.Token, err = s.tStore.Commission(ctx, time.Hour*48, email, iv)
+	iv.Token, err = s.tStore.Commission(ctx, ttl, email, iv)
 	if err != nil {
 		return Invitation{}, err
 	}
@@ -40,20 +56,50 @@ func (s *Store) Create(ctx context.Context, wkpID uint, wkpName string, email st
 	return iv, err
 }
 
-func (s *Store) Extend(ctx context.Context, token string) (Invitation, error) {
-	var iv Invitation
-	err := s.tStore.Extend(ctx, token, time.Hour, &iv)
+// Extend keeps an invitation alive for the given time from now, unless it has already
+// been extended max times. Extensions are counted in Redis before anything else, so
+// concurrent extends can't get past max.
+func (s *Store) Extend(ctx context.Context, token string, by time.Duration, max int) (Invitation, error) {
+	iv, err := s.View(ctx, token)
+	if err != nil {
+		return Invitation{}, err
+	}
 
-	return iv, err
+	n, err := extend.Run(ctx, s.redis, []string{"invitation-extensions:" + token}, by.Milliseconds()).Int()
+	if err != nil {
+		return Invitation{}, err
+	}
+
+	if n > max {
+		return Invitation{}, ErrExtendedTooMuch
+	}
+
+	// only for showing, the count in Redis is the one that's enforced
+	iv.Extensions = n
+	if err := s.tStore.Reset(ctx, iv.EmailAddress, iv); err != nil {
+		return Invitation{}, expired(err)
+	}
+
+	err = s.tStore.Extend(ctx, token, by, &iv)
+
+	return iv, expired(err)
 }
 
 func (s *Store) View(ctx context.Context, token string) (Invitation, error) {
 	var iv Invitation
 	err := s.tStore.Peek(ctx, token, &iv)
 
-	return iv, err
+	return iv, expired(err)
 }
 
 func (s *Store) Revoke(ctx context.Context, key string) error {
 	return s.tStore.Revoke(ctx, key)
 }
+
+func expired(err error) error {
+	if errors.Is(err, tokens.ErrTokenNotFound) {
+		return ErrExpired
+	}
+
+	return err
+}
@@ -0,0 +1,111 @@
+package invitations
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/tsaron/anansi"
+	"github.com/tsaron/anansi/tokens"
+	"tsaron.com/godview-starter/pkg/config"
+)
+
+var store *Store
+var mem *redis.Client
+
+func afterEach(t *testing.T) {
+	if _, err := mem.FlushDB(context.TODO()).Result(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	var err error
+
+	var env config.Env
+	if err = anansi.LoadEnv(&env); err != nil {
+		panic(err)
+	}
+
+	if mem, err = config.SetupRedis(context.TODO(), env); err != nil {
+		panic(err)
+	}
+	store = NewStore(tokens.NewStore(mem, env.Secret), mem)
+
+	code := m.Run()
+
+	if err := mem.Close(); err != nil {
+		panic(err)
+	}
+
+	os.Exit(code)
+}
+
+func TestStoreExtend(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("keeps the invitation alive for the new time", func(t *testing.T) {
+		defer afterEach(t)
+
+		iv, err := store.Create(ctx, 1, "Acme", "jane@example.com", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		extended, err := store.Extend(ctx, iv.Token, time.Hour, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if extended.Extensions != 1 {
+			t.Errorf("Expected 1 extension, got %d", extended.Extensions)
+		}
+
+		ttl, err := mem.TTL(ctx, iv.Token).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ttl <= time.Minute || ttl > time.Hour {
+			t.Errorf("Expected the invitation to last about an hour, got %v", ttl)
+		}
+	})
+
+	t.Run("stops at the maximum extensions", func(t *testing.T) {
+		defer afterEach(t)
+
+		iv, err := store.Create(ctx, 1, "Acme", "jane@example.com", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 2; i++ {
+			if _, err := store.Extend(ctx, iv.Token, time.Hour, 2); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := store.Extend(ctx, iv.Token, time.Hour, 2); err != ErrExtendedTooMuch {
+			t.Errorf("Expected %v, got %v", ErrExtendedTooMuch, err)
+		}
+
+		viewed, err := store.View(ctx, iv.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if viewed.Extensions != 2 {
+			t.Errorf("Expected the invitation to show 2 extensions, got %d", viewed.Extensions)
+		}
+	})
+
+	t.Run("fails for invitations that don't exist", func(t *testing.T) {
+		defer afterEach(t)
+
+		if _, err := store.Extend(ctx, "missing", time.Hour, 2); err != ErrExpired {
+			t.Errorf("Expected %v, got %v", ErrExpired, err)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package joinlinks
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"tsaron.com/godview-starter/pkg/users"
+)
+
+var (
+	// ErrUnavailable is returned for links that don't exist, expired, were revoked or
+	// are used up.
+	ErrUnavailable = errors.New("join link is no longer available")
+	// ErrDomain is returned when an address isn't at the domain a link requires.
+	ErrDomain = errors.New("email address isn't at the domain the join link requires")
+)
+
+// Link lets anyone who has it join a workspace, up to a number of times.
+type Link struct {
+	tableName struct{} `pg:"join_links"`
+
+	ID        uint       `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Workspace uint       `json:"workspace"`
+	CreatedBy uint       `json:"created_by,omitempty"`
+	Token     string     `json:"token"`
+	Role      string     `json:"role"`
+	MaxUses   int        `json:"max_uses"`
+	Uses      int        `json:"uses" pg:",use_zero"`
+	Domain    string     `json:"domain,omitempty"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Available checks whether the link can still be used.
+func (l *Link) Available() bool {
+	return l.RevokedAt == nil && l.Uses < l.MaxUses && time.Now().Before(l.ExpiresAt)
+}
+
+// Allows checks whether an address is at the domain the link requires, if any.
+func (l *Link) Allows(email string) bool {
+	if l.Domain == "" {
+		return true
+	}
+
+	return strings.HasSuffix(strings.ToLower(email), "@"+l.Domain)
+}
+
+type Repo struct {
+	db *pg.DB
+}
+
+func NewRepo(db *pg.DB) *Repo {
+	return &Repo{db}
+}
+
+// Create saves a new link.
+func (r *Repo) Create(ctx context.Context, l *Link) (*Link, error) {
+	_, err := r.db.
+		ModelContext(ctx, l).
+		Returning("*").
+		Insert(l)
+
+	return l, err
+}
+
+// List returns a page of a workspace's links, newest first.
+func (r *Repo) List(ctx context.Context, workspace uint, offset, limit int) ([]Link, error) {
+	var links []Link
+
+	err := r.db.
+		ModelContext(ctx, &links).
+		Where("workspace = ?", workspace).
+		Order("created_at desc", "id desc").
+		Offset(offset).
+		Limit(limit).
+		Select()
+
+	return links, err
+}
+
+// Find returns the link with the given token. Returns nil if the link doesn't exist
+func (r *Repo) Find(ctx context.Context, token string) (*Link, error) {
+	l := new(Link)
+	err := r.db.
+		ModelContext(ctx, l).
+		Where("token = ?", token).
+		Select()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return l, err
+}
+
+// Revoke stops a link of a workspace from being used. Returns nil if the link doesn't exist
+func (r *Repo) Revoke(ctx context.Context, workspace, id uint) (*Link, error) {
+	l := new(Link)
+	_, err := r.db.
+		ModelContext(ctx, l).
+		Set("revoked_at = coalesce(revoked_at, now())").
+		Where("id = ?", id).
+		Where("workspace = ?", workspace).
+		Returning("*").
+		Update()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return l, err
+}
+
+// Claim uses up one use of the link with the given token for email, for users.Repo.Join.
+func (r *Repo) Claim(ctx context.Context, token, email string) users.Claim {
+	return func(tx *pg.Tx) (uint, string, error) {
+		l := new(Link)
+		err := tx.
+			ModelContext(ctx, l).
+			Where("token = ?", token).
+			For("UPDATE").
+			Select()
+
+		if err == pg.ErrNoRows {
+			return 0, "", ErrUnavailable
+		}
+
+		if err != nil {
+			return 0, "", err
+		}
+
+		if !l.Available() {
+			return 0, "", ErrUnavailable
+		}
+
+		if !l.Allows(email) {
+			return 0, "", ErrDomain
+		}
+
+		_, err = tx.
+			ModelContext(ctx, l).
+			Set("uses = uses + 1").
+			WherePK().
+			Update()
+
+		return l.Workspace, l.Role, err
+	}
+}
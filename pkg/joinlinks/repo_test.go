@@ -0,0 +1,164 @@
+package joinlinks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/tsaron/anansi"
+	"github.com/tsaron/anansi/postgres"
+	"syreclabs.com/go/faker"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/users"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+var testDB *pg.DB
+
+func afterEach(t *testing.T) {
+	if err := postgres.CleanUpTables(testDB, "join_links", "users", "workspaces"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	var err error
+
+	var env config.Env
+	if err = anansi.LoadEnv(&env); err != nil {
+		panic(err)
+	}
+
+	log := anansi.NewLogger(env.Name)
+
+	if testDB, err = config.SetupDB(env); err != nil {
+		panic(err)
+	}
+	log.Info().Msg("Successfully connected to postgres")
+
+	code := m.Run()
+
+	if err := testDB.Close(); err != nil {
+		log.Err(err).Msg("Failed to disconnect from postgres cleanly")
+	}
+
+	os.Exit(code)
+}
+
+func TestRepoClaim(t *testing.T) {
+	repo := NewRepo(testDB)
+	uRepo := users.NewRepo(testDB)
+	ctx := context.TODO()
+
+	wk, err := workspaces.NewRepo(testDB).Create(ctx, faker.Company().Name(), faker.Internet().Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer afterEach(t)
+
+	link, err := repo.Create(ctx, &Link{
+		Workspace: wk.ID,
+		Token:     faker.RandomString(32),
+		Role:      users.RoleMember,
+		MaxUses:   2,
+		Domain:    "example.com",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	join := func(email string) (*users.User, error) {
+		return uRepo.Join(ctx, email, repo.Claim(ctx, link.Token, email))
+	}
+
+	uses := func(t *testing.T) int {
+		l, err := repo.Find(ctx, link.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return l.Uses
+	}
+
+	t.Run("creates a user with the link's role", func(t *testing.T) {
+		user, err := join("jane@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if user.Workspace != wk.ID || user.Role != users.RoleMember {
+			t.Errorf("Expected a member of the workspace, got %v", user)
+		}
+
+		if n := uses(t); n != 1 {
+			t.Errorf("Expected the link to have been used once, got %d", n)
+		}
+	})
+
+	t.Run("doesn't use up the link when the user can't be created", func(t *testing.T) {
+		if _, err := join("jane@example.com"); !errors.As(err, new(users.ErrEmail)) {
+			t.Errorf("Expected the address to be taken, got %v", err)
+		}
+
+		if _, err := join("jane@example.org"); err != ErrDomain {
+			t.Errorf("Expected %v, got %v", ErrDomain, err)
+		}
+
+		if n := uses(t); n != 1 {
+			t.Errorf("Expected the link to have been used once, got %d", n)
+		}
+	})
+
+	t.Run("stops at the maximum uses", func(t *testing.T) {
+		if _, err := join("john@example.com"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := join("joan@example.com"); err != ErrUnavailable {
+			t.Errorf("Expected %v, got %v", ErrUnavailable, err)
+		}
+	})
+}
+
+func TestRepoRevoke(t *testing.T) {
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+
+	wk, err := workspaces.NewRepo(testDB).Create(ctx, faker.Company().Name(), faker.Internet().Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer afterEach(t)
+
+	link, err := repo.Create(ctx, &Link{
+		Workspace: wk.ID,
+		Token:     faker.RandomString(32),
+		Role:      users.RoleMember,
+		MaxUses:   10,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l, err := repo.Revoke(ctx, wk.ID+1, link.ID); err != nil || l != nil {
+		t.Errorf("Expected links of other workspaces to be left alone, got %v, %v", l, err)
+	}
+
+	l, err := repo.Revoke(ctx, wk.ID, link.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l.RevokedAt == nil || l.Available() {
+		t.Errorf("Expected the link to be revoked, got %v", l)
+	}
+
+	email := faker.Internet().Email()
+	if _, err := users.NewRepo(testDB).Join(ctx, email, repo.Claim(ctx, link.Token, email)); err != ErrUnavailable {
+		t.Errorf("Expected %v, got %v", ErrUnavailable, err)
+	}
+}
@@ -0,0 +1,159 @@
+package joinrequests
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/tsaron/anansi/postgres"
+	"tsaron.com/godview-starter/pkg/users"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusDenied   = "denied"
+)
+
+var (
+	// ErrPending is returned when someone asks to join a workspace they're already
+	// waiting on.
+	ErrPending = errors.New("there's already a pending request to join the workspace")
+	// ErrDecided is returned when approving or denying a request that isn't pending.
+	ErrDecided = errors.New("join request has already been decided")
+)
+
+// Request is someone asking the admins of a workspace to let them in.
+type Request struct {
+	tableName struct{} `pg:"join_requests"`
+
+	ID           uint       `json:"id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	Workspace    uint       `json:"workspace"`
+	EmailAddress string     `json:"email_address"`
+	FirstName    string     `json:"first_name"`
+	LastName     string     `json:"last_name"`
+	Status       string     `json:"status"`
+	Role         string     `json:"role,omitempty"`
+	DecidedBy    uint       `json:"decided_by,omitempty"`
+	DecidedAt    *time.Time `json:"decided_at,omitempty"`
+}
+
+// Name is how the requester introduced themselves.
+func (r *Request) Name() string {
+	return r.FirstName + " " + r.LastName
+}
+
+type Repo struct {
+	db *pg.DB
+}
+
+func NewRepo(db *pg.DB) *Repo {
+	return &Repo{db}
+}
+
+// Create saves a pending request.
+func (r *Repo) Create(ctx context.Context, req *Request) (*Request, error) {
+	req.Status = StatusPending
+
+	_, err := r.db.
+		ModelContext(ctx, req).
+		Returning("*").
+		Insert(req)
+
+	if err != nil && postgres.ErrDuplicate.MatchString(err.Error()) {
+		return nil, ErrPending
+	}
+
+	return req, err
+}
+
+// List returns a page of a workspace's requests, newest first. An empty status lists
+// requests of every status.
+func (r *Repo) List(ctx context.Context, workspace uint, status string, offset, limit int) ([]Request, error) {
+	var requests []Request
+
+	err := r.db.
+		ModelContext(ctx, &requests).
+		Where("workspace = ?", workspace).
+		Apply(func(q *orm.Query) (*orm.Query, error) {
+			if status != "" {
+				q = q.Where("status = ?", status)
+			}
+			return q, nil
+		}).
+		Order("created_at desc", "id desc").
+		Offset(offset).
+		Limit(limit).
+		Select()
+
+	return requests, err
+}
+
+// Get returns a request to join a workspace. Returns nil if the request doesn't exist
+func (r *Repo) Get(ctx context.Context, workspace, id uint) (*Request, error) {
+	req := new(Request)
+	err := r.db.
+		ModelContext(ctx, req).
+		Where("id = ?", id).
+		Where("workspace = ?", workspace).
+		Select()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return req, err
+}
+
+// Deny turns down a pending request.
+func (r *Repo) Deny(ctx context.Context, workspace, id, by uint) (*Request, error) {
+	req := new(Request)
+	_, err := r.db.
+		ModelContext(ctx, req).
+		Set("status = ?", StatusDenied).
+		Set("decided_by = ?", by).
+		Set("decided_at = now()").
+		Where("id = ?", id).
+		Where("workspace = ?", workspace).
+		Where("status = ?", StatusPending).
+		Returning("*").
+		Update()
+
+	if err == pg.ErrNoRows {
+		return nil, ErrDecided
+	}
+
+	return req, err
+}
+
+// Approve accepts a pending request with the given role, for users.Repo.Join to turn
+// the requester into a user.
+func (r *Repo) Approve(ctx context.Context, workspace, id, by uint, role string) users.Claim {
+	return func(tx *pg.Tx) (uint, string, error) {
+		req := new(Request)
+		_, err := tx.
+			ModelContext(ctx, req).
+			Set("status = ?", StatusApproved).
+			Set("role = ?", role).
+			Set("decided_by = ?", by).
+			Set("decided_at = now()").
+			Where("id = ?", id).
+			Where("workspace = ?", workspace).
+			Where("status = ?", StatusPending).
+			Returning("*").
+			Update()
+
+		if err == pg.ErrNoRows {
+			return 0, "", ErrDecided
+		}
+
+		if err != nil {
+			return 0, "", err
+		}
+
+		return req.Workspace, req.Role, nil
+	}
+}
@@ -0,0 +1,152 @@
+package joinrequests
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/tsaron/anansi"
+	"github.com/tsaron/anansi/postgres"
+	"syreclabs.com/go/faker"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/users"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+var testDB *pg.DB
+
+func afterEach(t *testing.T) {
+	if err := postgres.CleanUpTables(testDB, "join_requests", "users", "workspaces"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	var err error
+
+	var env config.Env
+	if err = anansi.LoadEnv(&env); err != nil {
+		panic(err)
+	}
+
+	log := anansi.NewLogger(env.Name)
+
+	if testDB, err = config.SetupDB(env); err != nil {
+		panic(err)
+	}
+	log.Info().Msg("Successfully connected to postgres")
+
+	code := m.Run()
+
+	if err := testDB.Close(); err != nil {
+		log.Err(err).Msg("Failed to disconnect from postgres cleanly")
+	}
+
+	os.Exit(code)
+}
+
+func TestRepoDecide(t *testing.T) {
+	repo := NewRepo(testDB)
+	uRepo := users.NewRepo(testDB)
+	ctx := context.TODO()
+
+	wk, err := workspaces.NewRepo(testDB).Create(ctx, faker.Company().Name(), faker.Internet().Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer afterEach(t)
+
+	ask := func(t *testing.T) *Request {
+		req, err := repo.Create(ctx, &Request{
+			Workspace:    wk.ID,
+			EmailAddress: faker.Internet().Email(),
+			FirstName:    faker.Name().FirstName(),
+			LastName:     faker.Name().LastName(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	t.Run("only keeps one pending request per address", func(t *testing.T) {
+		req := ask(t)
+
+		_, err := repo.Create(ctx, &Request{
+			Workspace:    wk.ID,
+			EmailAddress: req.EmailAddress,
+			FirstName:    req.FirstName,
+			LastName:     req.LastName,
+		})
+		if err != ErrPending {
+			t.Errorf("Expected %v, got %v", ErrPending, err)
+		}
+	})
+
+	t.Run("approves a request into a user", func(t *testing.T) {
+		req := ask(t)
+
+		user, err := uRepo.JoinNamed(ctx, req.EmailAddress, req.FirstName, req.LastName, repo.Approve(ctx, wk.ID, req.ID, 0, users.RoleAdmin))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if user.Workspace != wk.ID || user.Role != users.RoleAdmin || user.EmailAddress != req.EmailAddress {
+			t.Errorf("Expected the requester to be an admin of the workspace, got %v", user)
+		}
+
+		if user.FirstName != req.FirstName || user.LastName != req.LastName {
+			t.Errorf("Expected the user to have the requester's name, got %v", user)
+		}
+
+		if req, err = repo.Get(ctx, wk.ID, req.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if req.Status != StatusApproved || req.Role != users.RoleAdmin || req.DecidedAt == nil {
+			t.Errorf("Expected the request to be approved, got %v", req)
+		}
+
+		if _, err := repo.Deny(ctx, wk.ID, req.ID, 0); err != ErrDecided {
+			t.Errorf("Expected %v, got %v", ErrDecided, err)
+		}
+	})
+
+	t.Run("denies a request", func(t *testing.T) {
+		req := ask(t)
+
+		req, err := repo.Deny(ctx, wk.ID, req.ID, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if req.Status != StatusDenied {
+			t.Errorf("Expected the request to be denied, got %s", req.Status)
+		}
+
+		_, err = uRepo.Join(ctx, req.EmailAddress, repo.Approve(ctx, wk.ID, req.ID, 0, users.RoleMember))
+		if err != ErrDecided {
+			t.Errorf("Expected %v, got %v", ErrDecided, err)
+		}
+
+		if user, _ := uRepo.GetByEmail(ctx, req.EmailAddress); user != nil {
+			t.Errorf("Expected no user for a denied request, got %v", user)
+		}
+	})
+
+	t.Run("lists requests by status", func(t *testing.T) {
+		requests, err := repo.List(ctx, wk.ID, StatusPending, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(requests) != 1 {
+			t.Errorf("Expected one pending request, got %d", len(requests))
+		}
+
+		if requests, _ = repo.List(ctx, wk.ID, "", 0, 10); len(requests) != 3 {
+			t.Errorf("Expected three requests, got %d", len(requests))
+		}
+	})
+}
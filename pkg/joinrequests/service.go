@@ -0,0 +1,106 @@
+package joinrequests
+
+import (
+	"context"
+	"fmt"
+
+	"tsaron.com/godview-starter/pkg/invitations"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/users"
+)
+
+// ApprovedMail is the data of the request-approved template, which invites the
+// requester to set up their profile.
+type ApprovedMail struct {
+	Route       string
+	Token       string
+	FirstName   string
+	CompanyName string
+}
+
+// DeniedMail is the data of the request-denied template.
+type DeniedMail struct {
+	FirstName   string
+	CompanyName string
+}
+
+func init() {
+	notification.Declare("request-approved", ApprovedMail{
+		Route:       "https://app.example.com/onboarding/invitations",
+		Token:       "sample",
+		FirstName:   "Jane",
+		CompanyName: "Acme",
+	})
+	notification.Declare("request-denied", DeniedMail{
+		FirstName:   "Jane",
+		CompanyName: "Acme",
+	})
+}
+
+// SendRequest asks an admin to review a request, in their own language if they chose
+// one.
+func SendRequest(ctx context.Context, mailer notification.Mailer, route string, req *Request, company string, admin users.User, brand notification.Branding, locale string) error {
+	if admin.Locale != "" {
+		locale = admin.Locale
+	}
+
+	data := invitations.RequestMail{
+		Route:        fmt.Sprintf("%s/%d", route, req.ID),
+		Name:         req.Name(),
+		EmailAddress: req.EmailAddress,
+		CompanyName:  company,
+	}
+
+	return mailer.Send(ctx, notification.TemplateMail{
+		Sender:        notification.SenderNotify,
+		Branding:      brand,
+		Locale:        locale,
+		Workspace:     req.Workspace,
+		Category:      notification.CategoryMembers,
+		ReceiverName:  fmt.Sprintf("%s %s", admin.FirstName, admin.LastName),
+		ReceiverEmail: admin.EmailAddress,
+		Template:      "request",
+		TemplateData:  data,
+	})
+}
+
+// SendApproved tells the requester they can join, with the invitation to set up
+// their profile.
+func SendApproved(ctx context.Context, mailer notification.Mailer, route string, req *Request, iv invitations.Invitation, brand notification.Branding, locale string) error {
+	data := ApprovedMail{
+		Route:       route,
+		Token:       iv.Token,
+		FirstName:   req.FirstName,
+		CompanyName: iv.CompanyName,
+	}
+
+	return mailer.Send(ctx, notification.TemplateMail{
+		Sender:        notification.SenderPostmaster,
+		Branding:      brand,
+		Locale:        locale,
+		Workspace:     req.Workspace,
+		ReceiverName:  req.Name(),
+		ReceiverEmail: req.EmailAddress,
+		Template:      "request-approved",
+		TemplateData:  data,
+	})
+}
+
+// SendDenied tells the requester they weren't let in.
+func SendDenied(ctx context.Context, mailer notification.Mailer, req *Request, company string, brand notification.Branding, locale string) error {
+	data := DeniedMail{
+		FirstName:   req.FirstName,
+		CompanyName: company,
+	}
+
+	return mailer.Send(ctx, notification.TemplateMail{
+		Sender:        notification.SenderPostmaster,
+		Branding:      brand,
+		Locale:        locale,
+		Workspace:     req.Workspace,
+		ReceiverName:  req.Name(),
+		ReceiverEmail: req.EmailAddress,
+		Template:      "request-denied",
+		TemplateData:  data,
+	})
+}
@@ -0,0 +1,152 @@
+package maillog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"tsaron.com/godview-starter/pkg/notification"
+)
+
+const (
+	// MailIDArg is the custom arg that ties provider events to the logged message.
+	MailIDArg = "mail_id"
+	// ResentFromArg marks a mail as a resend of the logged message it names.
+	ResentFromArg = "resent_from"
+)
+
+// ErrNotResendable is returned for mail whose data had secrets, which aren't logged.
+var ErrNotResendable = errors.New("mail carried secrets and can't be resent")
+
+type mailer struct {
+	next notification.Mailer
+	repo *Repo
+}
+
+// Mailer records every mail sent through next, so events about it can be tracked
+// and it can be sent again.
+func Mailer(next notification.Mailer, repo *Repo) notification.Mailer {
+	return &mailer{next, repo}
+}
+
+func (m *mailer) Send(ctx context.Context, mail notification.TemplateMail) error {
+	data, err := dataOf(mail.TemplateData)
+	if err != nil {
+		return err
+	}
+
+	msg := &Message{
+		Workspace:    mail.Workspace,
+		Template:     mail.Template,
+		Category:     mail.Category,
+		Receiver:     mail.ReceiverEmail,
+		ReceiverName: mail.ReceiverName,
+		Locale:       mail.Locale,
+		Data:         data,
+		Status:       StatusSent,
+		StatusAt:     time.Now(),
+	}
+	if mail.Sender != nil {
+		msg.Sender = mail.Sender.Address
+	}
+	if id, err := parseID(mail.CustomArgs[ResentFromArg]); err == nil {
+		msg.ResentFrom = id
+	}
+
+	if _, err := m.repo.Record(ctx, msg); err != nil {
+		return err
+	}
+
+	args := map[string]string{MailIDArg: strconv.FormatUint(uint64(msg.ID), 10)}
+	for k, v := range mail.CustomArgs {
+		args[k] = v
+	}
+	mail.CustomArgs = args
+
+	receipt := mail.Receipt
+	if receipt == nil {
+		receipt = new(notification.Receipt)
+		mail.Receipt = receipt
+	}
+
+	sendErr := m.next.Send(ctx, mail)
+	if sendErr == nil {
+		if receipt.MessageID == "" {
+			return nil
+		}
+
+		return m.repo.Accepted(ctx, msg.ID, receipt.MessageID)
+	}
+
+	status := StatusFailed
+	if errors.Is(sendErr, notification.ErrSuppressed) {
+		status = StatusSuppressed
+	}
+
+	if err := m.repo.Failed(ctx, msg.ID, status, sendErr); err != nil {
+		return errors.Join(sendErr, err)
+	}
+
+	return sendErr
+}
+
+// Resendable reports whether mail from a template can be sent again from the log.
+// Templates with secrets, such as the tokens of one-time links, can't, since their
+// secrets aren't logged and would be stale or used up by the time of a resend anyway.
+func Resendable(template string) bool {
+	sample, _ := notification.Sample(template)
+
+	data, _ := fieldsOf(sample)
+	for k := range data {
+		if secret(k) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dataOf keeps template data as the JSON object templates would see after a resend,
+// without its secrets.
+func dataOf(v interface{}) (map[string]interface{}, error) {
+	data, err := fieldsOf(v)
+	if err != nil {
+		return nil, err
+	}
+
+	for k := range data {
+		if secret(k) {
+			delete(data, k)
+		}
+	}
+
+	return data, nil
+}
+
+// secret reports whether a field of template data is a token, which can sign people
+// in or change their account.
+func secret(field string) bool {
+	return strings.HasSuffix(strings.ToLower(field), "token")
+}
+
+func fieldsOf(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		// only objects can be sent again, anything else is left out of the log
+		return nil, nil
+	}
+
+	return data, nil
+}
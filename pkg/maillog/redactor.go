@@ -0,0 +1,56 @@
+package maillog
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Redactor removes the personal details of messages once they're older than the
+// retention period.
+type Redactor struct {
+	repo      *Repo
+	retention time.Duration
+	interval  time.Duration
+	log       zerolog.Logger
+}
+
+func NewRedactor(repo *Repo, retention time.Duration, log zerolog.Logger) *Redactor {
+	return &Redactor{
+		repo:      repo,
+		retention: retention,
+		interval:  time.Hour,
+		log:       log.With().Str("worker", "maillog").Logger(),
+	}
+}
+
+// Run redacts old messages every interval until the context is cancelled.
+func (r *Redactor) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.redact(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Redactor) redact(ctx context.Context) {
+	n, err := r.repo.Redact(ctx, time.Now().Add(-r.retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			r.log.Err(err).Msg("failed to redact mail log")
+		}
+		return
+	}
+
+	if n > 0 {
+		r.log.Info().Int("messages", n).Msg("redacted mail log")
+	}
+}
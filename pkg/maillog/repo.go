@@ -0,0 +1,349 @@
+package maillog
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"tsaron.com/godview-starter/pkg/notification"
+)
+
+const (
+	StatusSent       = "sent"
+	StatusFailed     = "failed"
+	StatusSuppressed = "suppressed"
+	StatusDelivered  = "delivered"
+	StatusBounced    = "bounced"
+	StatusDropped    = "dropped"
+	StatusSpamReport = "spam_report"
+)
+
+// Undelivered lists the statuses of mail that never reached its receiver.
+var Undelivered = []string{StatusFailed, StatusSuppressed, StatusBounced, StatusDropped, StatusSpamReport}
+
+// statuses maps the provider's events to the statuses they leave a mail in.
+var statuses = map[string]string{
+	notification.EventDelivered:  StatusDelivered,
+	notification.EventBounce:     StatusBounced,
+	notification.EventDropped:    StatusDropped,
+	notification.EventSpamReport: StatusSpamReport,
+}
+
+// Message is a mail we sent, or tried to. Once redacted, the receiver, data and
+// errors of a message are gone and it can't be resent.
+type Message struct {
+	tableName struct{} `pg:"mail_messages"`
+
+	ID           uint                   `json:"id"`
+	CreatedAt    time.Time              `json:"created_at"`
+	Workspace    uint                   `json:"workspace,omitempty"`
+	Template     string                 `json:"template"`
+	Category     string                 `json:"category,omitempty"`
+	Sender       string                 `json:"sender"`
+	Receiver     string                 `json:"receiver"`
+	ReceiverName string                 `json:"receiver_name,omitempty"`
+	Locale       string                 `json:"locale,omitempty"`
+	Data         map[string]interface{} `json:"-"`
+	ProviderID   string                 `json:"provider_id,omitempty"`
+	Status       string                 `json:"status"`
+	StatusAt     time.Time              `json:"status_at"`
+	Reason       string                 `json:"reason,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+	ResentFrom   uint                   `json:"resent_from,omitempty"`
+	RedactedAt   *time.Time             `json:"redacted_at,omitempty"`
+}
+
+// Filter narrows down the messages of a workspace. Zero values are ignored.
+type Filter struct {
+	Workspace uint
+	Receiver  string
+	Template  string
+	Status    string
+	From      time.Time
+	To        time.Time
+}
+
+// Suppression is an address we no longer send mail to.
+type Suppression struct {
+	tableName struct{} `pg:"mail_suppressions"`
+
+	EmailAddress string    `json:"email_address" pg:",pk"`
+	CreatedAt    time.Time `json:"created_at"`
+	Reason       string    `json:"reason"`
+}
+
+type Repo struct {
+	db *pg.DB
+}
+
+func NewRepo(db *pg.DB) *Repo {
+	return &Repo{db}
+}
+
+// Record saves a message.
+func (r *Repo) Record(ctx context.Context, m *Message) (*Message, error) {
+	// provider events only have whole seconds, and one in the same second as the send
+	// is still newer
+	m.StatusAt = m.StatusAt.Truncate(time.Second)
+	// filters look for receivers in lower case
+	m.Receiver = strings.ToLower(m.Receiver)
+
+	_, err := r.db.
+		ModelContext(ctx, m).
+		Returning("*").
+		Insert(m)
+
+	return m, err
+}
+
+// Get returns a message of a workspace. Returns nil if the message doesn't exist
+func (r *Repo) Get(ctx context.Context, workspace, id uint) (*Message, error) {
+	m := new(Message)
+	err := r.db.
+		ModelContext(ctx, m).
+		Where("id = ?", id).
+		Where("workspace = ?", workspace).
+		Select()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return m, err
+}
+
+// List returns a page of messages matching the filter, newest first.
+func (r *Repo) List(ctx context.Context, f Filter, offset, limit int) ([]Message, error) {
+	var messages []Message
+
+	err := r.db.
+		ModelContext(ctx, &messages).
+		Apply(f.apply).
+		Order("created_at desc", "id desc").
+		Offset(offset).
+		Limit(limit).
+		Select()
+
+	return messages, err
+}
+
+// LastResend returns the latest message sent again from the given one since a time.
+// Returns nil if it wasn't resent then
+func (r *Repo) LastResend(ctx context.Context, id uint, since time.Time) (*Message, error) {
+	m := new(Message)
+	err := r.db.
+		ModelContext(ctx, m).
+		Where("resent_from = ?", id).
+		Where("created_at >= ?", since).
+		Order("id desc").
+		Limit(1).
+		Select()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return m, err
+}
+
+// Accepted records the provider's ID for a message it took.
+func (r *Repo) Accepted(ctx context.Context, id uint, providerID string) error {
+	_, err := r.db.
+		ModelContext(ctx, (*Message)(nil)).
+		Set("provider_id = ?", nullable(providerID)).
+		Where("id = ?", id).
+		Update()
+
+	return err
+}
+
+// Failed records why a message couldn't be sent.
+func (r *Repo) Failed(ctx context.Context, id uint, status string, sendErr error) error {
+	_, err := r.db.
+		ModelContext(ctx, (*Message)(nil)).
+		Set("status = ?", status).
+		Set("status_at = date_trunc('second', now())").
+		Set("error = ?", sendErr.Error()).
+		Where("id = ?", id).
+		Update()
+
+	return err
+}
+
+// Redact removes the personal details of messages sent before a time, returning how
+// many there were.
+func (r *Repo) Redact(ctx context.Context, before time.Time) (int, error) {
+	res, err := r.db.
+		ModelContext(ctx, (*Message)(nil)).
+		Set("receiver = ''").
+		Set("receiver_name = null").
+		Set("data = null").
+		Set("reason = null").
+		Set("error = null").
+		Set("redacted_at = now()").
+		Where("created_at < ?", before).
+		Where("redacted_at is null").
+		Update()
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// SetStatus moves a message to a new status, unless it already has a newer one.
+func (r *Repo) SetStatus(ctx context.Context, id uint, status, reason string, at time.Time) error {
+	_, err := r.db.
+		ModelContext(ctx, (*Message)(nil)).
+		Set("status = ?", status).
+		Set("status_at = ?", at).
+		Set("reason = ?", nullable(reason)).
+		Where("id = ?", id).
+		Where("status_at <= ?", at).
+		Update()
+
+	return err
+}
+
+// Undelivered returns a page of a workspace's mails from a template that never
+// reached their receivers, newest first.
+func (r *Repo) Undelivered(ctx context.Context, workspace uint, template string, offset, limit int) ([]Message, error) {
+	var messages []Message
+
+	err := r.db.
+		ModelContext(ctx, &messages).
+		Where("workspace = ?", workspace).
+		Where("template = ?", template).
+		Where("status in (?)", pg.In(Undelivered)).
+		Order("created_at desc", "id desc").
+		Offset(offset).
+		Limit(limit).
+		Select()
+
+	return messages, err
+}
+
+// Apply records a provider event against the mail it's about, and suppresses
+// addresses that hard bounced or reported us as spam.
+func (r *Repo) Apply(ctx context.Context, e notification.Event) error {
+	status, ok := statuses[e.Event]
+	if !ok {
+		return nil
+	}
+
+	// soft bounces may yet be delivered
+	if e.Event == notification.EventBounce && e.Type == "blocked" {
+		return nil
+	}
+
+	id, err := parseID(e.MailID)
+	if err != nil {
+		// mail sent before it was tagged with its ID can still be found by the provider's
+		if id, err = r.byProviderID(ctx, e.MessageID); err != nil {
+			return err
+		}
+	}
+
+	if id != 0 {
+		if err := r.SetStatus(ctx, id, status, e.Reason, e.Time()); err != nil {
+			return err
+		}
+	}
+
+	switch status {
+	case StatusBounced, StatusSpamReport:
+		reason := status
+		if e.Reason != "" {
+			reason += ": " + e.Reason
+		}
+
+		return r.Suppress(ctx, e.Email, reason)
+	}
+
+	return nil
+}
+
+// Suppress stops mail to an address.
+func (r *Repo) Suppress(ctx context.Context, address, reason string) error {
+	s := &Suppression{EmailAddress: strings.ToLower(address), Reason: reason}
+
+	_, err := r.db.
+		ModelContext(ctx, s).
+		OnConflict("(email_address) DO NOTHING").
+		Insert()
+
+	return err
+}
+
+// Suppressed checks whether mail to an address is suppressed.
+func (r *Repo) Suppressed(ctx context.Context, address string) (bool, error) {
+	return r.db.
+		ModelContext(ctx, (*Suppression)(nil)).
+		Where("email_address = ?", strings.ToLower(address)).
+		Exists()
+}
+
+// byProviderID finds the message an sg_message_id is about, which starts with the
+// provider ID. Returns 0 if there's no such message.
+func (r *Repo) byProviderID(ctx context.Context, sgMessageID string) (uint, error) {
+	providerID := strings.SplitN(sgMessageID, ".", 2)[0]
+	if providerID == "" {
+		return 0, nil
+	}
+
+	var id uint
+	err := r.db.
+		ModelContext(ctx, (*Message)(nil)).
+		Column("id").
+		Where("provider_id = ?", providerID).
+		Limit(1).
+		Select(&id)
+
+	if err == pg.ErrNoRows {
+		return 0, nil
+	}
+
+	return id, err
+}
+
+func (f Filter) apply(q *orm.Query) (*orm.Query, error) {
+	q = q.Where("workspace = ?", f.Workspace)
+
+	if f.Receiver != "" {
+		q = q.Where("receiver = ?", strings.ToLower(f.Receiver))
+	}
+
+	if f.Template != "" {
+		q = q.Where("template = ?", f.Template)
+	}
+
+	if f.Status != "" {
+		q = q.Where("status = ?", f.Status)
+	}
+
+	if !f.From.IsZero() {
+		q = q.Where("created_at >= ?", f.From)
+	}
+
+	if !f.To.IsZero() {
+		q = q.Where("created_at < ?", f.To)
+	}
+
+	return q, nil
+}
+
+func parseID(s string) (uint, error) {
+	id, err := strconv.ParseUint(s, 10, 64)
+	return uint(id), err
+}
+
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
@@ -0,0 +1,316 @@
+package maillog
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"github.com/tsaron/anansi"
+	"github.com/tsaron/anansi/postgres"
+	"syreclabs.com/go/faker"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+var testDB *pg.DB
+
+func afterEach(t *testing.T) {
+	if err := postgres.CleanUpTables(testDB, "mail_messages", "mail_suppressions", "workspaces"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	var err error
+
+	var env config.Env
+	if err = anansi.LoadEnv(&env); err != nil {
+		panic(err)
+	}
+
+	log := anansi.NewLogger(env.Name)
+
+	if testDB, err = config.SetupDB(env); err != nil {
+		panic(err)
+	}
+	log.Info().Msg("Successfully connected to postgres")
+
+	code := m.Run()
+
+	if err := testDB.Close(); err != nil {
+		log.Err(err).Msg("Failed to disconnect from postgres cleanly")
+	}
+
+	os.Exit(code)
+}
+
+type stubMailer struct {
+	err       error
+	messageID string
+	sent      []notification.TemplateMail
+}
+
+func (s *stubMailer) Send(ctx context.Context, m notification.TemplateMail) error {
+	s.sent = append(s.sent, m)
+	if s.err == nil && m.Receipt != nil {
+		m.Receipt.MessageID = s.messageID
+	}
+	return s.err
+}
+
+func TestRepoApply(t *testing.T) {
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+
+	wk, err := workspaces.NewRepo(testDB).Create(ctx, faker.Company().Name(), faker.Internet().Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer afterEach(t)
+
+	send := func(t *testing.T) (*Message, *stubMailer) {
+		stub := &stubMailer{}
+		err := Mailer(stub, repo).Send(ctx, notification.TemplateMail{
+			Workspace:     wk.ID,
+			Template:      "invitation",
+			ReceiverEmail: faker.Internet().Email(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		id, _ := parseID(stub.sent[0].CustomArgs[MailIDArg])
+		return &Message{ID: id, Receiver: stub.sent[0].ReceiverEmail}, stub
+	}
+
+	t.Run("suppresses hard bounces", func(t *testing.T) {
+		msg, _ := send(t)
+
+		err := repo.Apply(ctx, notification.Event{
+			Email:     msg.Receiver,
+			Timestamp: time.Now().Add(time.Minute).Unix(),
+			Event:     notification.EventBounce,
+			Type:      "bounce",
+			Reason:    "550 no such user",
+			MailID:    strconv.Itoa(int(msg.ID)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bounced, err := repo.Undelivered(ctx, wk.ID, "invitation", 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(bounced) != 1 || bounced[0].ID != msg.ID || bounced[0].Status != StatusBounced {
+			t.Errorf("Expected the invitation to have bounced, got %v", bounced)
+		}
+
+		if suppressed, err := repo.Suppressed(ctx, msg.Receiver); err != nil || !suppressed {
+			t.Errorf("Expected %s to be suppressed, got %v", msg.Receiver, err)
+		}
+	})
+
+	t.Run("applies events from the second the mail was sent", func(t *testing.T) {
+		msg, _ := send(t)
+
+		err := repo.Apply(ctx, notification.Event{
+			Email:     msg.Receiver,
+			Timestamp: time.Now().Unix(),
+			Event:     notification.EventDropped,
+			MailID:    strconv.Itoa(int(msg.ID)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bounced, err := repo.Undelivered(ctx, wk.ID, "invitation", 0, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(bounced) != 1 || bounced[0].ID != msg.ID || bounced[0].Status != StatusDropped {
+			t.Errorf("Expected the invitation to have been dropped, got %v", bounced)
+		}
+	})
+
+	t.Run("ignores soft bounces and stale events", func(t *testing.T) {
+		msg, _ := send(t)
+		id := strconv.Itoa(int(msg.ID))
+
+		events := []notification.Event{
+			{Email: msg.Receiver, Timestamp: time.Now().Add(time.Minute).Unix(), Event: notification.EventBounce, Type: "blocked", MailID: id},
+			{Email: msg.Receiver, Timestamp: time.Now().Add(time.Minute * 2).Unix(), Event: notification.EventDelivered, MailID: id},
+			{Email: msg.Receiver, Timestamp: time.Now().Add(-time.Hour).Unix(), Event: notification.EventDropped, MailID: id},
+		}
+		for _, e := range events {
+			if err := repo.Apply(ctx, e); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if suppressed, _ := repo.Suppressed(ctx, msg.Receiver); suppressed {
+			t.Errorf("Expected %s not to be suppressed", msg.Receiver)
+		}
+
+		bounced, err := repo.Undelivered(ctx, wk.ID, "invitation", 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, b := range bounced {
+			if b.ID == msg.ID {
+				t.Errorf("Expected the delivered invitation not to be listed, got %v", b)
+			}
+		}
+	})
+
+	t.Run("records suppressed mail", func(t *testing.T) {
+		stub := &stubMailer{err: notification.ErrSuppressed}
+		err := Mailer(stub, repo).Send(ctx, notification.TemplateMail{
+			Workspace:     wk.ID,
+			Template:      "invitation",
+			ReceiverEmail: faker.Internet().Email(),
+		})
+		if !errors.Is(err, notification.ErrSuppressed) {
+			t.Fatalf("Expected the mailer's error, got %v", err)
+		}
+
+		bounced, err := repo.Undelivered(ctx, wk.ID, "invitation", 0, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(bounced) != 1 || bounced[0].Status != StatusSuppressed {
+			t.Errorf("Expected the suppressed invitation, got %v", bounced)
+		}
+	})
+}
+
+func TestRepoList(t *testing.T) {
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+
+	wk, err := workspaces.NewRepo(testDB).Create(ctx, faker.Company().Name(), faker.Internet().Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer afterEach(t)
+
+	receiver := faker.Internet().Email()
+	stub := &stubMailer{messageID: faker.RandomString(22)}
+	sentTo := strings.ToUpper(receiver[:1]) + receiver[1:]
+	for _, template := range []string{"invitation", "password-reset"} {
+		err := Mailer(stub, repo).Send(ctx, notification.TemplateMail{
+			Sender:        mail.NewEmail("Godview", "postmaster@example.com"),
+			Workspace:     wk.ID,
+			Locale:        "fr",
+			Template:      template,
+			ReceiverName:  faker.Name().Name(),
+			ReceiverEmail: sentTo,
+			TemplateData:  struct{ Token, FirstName string }{"secret", "Jane"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("keeps what a resend needs", func(t *testing.T) {
+		messages, err := repo.List(ctx, Filter{Workspace: wk.ID, Template: "invitation"}, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(messages) != 1 {
+			t.Fatalf("Expected one invitation, got %d", len(messages))
+		}
+
+		msg := messages[0]
+		if msg.Sender != "postmaster@example.com" || msg.Locale != "fr" || msg.Data["FirstName"] != "Jane" {
+			t.Errorf("Expected the sender, locale and data of the mail, got %v", msg)
+		}
+
+		if _, ok := msg.Data["Token"]; ok {
+			t.Errorf("Expected the token to be left out of the log, got %v", msg.Data)
+		}
+
+		if msg.ProviderID != stub.messageID {
+			t.Errorf("Expected provider ID to be %s, got %s", stub.messageID, msg.ProviderID)
+		}
+	})
+
+	t.Run("filters by receiver", func(t *testing.T) {
+		messages, err := repo.List(ctx, Filter{Workspace: wk.ID, Receiver: sentTo}, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(messages) != 2 || messages[0].Template != "password-reset" {
+			t.Errorf("Expected both mails, newest first, got %v", messages)
+		}
+
+		messages, err = repo.List(ctx, Filter{Workspace: wk.ID, Receiver: faker.Internet().Email()}, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(messages) != 0 {
+			t.Errorf("Expected no mail for another receiver, got %v", messages)
+		}
+	})
+
+	t.Run("applies events by provider ID", func(t *testing.T) {
+		err := repo.Apply(ctx, notification.Event{
+			Email:     receiver,
+			Timestamp: time.Now().Add(time.Minute).Unix(),
+			Event:     notification.EventDelivered,
+			MessageID: stub.messageID + ".filter0001.16648.5515E0B88.0",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		messages, err := repo.List(ctx, Filter{Workspace: wk.ID, Status: StatusDelivered}, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(messages) != 1 {
+			t.Errorf("Expected one delivered mail, got %v", messages)
+		}
+	})
+
+	t.Run("redacts old messages", func(t *testing.T) {
+		n, err := repo.Redact(ctx, time.Now().Add(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n != 2 {
+			t.Errorf("Expected 2 messages to be redacted, got %d", n)
+		}
+
+		messages, err := repo.List(ctx, Filter{Workspace: wk.ID}, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, msg := range messages {
+			if msg.RedactedAt == nil || msg.Receiver != "" || msg.ReceiverName != "" || msg.Data != nil {
+				t.Errorf("Expected the personal details to be gone, got %v", msg)
+			}
+		}
+
+		if n, _ := repo.Redact(ctx, time.Now().Add(time.Minute)); n != 0 {
+			t.Errorf("Expected redacted messages to be left alone, got %d", n)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package notification
+
+// Branding is how a workspace's emails look. Empty fields fall back to
+// DefaultBranding.
+type Branding struct {
+	Name        string
+	LogoURL     string
+	AccentColor string
+	// ReplyTo is where replies go instead of the no-reply sender
+	ReplyTo string
+}
+
+// DefaultBranding is used for mail that doesn't belong to a workspace, and to fill
+// in whatever a workspace hasn't set.
+var DefaultBranding = Branding{
+	Name:        "Tsaron Tech",
+	LogoURL:     "https://gravitypro.tsaron.com/assets/logo.png",
+	AccentColor: "#37352f",
+}
+
+func (b Branding) withDefaults() Branding {
+	if b.Name == "" {
+		b.Name = DefaultBranding.Name
+	}
+
+	if b.LogoURL == "" {
+		b.LogoURL = DefaultBranding.LogoURL
+	}
+
+	if b.AccentColor == "" {
+		b.AccentColor = DefaultBranding.AccentColor
+	}
+
+	return b
+}
+
+// View is what mail templates are executed with. Templates can look up messages
+// for the locale with {{t .Locale "key"}}.
+type View struct {
+	Brand  Branding
+	Locale string
+	// Unsubscribe links to where the receiver can stop getting mails like this one
+	Unsubscribe string
+	Data        interface{}
+}
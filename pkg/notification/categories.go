@@ -0,0 +1,43 @@
+package notification
+
+// Channels notifications are delivered through.
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelInApp = "in_app"
+)
+
+// Channels lists every channel users can choose to receive a category on.
+var Channels = []string{ChannelEmail, ChannelSMS, ChannelInApp}
+
+// Categories group notifications so users can choose which ones they get.
+const (
+	// CategorySecurity covers password resets, email changes and verification codes
+	CategorySecurity = "security"
+	// CategoryMembers covers people joining or asking to join a workspace
+	CategoryMembers = "members"
+)
+
+// Category is a kind of notification users can turn off, unless it's locked.
+type Category struct {
+	Name   string `json:"name"`
+	Locked bool   `json:"locked"`
+}
+
+// Categories lists every category. Mail without a category, such as invitations to
+// people who don't have an account yet, is always sent.
+var Categories = []Category{
+	{Name: CategorySecurity, Locked: true},
+	{Name: CategoryMembers},
+}
+
+// Locked checks whether users are kept from turning off a category.
+func Locked(category string) bool {
+	for _, c := range Categories {
+		if c.Name == category {
+			return c.Locked
+		}
+	}
+
+	return false
+}
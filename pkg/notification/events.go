@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/sendgrid/sendgrid-go/helpers/eventwebhook"
+)
+
+// Kinds of mail events we keep track of.
+const (
+	EventDelivered  = "delivered"
+	EventBounce     = "bounce"
+	EventDropped    = "dropped"
+	EventSpamReport = "spamreport"
+)
+
+// Headers SendGrid signs event webhooks with.
+const (
+	EventSignatureHeader = eventwebhook.VerificationHTTPHeader
+	EventTimestampHeader = eventwebhook.TimestampHTTPHeader
+)
+
+var (
+	ErrInvalidEventSignature = errors.New("mail event signature is invalid")
+	ErrMalformedEvents       = errors.New("mail events are malformed")
+)
+
+// Event is something that happened to a mail after SendGrid accepted it. Custom
+// args of the mail, such as mail_id, come back as fields of the event.
+type Event struct {
+	Email     string `json:"email"`
+	Timestamp int64  `json:"timestamp"`
+	Event     string `json:"event"`
+	// Type is "bounce" for hard bounces and "blocked" for soft ones
+	Type      string `json:"type"`
+	Reason    string `json:"reason"`
+	MessageID string `json:"sg_message_id"`
+	MailID    string `json:"mail_id"`
+}
+
+// Time is when the event happened.
+func (e Event) Time() time.Time {
+	return time.Unix(e.Timestamp, 0)
+}
+
+// ParseEventKey reads the base64 public key SendGrid shows for a signed event webhook.
+func ParseEventKey(key string) (*ecdsa.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	ecKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("mail event key is not an ECDSA public key")
+	}
+
+	return ecKey, nil
+}
+
+// VerifyEvents checks the signature SendGrid sent with a batch of events, rejecting
+// batches signed more than tolerance ago, and parses them.
+func VerifyEvents(key *ecdsa.PublicKey, body []byte, signature, timestamp string, tolerance time.Duration) ([]Event, error) {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidEventSignature
+	}
+
+	if math.Abs(float64(time.Now().Unix()-unix)) > tolerance.Seconds() {
+		return nil, ErrInvalidEventSignature
+	}
+
+	ok, err := eventwebhook.VerifySignature(key, body, signature, timestamp)
+	if err != nil || !ok {
+		return nil, ErrInvalidEventSignature
+	}
+
+	var events []Event
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedEvents, err)
+	}
+
+	return events, nil
+}
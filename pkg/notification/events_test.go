@@ -0,0 +1,90 @@
+package notification
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signEvents(t *testing.T, key *ecdsa.PrivateKey, body []byte, at time.Time) (string, string) {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	digest := sha256.Sum256(append([]byte(ts), body...))
+
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(sig), ts
+}
+
+func TestVerifyEvents(t *testing.T) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ParseEventKey(base64.StdEncoding.EncodeToString(der))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := []byte(`[{"email":"jane@example.com","timestamp":1700000000,"event":"bounce","type":"bounce","reason":"550 no such user","mail_id":"12"}]`)
+
+	t.Run("parses signed events", func(t *testing.T) {
+		sig, ts := signEvents(t, private, body, time.Now())
+
+		events, err := VerifyEvents(key, body, sig, ts, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(events) != 1 || events[0].Event != EventBounce || events[0].MailID != "12" || events[0].Email != "jane@example.com" {
+			t.Errorf("Expected the bounce with its custom args, got %v", events)
+		}
+	})
+
+	t.Run("rejects changed bodies", func(t *testing.T) {
+		sig, ts := signEvents(t, private, body, time.Now())
+
+		changed := []byte(`[{"email":"john@example.com","event":"bounce"}]`)
+		if _, err := VerifyEvents(key, changed, sig, ts, time.Minute); err != ErrInvalidEventSignature {
+			t.Errorf("Expected the signature to fail, got %v", err)
+		}
+	})
+
+	t.Run("rejects signed bodies that aren't events", func(t *testing.T) {
+		malformed := []byte(`{"email":"jane@example.com"}`)
+		sig, ts := signEvents(t, private, malformed, time.Now())
+
+		if _, err := VerifyEvents(key, malformed, sig, ts, time.Minute); !errors.Is(err, ErrMalformedEvents) {
+			t.Errorf("Expected %v, got %v", ErrMalformedEvents, err)
+		}
+	})
+
+	t.Run("rejects old batches", func(t *testing.T) {
+		sig, ts := signEvents(t, private, body, time.Now().Add(-time.Hour))
+
+		if _, err := VerifyEvents(key, body, sig, ts, time.Minute); err != ErrInvalidEventSignature {
+			t.Errorf("Expected the old batch to fail, got %v", err)
+		}
+	})
+
+	t.Run("rejects keys that aren't ECDSA", func(t *testing.T) {
+		if _, err := ParseEventKey("bm90IGEga2V5"); err == nil {
+			t.Error("Expected the key to fail")
+		}
+	})
+}
@@ -1,8 +1,35 @@
 package notification
 
+import (
+	"context"
+	"sync"
+)
+
 type MailerMock struct {
 }
 
-func (n *MailerMock) Send(m TemplateMail) error {
+func (n *MailerMock) Send(ctx context.Context, m TemplateMail) error {
+	return nil
+}
+
+// SMSRecorder keeps every message sent through it so tests can inspect them.
+type SMSRecorder struct {
+	mu       sync.Mutex
+	messages []SMS
+}
+
+func (s *SMSRecorder) SendSMS(ctx context.Context, m SMS) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, m)
 	return nil
 }
+
+// Messages returns the messages sent so far, oldest first.
+func (s *SMSRecorder) Messages() []SMS {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]SMS(nil), s.messages...)
+}
@@ -1,32 +1,73 @@
 package notification
 
 import (
+	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
-	"html/template"
-	"io/ioutil"
+	"net/http"
 
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
 )
 
+// ErrUnknownTemplate is returned when a mail names a template that wasn't loaded.
+var ErrUnknownTemplate = errors.New("template doesn't exist")
+
+// ErrSuppressed is returned for mail to addresses that bounced or reported our mail
+// as spam.
+var ErrSuppressed = errors.New("address is on the suppression list")
+
 var (
 	SenderNotify     *mail.Email
 	SenderPostmaster *mail.Email
-
-	templatesNames = []string{"request", "invitation", "password-reset"}
 )
 
 type TemplateMail struct {
 	Sender *mail.Email
 
+	// Subject is used when the template has no subject line of its own
 	Subject string
 
+	// Branding of the workspace the mail is sent for
+	Branding Branding
+	// Locale the mail is written in, see i18n.Resolve
+	Locale string
+	// Workspace the mail is sent for, if any
+	Workspace uint
+	// Category decides whether the receiver's preferences apply to the mail
+	Category string
+	// UnsubscribeURL is shown at the bottom of mails the receiver can opt out of
+	UnsubscribeURL string
+
 	ReceiverName  string
 	ReceiverEmail string
 
 	Template     string
 	TemplateData interface{}
+
+	Attachments []Attachment
+	// Headers are extra headers such as List-Unsubscribe
+	Headers map[string]string
+	// CustomArgs come back with the provider's events about the mail
+	CustomArgs map[string]string
+	// Receipt, when set, is filled in once the provider accepts the mail
+	Receipt *Receipt
+}
+
+// Receipt is what the provider tells us about a mail it accepted.
+type Receipt struct {
+	// MessageID is the provider's ID for the mail, the start of sg_message_id in events
+	MessageID string
+}
+
+// Attachment is a file sent along with a mail. Inline attachments are shown in the
+// body where the HTML refers to cid:<ContentID>.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+	ContentID   string
 }
 
 type MailOpts struct {
@@ -34,55 +75,137 @@ type MailOpts struct {
 	Sender          string
 	NotifyEmail     string
 	PostmasterEmail string
-	TemplatePath    string
 }
 
 type Mailer interface {
-	Send(m TemplateMail) error
+	Send(ctx context.Context, m TemplateMail) error
 }
 
-type service struct {
-	client    *sendgrid.Client
-	templates map[string]*template.Template
+// SuppressionList knows the addresses mail shouldn't be sent to.
+type SuppressionList interface {
+	Suppressed(ctx context.Context, address string) (bool, error)
 }
 
-func New(opts MailOpts) Mailer {
-	templates := make(map[string]*template.Template)
-
-	for _, n := range templatesNames {
-		path := fmt.Sprintf("%s/%s.html", opts.TemplatePath, n)
-		templates[n] = FileTemplate(path)
-	}
+type service struct {
+	client       *sendgrid.Client
+	templates    *Templates
+	suppressions SuppressionList
+}
 
+// New creates a mailer that refuses to send to the addresses on suppressions, which
+// can be nil.
+func New(opts MailOpts, templates *Templates, suppressions SuppressionList) (Mailer, error) {
 	// mail senders
 	SenderNotify = mail.NewEmail(opts.Sender, opts.NotifyEmail)
+	SenderPostmaster = mail.NewEmail(opts.Sender, opts.PostmasterEmail)
 
 	// sendgrid client
 	client := sendgrid.NewSendClient(opts.Key)
 
-	return &service{client, templates}
+	return &service{client, templates, suppressions}, nil
 }
 
-func (s *service) Send(m TemplateMail) error {
-	htmlTmpl, ok := s.templates[m.Template]
-	if !ok {
-		msg := fmt.Sprintf("template with key \"%s\" doesn't exist", m.Template)
-		panic(errors.New(msg))
+// Ping confirms SendGrid is reachable and accepts the API key.
+func Ping(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.sendgrid.com/v3/scopes", nil)
+	if err != nil {
+		return err
 	}
+	req.Header.Set("Authorization", "Bearer "+key)
 
-	rcv := mail.NewEmail(m.ReceiverName, m.ReceiverEmail)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
 
-	buf, err := ioutil.ReadAll(ExecuteTemplate(htmlTmpl, m.TemplateData))
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("sendgrid responded with %s", res.Status)
+	}
+
+	return nil
+}
+
+func (s *service) Send(ctx context.Context, m TemplateMail) error {
+	if s.suppressions != nil {
+		suppressed, err := s.suppressions.Suppressed(ctx, m.ReceiverEmail)
+		if err != nil {
+			return err
+		}
+
+		if suppressed {
+			return fmt.Errorf("%w: %s", ErrSuppressed, m.ReceiverEmail)
+		}
+	}
+
+	msg, err := s.templates.render(m.Template, View{
+		Brand:       m.Branding,
+		Locale:      m.Locale,
+		Unsubscribe: m.UnsubscribeURL,
+		Data:        m.TemplateData,
+	})
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	rcv := mail.NewEmail(m.ReceiverName, m.ReceiverEmail)
+
+	// mail still comes from our address, but under the workspace's name
+	sender := m.Sender
+	if sender != nil && m.Branding.Name != "" {
+		sender = mail.NewEmail(m.Branding.Name, sender.Address)
+	}
+
+	subject := msg.Subject
+	if subject == "" {
+		subject = m.Subject
+	}
+
+	message := mail.NewSingleEmail(sender, subject, rcv, msg.Text, msg.HTML)
+	if m.Branding.ReplyTo != "" {
+		message.SetReplyTo(mail.NewEmail(m.Branding.Name, m.Branding.ReplyTo))
+	}
+
+	for k, v := range m.Headers {
+		message.SetHeader(k, v)
+	}
+
+	for k, v := range m.CustomArgs {
+		message.SetCustomArg(k, v)
+	}
+
+	for _, a := range m.Attachments {
+		message.AddAttachment(a.sendgrid())
 	}
 
-	message := mail.NewSingleEmail(m.Sender, m.Subject, rcv, "Placeolder Text", string(buf))
-	if res, err := s.client.Send(message); err != nil && res.StatusCode != 200 {
+	res, err := s.client.Send(message)
+	if err != nil {
 		return err
-	} else if res.StatusCode >= 400 {
+	}
+
+	if res.StatusCode >= 400 {
 		return errors.New(res.Body)
 	}
 
+	if m.Receipt != nil {
+		if ids := res.Headers["X-Message-Id"]; len(ids) > 0 {
+			m.Receipt.MessageID = ids[0]
+		}
+	}
+
 	return nil
 }
+
+func (a Attachment) sendgrid() *mail.Attachment {
+	att := mail.NewAttachment().
+		SetFilename(a.Filename).
+		SetType(a.ContentType).
+		SetContent(base64.StdEncoding.EncodeToString(a.Content)).
+		SetDisposition("attachment")
+
+	if a.ContentID != "" {
+		att.SetDisposition("inline").SetContentID(a.ContentID)
+	}
+
+	return att
+}
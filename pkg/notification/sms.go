@@ -0,0 +1,90 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type SMS struct {
+	To   string `json:"to"`
+	Body string `json:"body"`
+	// Category decides whether the receiver's preferences apply to the message
+	Category string `json:"-"`
+}
+
+type SMSSender interface {
+	SendSMS(ctx context.Context, m SMS) error
+}
+
+type SMSOpts struct {
+	URL    string
+	Key    string
+	Sender string
+}
+
+type httpSender struct {
+	client *http.Client
+	opts   SMSOpts
+}
+
+// NewHTTPSender creates a sender for SMS gateways that accept a JSON POST of the
+// sender, recipient and message, authenticated with a bearer key.
+func NewHTTPSender(opts SMSOpts) SMSSender {
+	return &httpSender{&http.Client{Timeout: time.Second * 10}, opts}
+}
+
+func (s *httpSender) SendSMS(ctx context.Context, m SMS) error {
+	raw, err := json.Marshal(map[string]string{
+		"from": s.opts.Sender,
+		"to":   m.To,
+		"body": m.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.opts.URL, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.opts.Key)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("sms provider responded with %s: %s", res.Status, body)
+	}
+
+	return nil
+}
+
+type logSender struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewLogSender creates a sender that writes messages to out instead of delivering
+// them, for development.
+func NewLogSender(out io.Writer) SMSSender {
+	return &logSender{out: out}
+}
+
+func (s *logSender) SendSMS(ctx context.Context, m SMS) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := fmt.Fprintf(s.out, "%s sms to %s: %s\n", time.Now().Format(time.RFC3339), m.To, m.Body)
+	return err
+}
@@ -0,0 +1,63 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPSender(t *testing.T) {
+	var received map[string]string
+	var auth string
+
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Fatal(err)
+		}
+
+		if received["to"] == "+2348000000000" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+	}))
+	defer gateway.Close()
+
+	sender := NewHTTPSender(SMSOpts{URL: gateway.URL, Key: "key", Sender: "Tsaron"})
+
+	t.Run("posts the message to the gateway", func(t *testing.T) {
+		err := sender.SendSMS(context.TODO(), SMS{To: "+2348031234567", Body: "Your code is 123456"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if auth != "Bearer key" {
+			t.Errorf("Expected the key as a bearer token, got %s", auth)
+		}
+
+		if received["from"] != "Tsaron" || received["to"] != "+2348031234567" || received["body"] != "Your code is 123456" {
+			t.Errorf("Expected the message to be sent as is, got %v", received)
+		}
+	})
+
+	t.Run("fails on error responses", func(t *testing.T) {
+		err := sender.SendSMS(context.TODO(), SMS{To: "+2348000000000", Body: "Your code is 123456"})
+		if err == nil || !strings.Contains(err.Error(), "422") {
+			t.Errorf("Expected the gateway's status in the error, got %v", err)
+		}
+	})
+}
+
+func TestLogSender(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewLogSender(&out).SendSMS(context.TODO(), SMS{To: "+2348031234567", Body: "Your code is 123456"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "sms to +2348031234567: Your code is 123456") {
+		t.Errorf("Expected the message to be logged, got %q", out.String())
+	}
+}
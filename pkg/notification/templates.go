@@ -2,29 +2,429 @@ package notification
 
 import (
 	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
 	"html/template"
-	"io/ioutil"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/rs/zerolog"
+	"tsaron.com/godview-starter/pkg/i18n"
 )
 
-func FileTemplate(path string) *template.Template {
-	raw, err := ioutil.ReadFile(path)
+//go:embed templates
+var embedded embed.FS
+
+// Embedded holds the templates that ship with the server.
+var Embedded = func() fs.FS {
+	sub, err := fs.Sub(embedded, "templates")
 	if err != nil {
 		panic(err)
 	}
+	return sub
+}()
 
-	var tmpl *template.Template
-	if tmpl, err = template.New(path).Parse(string(raw)); err != nil {
-		panic(err)
+// Templates holds the mail templates found in its sources. Every "<name>.html" at
+// the top of a source is a mail, rendered inside the "layout" template with the
+// templates in layouts/ and partials/. A mail may also have a plain text template,
+// "<name>.txt", and a subject line template, "<name>.subject.txt". Translations
+// put the locale before the extension, as in "<name>.fr.html".
+type Templates struct {
+	sources []fs.FS
+
+	mu  sync.RWMutex
+	set *templateSet
+}
+
+// templateSet keys templates by name, and by "<name>.<locale>" for translations.
+type templateSet struct {
+	names   map[string]bool
+	html    map[string]*template.Template
+	text    map[string]*texttemplate.Template
+	subject map[string]*texttemplate.Template
+}
+
+// Message is a rendered mail.
+type Message struct {
+	// Subject is empty for mails without a subject template
+	Subject string
+	HTML    string
+	Text    string
+}
+
+var (
+	declaredMu sync.RWMutex
+	declared   = make(map[string]interface{})
+)
+
+// Declare records sample data for the named template. The sample's type is what the
+// template is executed with, and the sample itself is used to validate and preview
+// it. Declaring a template twice panics.
+func Declare(name string, sample interface{}) {
+	declaredMu.Lock()
+	defer declaredMu.Unlock()
+
+	if _, ok := declared[name]; ok {
+		panic(fmt.Sprintf("notification: template %s declared twice", name))
+	}
+	declared[name] = sample
+}
+
+// Sample returns the sample data declared for the named template.
+func Sample(name string) (interface{}, bool) {
+	declaredMu.RLock()
+	defer declaredMu.RUnlock()
+
+	sample, ok := declared[name]
+	return sample, ok
+}
+
+// LoadTemplates parses the templates in sources. Files in earlier sources override
+// files at the same path in later ones, so a directory can be laid over Embedded.
+func LoadTemplates(sources ...fs.FS) (*Templates, error) {
+	set, err := parse(sources)
+	if err != nil {
+		return nil, err
 	}
 
-	return tmpl
+	return &Templates{sources: sources, set: set}, nil
 }
 
-func ExecuteTemplate(t *template.Template, data interface{}) *bytes.Buffer {
-	var buf bytes.Buffer
-	if err := t.Execute(&buf, data); err != nil {
-		panic(err)
+// Names lists every mail template, sorted.
+func (t *Templates) Names() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var names []string
+	for n := range t.set.names {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Validate checks that every template has declared data, every declaration has a
+// template, and that every template renders its sample.
+func (t *Templates) Validate() error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.set.validate()
+}
+
+// Reload parses the sources again, keeping the current templates if they no longer
+// parse or validate.
+func (t *Templates) Reload() error {
+	set, err := parse(t.sources)
+	if err != nil {
+		return err
+	}
+
+	if err := set.validate(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.set = set
+	t.mu.Unlock()
+
+	return nil
+}
+
+// Watch reloads the templates whenever a file in dir changes, checking every
+// interval until ctx is done. It's meant for working on templates in development.
+func (t *Templates) Watch(ctx context.Context, dir string, interval time.Duration, log zerolog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := fingerprint(dir)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := fingerprint(dir)
+			if current == last {
+				continue
+			}
+			last = current
+
+			if err := t.Reload(); err != nil {
+				log.Err(err).Msg("failed to reload mail templates")
+				continue
+			}
+			log.Info().Msg("reloaded mail templates")
+		}
+	}
+}
+
+// Render executes the named templates in locale with data, dressed in brand. Mails
+// that haven't been translated to locale are rendered from the untranslated
+// templates, and those without a text template get a text version of their HTML.
+func (t *Templates) Render(name, locale string, brand Branding, data interface{}) (Message, error) {
+	return t.render(name, View{Brand: brand, Locale: locale, Data: data})
+}
+
+func (t *Templates) render(name string, view View) (Message, error) {
+	t.mu.RLock()
+	set := t.set
+	t.mu.RUnlock()
+
+	if !set.names[name] {
+		return Message{}, fmt.Errorf("%w: %q", ErrUnknownTemplate, name)
+	}
+
+	key := name
+	if _, ok := set.html[name+"."+view.Locale]; ok {
+		key = name + "." + view.Locale
+	}
+
+	return set.render(key, name, view)
+}
+
+// render executes the templates at key, falling back to the subject line of name.
+func (s *templateSet) render(key, name string, view View) (Message, error) {
+	if view.Locale == "" {
+		view.Locale = i18n.Default
+	}
+	view.Brand = view.Brand.withDefaults()
+
+	var html bytes.Buffer
+	if err := s.html[key].ExecuteTemplate(&html, "layout", view); err != nil {
+		return Message{}, err
+	}
+	msg := Message{HTML: html.String()}
+
+	if textTmpl := s.text[key]; textTmpl != nil {
+		var buf bytes.Buffer
+		if err := textTmpl.Execute(&buf, view); err != nil {
+			return Message{}, err
+		}
+		msg.Text = buf.String()
+	} else {
+		msg.Text = HTMLToText(msg.HTML)
+	}
+
+	subjectTmpl := s.subject[key]
+	if subjectTmpl == nil {
+		subjectTmpl = s.subject[name]
+	}
+
+	if subjectTmpl != nil {
+		var buf bytes.Buffer
+		if err := subjectTmpl.Execute(&buf, view); err != nil {
+			return Message{}, err
+		}
+		// subjects are headers, so they have to stay on one line
+		msg.Subject = strings.Join(strings.Fields(buf.String()), " ")
+	}
+
+	return msg, nil
+}
+
+func (s *templateSet) validate() error {
+	declaredMu.RLock()
+	defer declaredMu.RUnlock()
+
+	var errs []error
+	for key := range s.html {
+		name, locale := splitLocale(key)
+		if !s.names[name] {
+			errs = append(errs, fmt.Errorf("template %s is a translation of a missing template", key))
+			continue
+		}
+
+		sample, ok := declared[name]
+		if !ok {
+			if locale == "" {
+				errs = append(errs, fmt.Errorf("template %s has no declared data", name))
+			}
+			continue
+		}
+
+		view := View{Locale: locale, Unsubscribe: "https://app.example.com/unsubscribe/sample", Data: sample}
+		if _, err := s.render(key, name, view); err != nil {
+			errs = append(errs, fmt.Errorf("template %s: %w", key, err))
+		}
+	}
+
+	for name := range declared {
+		if !s.names[name] {
+			errs = append(errs, fmt.Errorf("template %s is declared but missing", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func parse(sources []fs.FS) (*templateSet, error) {
+	base := template.New("mail").Funcs(template.FuncMap{"t": i18n.T})
+	for _, pattern := range []string{"layouts/*.html", "partials/*.html"} {
+		paths, err := glob(sources, pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range paths {
+			raw, err := readFile(sources, path)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, err := base.New(path).Parse(string(raw)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	pages, err := glob(sources, "*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	set := &templateSet{
+		names:   make(map[string]bool),
+		html:    make(map[string]*template.Template),
+		text:    make(map[string]*texttemplate.Template),
+		subject: make(map[string]*texttemplate.Template),
+	}
+
+	for _, path := range pages {
+		name := strings.TrimSuffix(path, ".html")
+		if base, locale := splitLocale(name); locale == "" {
+			set.names[base] = true
+		}
+
+		raw, err := readFile(sources, path)
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := page.New(path).Parse(string(raw)); err != nil {
+			return nil, err
+		}
+		set.html[name] = page
+
+		if set.text[name], err = optionalTextTemplate(sources, name+".txt"); err != nil {
+			return nil, err
+		}
+
+		if set.subject[name], err = optionalTextTemplate(sources, name+".subject.txt"); err != nil {
+			return nil, err
+		}
+	}
+
+	return set, nil
+}
+
+// splitLocale separates the locale from the key of a translated template.
+func splitLocale(key string) (string, string) {
+	i := strings.LastIndex(key, ".")
+	if i < 0 || !i18n.Supported(key[i+1:]) {
+		return key, ""
+	}
+
+	return key[:i], key[i+1:]
+}
+
+// glob lists the paths matching pattern across sources, without duplicates.
+func glob(sources []fs.FS, pattern string) ([]string, error) {
+	seen := make(map[string]bool)
+	var paths []string
+
+	for _, src := range sources {
+		matches, err := fs.Glob(src, pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				paths = append(paths, m)
+			}
+		}
+	}
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// readFile reads path from the first source that has it.
+func readFile(sources []fs.FS, path string) ([]byte, error) {
+	for _, src := range sources {
+		raw, err := fs.ReadFile(src, path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		return raw, err
+	}
+
+	return nil, fmt.Errorf("%s: %w", path, fs.ErrNotExist)
+}
+
+// optionalTextTemplate parses the text template at path, returning nil if no source
+// has it.
+func optionalTextTemplate(sources []fs.FS, path string) (*texttemplate.Template, error) {
+	raw, err := readFile(sources, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return texttemplate.New(path).Parse(string(raw))
+}
+
+// fingerprint summarises the names, sizes and modification times of the files in
+// dir, so changes to any of them can be noticed.
+func fingerprint(dir string) string {
+	var b strings.Builder
+
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		fmt.Fprintf(&b, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+
+	return b.String()
+}
+
+// Sources lays the templates in dir over the embedded ones. An empty dir, or one that
+// doesn't exist, leaves just the embedded templates.
+func Sources(dir string) []fs.FS {
+	if dir == "" {
+		return []fs.FS{Embedded}
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return []fs.FS{Embedded}
 	}
 
-	return &buf
+	return []fs.FS{os.DirFS(dir), Embedded}
 }
@@ -0,0 +1,276 @@
+package notification
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type invitationSample struct {
+	Route       string
+	Token       string
+	CompanyName string
+}
+
+var layout = &fstest.MapFile{Data: []byte(`{{define "layout"}}<h1>{{template "title" .}}</h1>{{template "content" .}}{{template "footer" .}}{{end}}`)}
+var footer = &fstest.MapFile{Data: []byte(`{{define "footer"}}<p>From {{.Brand.Name}}</p>{{end}}`)}
+
+func TestTemplatesRender(t *testing.T) {
+	templates, err := LoadTemplates(Embedded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := invitationSample{"https://app.example.com/invitations", "abc", "Acme"}
+
+	t.Run("renders the workspace's branding", func(t *testing.T) {
+		brand := Branding{Name: "Acme Inc", LogoURL: "https://api.example.com/workspaces/1/logo", AccentColor: "#ff6600"}
+
+		msg, err := templates.Render("invitation", "", brand, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, want := range []string{"From Acme Inc", brand.LogoURL, "color: #ff6600", "https://app.example.com/invitations/abc"} {
+			if !strings.Contains(msg.HTML, want) {
+				t.Errorf("Expected the mail to contain %q", want)
+			}
+		}
+
+		if msg.Subject != "Invitation to Acme" {
+			t.Errorf("Expected the subject from the subject template, got %q", msg.Subject)
+		}
+	})
+
+	t.Run("falls back to the default branding", func(t *testing.T) {
+		msg, err := templates.Render("invitation", "", Branding{}, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(msg.HTML, "From "+DefaultBranding.Name) {
+			t.Errorf("Expected the mail to be from %s", DefaultBranding.Name)
+		}
+
+		if !strings.Contains(msg.HTML, DefaultBranding.LogoURL) {
+			t.Errorf("Expected the default logo")
+		}
+	})
+
+	t.Run("converts the HTML when there's no text template", func(t *testing.T) {
+		msg, err := templates.Render("invitation", "", Branding{}, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(msg.Text, "Click here to setup your profile (https://app.example.com/invitations/abc)") {
+			t.Errorf("Expected the text to keep the link, got %q", msg.Text)
+		}
+
+		if strings.Contains(msg.Text, "<") || strings.Contains(msg.Text, "font-family") {
+			t.Errorf("Expected no markup or styles in the text, got %q", msg.Text)
+		}
+	})
+
+	t.Run("renders translations", func(t *testing.T) {
+		msg, err := templates.Render("invitation", "fr", Branding{Name: "Acme Inc"}, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, want := range []string{`lang="fr"`, "De la part de Acme Inc", "Cliquez ici pour créer votre profil"} {
+			if !strings.Contains(msg.HTML, want) {
+				t.Errorf("Expected the mail to contain %q", want)
+			}
+		}
+
+		if msg.Subject != "Invitation à rejoindre Acme" {
+			t.Errorf("Expected the translated subject, got %q", msg.Subject)
+		}
+	})
+
+	t.Run("falls back to untranslated templates", func(t *testing.T) {
+		msg, err := templates.Render("invitation", "de", Branding{}, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(msg.HTML, "Click here to setup your profile") {
+			t.Errorf("Expected the English mail, got %q", msg.HTML)
+		}
+	})
+
+	t.Run("links to unsubscribe when there's a link", func(t *testing.T) {
+		msg, err := templates.Render("invitation", "", Branding{}, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(msg.HTML, "Stop getting emails") {
+			t.Error("Expected no unsubscribe link")
+		}
+
+		msg, err = templates.render("invitation", View{Unsubscribe: "https://app.example.com/unsubscribe/abc", Data: data})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(msg.HTML, `href="https://app.example.com/unsubscribe/abc"`) {
+			t.Errorf("Expected the unsubscribe link, got %q", msg.HTML)
+		}
+	})
+
+	t.Run("fails for unknown templates", func(t *testing.T) {
+		if _, err := templates.Render("missing", "", Branding{}, data); !errors.Is(err, ErrUnknownTemplate) {
+			t.Errorf("Expected ErrUnknownTemplate, got %v", err)
+		}
+	})
+}
+
+func TestLoadTemplates(t *testing.T) {
+	base := fstest.MapFS{
+		"layouts/base.html":    layout,
+		"partials/footer.html": footer,
+		"welcome.html":         {Data: []byte(`{{define "title"}}Welcome{{end}}{{define "content"}}<p>Hi {{.Data.Name}}</p>{{end}}`)},
+		"welcome.subject.txt":  {Data: []byte("Welcome\n {{.Data.Name}} ")},
+		"welcome.txt":          {Data: []byte("Hi {{.Data.Name}} & co")},
+	}
+
+	t.Run("renders pages in the layout", func(t *testing.T) {
+		templates, err := LoadTemplates(base)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		msg, err := templates.Render("welcome", "", Branding{Name: "Acme"}, map[string]string{"Name": "Jane"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if msg.HTML != "<h1>Welcome</h1><p>Hi Jane</p><p>From Acme</p>" {
+			t.Errorf("Expected the page inside the layout, got %q", msg.HTML)
+		}
+
+		if msg.Subject != "Welcome Jane" {
+			t.Errorf("Expected the subject on one line, got %q", msg.Subject)
+		}
+
+		if msg.Text != "Hi Jane & co" {
+			t.Errorf("Expected the unescaped text template, got %q", msg.Text)
+		}
+	})
+
+	t.Run("lets earlier sources override later ones", func(t *testing.T) {
+		override := fstest.MapFS{
+			"partials/footer.html": {Data: []byte(`{{define "footer"}}<p>Sent by {{.Brand.Name}}</p>{{end}}`)},
+			"goodbye.html":         {Data: []byte(`{{define "title"}}Bye{{end}}`)},
+		}
+
+		templates, err := LoadTemplates(override, base)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if names := templates.Names(); len(names) != 2 || names[0] != "goodbye" || names[1] != "welcome" {
+			t.Errorf("Expected templates from both sources, got %v", names)
+		}
+
+		msg, err := templates.Render("welcome", "", Branding{Name: "Acme"}, map[string]string{"Name": "Jane"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(msg.HTML, "Sent by Acme") {
+			t.Errorf("Expected the overriding footer, got %q", msg.HTML)
+		}
+	})
+
+	t.Run("uses the subject of the untranslated template", func(t *testing.T) {
+		translated := fstest.MapFS{
+			"welcome.fr.html": {Data: []byte(`{{define "title"}}Bienvenue{{end}}{{define "content"}}<p>Salut {{.Data.Name}}</p>{{end}}`)},
+		}
+
+		templates, err := LoadTemplates(translated, base)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if names := templates.Names(); len(names) != 1 {
+			t.Errorf("Expected translations not to be listed, got %v", names)
+		}
+
+		msg, err := templates.Render("welcome", "fr", Branding{Name: "Acme"}, map[string]string{"Name": "Jane"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if msg.HTML != "<h1>Bienvenue</h1><p>Salut Jane</p><p>From Acme</p>" || msg.Subject != "Welcome Jane" {
+			t.Errorf("Expected the French page with the English subject, got %q and %q", msg.HTML, msg.Subject)
+		}
+	})
+
+	t.Run("fails on templates that don't parse", func(t *testing.T) {
+		broken := fstest.MapFS{"broken.html": {Data: []byte(`{{define "content"}}{{.Data.Name}`)}}
+
+		if _, err := LoadTemplates(broken, base); err == nil {
+			t.Error("Expected the broken template to fail")
+		}
+	})
+}
+
+func TestTemplatesValidate(t *testing.T) {
+	type welcome struct{ Name string }
+	Declare("validated", welcome{"Jane"})
+	defer func() {
+		declaredMu.Lock()
+		delete(declared, "validated")
+		declaredMu.Unlock()
+	}()
+
+	t.Run("accepts templates that render their samples", func(t *testing.T) {
+		templates, err := LoadTemplates(fstest.MapFS{
+			"layouts/base.html":    layout,
+			"partials/footer.html": footer,
+			"validated.html":       {Data: []byte(`{{define "title"}}Hi{{end}}{{define "content"}}{{.Data.Name}}{{end}}`)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := templates.Validate(); err != nil {
+			t.Errorf("Expected the templates to be valid, got %v", err)
+		}
+	})
+
+	t.Run("rejects fields the data doesn't have", func(t *testing.T) {
+		templates, err := LoadTemplates(fstest.MapFS{
+			"layouts/base.html":    layout,
+			"partials/footer.html": footer,
+			"validated.html":       {Data: []byte(`{{define "title"}}Hi{{end}}{{define "content"}}{{.Data.Nmae}}{{end}}`)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := templates.Validate(); err == nil {
+			t.Error("Expected the misspelt field to fail validation")
+		}
+	})
+
+	t.Run("rejects undeclared and missing templates", func(t *testing.T) {
+		templates, err := LoadTemplates(fstest.MapFS{
+			"layouts/base.html":    layout,
+			"partials/footer.html": footer,
+			"stray.html":           {Data: []byte(`{{define "title"}}Hi{{end}}`)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = templates.Validate()
+		if err == nil || !strings.Contains(err.Error(), "stray has no declared data") || !strings.Contains(err.Error(), "validated is declared but missing") {
+			t.Errorf("Expected both templates to be reported, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package notification
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	whitespace = regexp.MustCompile(`\s+`)
+
+	// blockTags start on a new line
+	blockTags = map[string]bool{
+		"br": true, "div": true, "hr": true, "li": true, "ol": true, "p": true,
+		"table": true, "tr": true, "ul": true, "blockquote": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	}
+	// paragraphTags are followed by a blank line
+	paragraphTags = map[string]bool{
+		"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	}
+	// hiddenTags hold nothing a reader would see
+	hiddenTags = map[string]bool{"head": true, "script": true, "style": true, "title": true}
+)
+
+// HTMLToText turns a mail's HTML into readable plain text. Links keep their URL in
+// brackets after the link text.
+func HTMLToText(s string) string {
+	z := html.NewTokenizer(strings.NewReader(s))
+
+	var out, linkText strings.Builder
+	var href string
+	hidden := 0
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return tidy(out.String())
+
+		case html.TextToken:
+			if hidden > 0 {
+				continue
+			}
+
+			// line breaks in the source mean nothing, only tags break lines
+			text := whitespace.ReplaceAllString(string(z.Text()), " ")
+			out.WriteString(text)
+			if href != "" {
+				linkText.WriteString(text)
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+
+			switch {
+			case hiddenTags[tag]:
+				if tt == html.StartTagToken {
+					hidden++
+				}
+			case tag == "a":
+				for hasAttr {
+					var key, val []byte
+					key, val, hasAttr = z.TagAttr()
+					if string(key) == "href" {
+						href = string(val)
+					}
+				}
+			case tag == "li":
+				out.WriteString("\n- ")
+			case blockTags[tag]:
+				out.WriteString("\n")
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+
+			switch {
+			case hiddenTags[tag]:
+				if hidden > 0 {
+					hidden--
+				}
+			case tag == "a":
+				if href != "" && strings.TrimSpace(linkText.String()) != href {
+					out.WriteString(" (" + href + ")")
+				}
+				href = ""
+				linkText.Reset()
+			case tag == "li":
+				// the next item or the end of the list breaks the line
+			case paragraphTags[tag]:
+				out.WriteString("\n\n")
+			case blockTags[tag]:
+				out.WriteString("\n")
+			}
+		}
+	}
+}
+
+// tidy trims every line and leaves at most one blank line between paragraphs.
+func tidy(s string) string {
+	var lines []string
+	blank := false
+
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
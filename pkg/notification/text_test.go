@@ -0,0 +1,27 @@
+package notification
+
+import "testing"
+
+func TestHTMLToText(t *testing.T) {
+	html := `<html>
+  <head><title>Hi</title><style>.module { color: red; }</style></head>
+  <body>
+    <p>Hello   <b>Jane</b>,
+      welcome &amp; enjoy.</p>
+    <ul><li>One</li><li>Two</li></ul>
+    <p><a href="https://example.com/a">Open it</a> or <a href="https://example.com/b">https://example.com/b</a></p>
+    <div>Line<br/>break</div>
+  </body>
+</html>`
+
+	expected := "Hello Jane, welcome & enjoy.\n\n" +
+		"- One\n" +
+		"- Two\n\n" +
+		"Open it (https://example.com/a) or https://example.com/b\n\n" +
+		"Line\n" +
+		"break"
+
+	if text := HTMLToText(html); text != expected {
+		t.Errorf("Expected %q, got %q", expected, text)
+	}
+}
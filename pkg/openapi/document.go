@@ -0,0 +1,184 @@
+package openapi
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var pathParam = regexp.MustCompile(`{([^}]+)}`)
+
+// Operation describes a single route for the document.
+type Operation struct {
+	Method   string
+	Path     string
+	Summary  string
+	Tag      string
+	Public   bool        // routes that don't need a session
+	Query    interface{} // struct read with anansi.ReadQuery
+	Request  interface{} // struct read with anansi.ReadJSON
+	Response interface{} // value sent with anansi.SendSuccess
+	Produces string      // content type of responses that aren't JSON
+	Upload   string      // multipart field of routes that take a file instead of JSON
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Components struct {
+	Schemas         map[string]Schema `json:"schemas"`
+	SecuritySchemes map[string]Schema `json:"securitySchemes"`
+}
+
+// Document is an OpenAPI 3.1 document.
+type Document struct {
+	OpenAPI    string                       `json:"openapi"`
+	Info       Info                         `json:"info"`
+	Servers    []Server                     `json:"servers"`
+	Paths      map[string]map[string]Schema `json:"paths"`
+	Components Components                   `json:"components"`
+}
+
+// New builds a document for the given operations. errorType describes the body of
+// every error response.
+func New(info Info, basePath string, errorType interface{}, ops []Operation) *Document {
+	d := &Document{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Servers: []Server{{URL: basePath}},
+		Paths:   make(map[string]map[string]Schema),
+		Components: Components{
+			Schemas: make(map[string]Schema),
+			SecuritySchemes: map[string]Schema{
+				"session": {"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+
+	errRef := d.schemaOf(reflect.TypeOf(errorType))
+
+	for _, op := range ops {
+		path := Normalize(op.Path)
+		if d.Paths[path] == nil {
+			d.Paths[path] = make(map[string]Schema)
+		}
+
+		d.Paths[path][strings.ToLower(op.Method)] = d.operation(op, errRef)
+	}
+
+	return d
+}
+
+// Normalize turns a chi route pattern into the path used in the document. Catch-all
+// patterns become a {path} parameter.
+func Normalize(pattern string) string {
+	if strings.HasSuffix(pattern, "/*") {
+		pattern = strings.TrimSuffix(pattern, "*") + "{path}"
+	}
+
+	if len(pattern) > 1 {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+
+	return pattern
+}
+
+func (d *Document) operation(op Operation, errRef Schema) Schema {
+	var params []Schema
+	for _, m := range pathParam.FindAllStringSubmatch(Normalize(op.Path), -1) {
+		params = append(params, Schema{
+			"name":     m[1],
+			"in":       "path",
+			"required": true,
+			"schema":   Schema{"type": "string"},
+		})
+	}
+
+	if op.Query != nil {
+		t := reflect.TypeOf(op.Query)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+
+			name := f.Tag.Get("key")
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+
+			s := d.schemaOf(f.Type)
+			if def := f.Tag.Get("default"); def != "" {
+				s["default"] = def
+				if n, err := strconv.Atoi(def); err == nil && s["type"] == "integer" {
+					s["default"] = n
+				}
+			}
+
+			params = append(params, Schema{"name": name, "in": "query", "schema": s})
+		}
+	}
+
+	errResponse := Schema{
+		"description": "error",
+		"content":     Schema{"application/problem+json": Schema{"schema": errRef}},
+	}
+
+	success := Schema{"description": "success"}
+	switch {
+	case op.Produces != "":
+		success["content"] = Schema{op.Produces: Schema{"schema": Schema{"type": "string"}}}
+	case op.Response != nil:
+		success["content"] = Schema{
+			"application/json": Schema{"schema": d.schemaOf(reflect.TypeOf(op.Response))},
+		}
+	}
+
+	o := Schema{
+		"summary":   op.Summary,
+		"responses": Schema{"200": success, "default": errResponse},
+	}
+
+	if op.Tag != "" {
+		o["tags"] = []string{op.Tag}
+	}
+
+	if len(params) > 0 {
+		o["parameters"] = params
+	}
+
+	if op.Request != nil {
+		o["requestBody"] = Schema{
+			"required": true,
+			"content": Schema{
+				"application/json": Schema{"schema": d.schemaOf(reflect.TypeOf(op.Request))},
+			},
+		}
+	}
+
+	if op.Upload != "" {
+		o["requestBody"] = Schema{
+			"required": true,
+			"content": Schema{
+				"multipart/form-data": Schema{"schema": Schema{
+					"type":       "object",
+					"properties": Schema{op.Upload: Schema{"type": "string", "contentMediaType": "application/octet-stream"}},
+					"required":   []string{op.Upload},
+				}},
+			},
+		}
+	}
+
+	if !op.Public {
+		o["security"] = []Schema{{"session": []string{}}}
+	}
+
+	return o
+}
@@ -0,0 +1,206 @@
+package openapi
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// Schema is a JSON schema object.
+type Schema map[string]interface{}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// probe is the value string fields are set to when discovering their rules. It's short
+// and unstructured enough to trip length, format and enum rules.
+const probe = "x"
+
+// schemaOf describes a Go type, registering named structs as components.
+func (d *Document) schemaOf(t reflect.Type) Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		name := t.Name()
+		if _, ok := d.Components.Schemas[name]; !ok {
+			// reserve the name first so recursive types terminate
+			d.Components.Schemas[name] = Schema{}
+			d.Components.Schemas[name] = d.structSchema(t)
+		}
+		return Schema{"$ref": "#/components/schemas/" + name}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Schema{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "format": "byte"}
+		}
+		return Schema{"type": "array", "items": d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return d.structSchema(t)
+	default:
+		// interface{} and friends can hold anything
+		return Schema{}
+	}
+}
+
+func (d *Document) structSchema(t reflect.Type) Schema {
+	props := Schema{}
+	var fields []string
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name, omit := jsonName(f)
+		if name == "-" {
+			continue
+		}
+
+		// embedded structs without a name are flattened like encoding/json does
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for k, v := range d.structSchema(embedded)["properties"].(Schema) {
+					props[k] = v
+					fields = append(fields, k)
+				}
+				continue
+			}
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		s := d.schemaOf(f.Type)
+		if omit || f.Type.Kind() == reflect.Ptr {
+			s["nullable"] = true
+		}
+		props[name] = s
+		fields = append(fields, name)
+	}
+
+	schema := Schema{"type": "object", "properties": props}
+	if required := applyRules(t, props); len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+// applyRules discovers the ozzo rules of a validatable struct by validating a zero value,
+// for required fields, and a probe value, for the constraints on string fields.
+func applyRules(t reflect.Type, props Schema) []string {
+	zero := reflect.New(t)
+	v, ok := zero.Interface().(ozzo.Validatable)
+	if !ok {
+		return nil
+	}
+
+	var required []string
+	for field, err := range fieldErrors(v.Validate()) {
+		if err.Code() == ozzo.ErrRequired.Code() {
+			required = append(required, field)
+		}
+	}
+
+	probed := reflect.New(t)
+	for i := 0; i < t.NumField(); i++ {
+		if f := probed.Elem().Field(i); f.Kind() == reflect.String && f.CanSet() {
+			f.SetString(probe)
+		}
+	}
+
+	for field, err := range fieldErrors(probed.Interface().(ozzo.Validatable).Validate()) {
+		prop, ok := props[field].(Schema)
+		if !ok {
+			continue
+		}
+
+		describe(prop, err)
+	}
+
+	sort.Strings(required)
+	return required
+}
+
+// describe translates a validation error into the matching schema keywords.
+func describe(s Schema, err ozzo.Error) {
+	params := err.Params()
+
+	switch err.Code() {
+	case "validation_is_email", "validation_is_email_format":
+		s["format"] = "email"
+	case "validation_is_url", "validation_is_request_url":
+		s["format"] = "uri"
+	case "validation_length_out_of_range", "validation_length_too_short", "validation_length_too_long":
+		if min, ok := params["min"].(int); ok && min > 0 {
+			s["minLength"] = min
+		}
+		if max, ok := params["max"].(int); ok && max > 0 {
+			s["maxLength"] = max
+		}
+	}
+
+	s["description"] = err.Error()
+	s["x-validation"] = err.Code()
+}
+
+func fieldErrors(err error) map[string]ozzo.Error {
+	out := make(map[string]ozzo.Error)
+
+	errs, ok := err.(ozzo.Errors)
+	if !ok {
+		return out
+	}
+
+	for field, e := range errs {
+		if ve, ok := e.(ozzo.Error); ok {
+			out[field] = ve
+		}
+	}
+
+	return out
+}
+
+func jsonName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "" {
+		return "", false
+	}
+
+	parts := strings.Split(tag, ",")
+	omit := false
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omit = true
+		}
+	}
+
+	return parts[0], omit
+}
@@ -0,0 +1,194 @@
+package otp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/tsaron/anansi/tokens"
+)
+
+const (
+	// TTL is how long a code can be used for.
+	TTL = time.Minute * 10
+	// MaxAttempts is how many codes a user can try within TTL of their first try,
+	// however many challenges they ask for.
+	MaxAttempts = 5
+	// Cooldown is how long a user or phone number waits between codes.
+	Cooldown = time.Minute
+	// MaxIssues is how many codes a phone number is sent within IssueWindow.
+	MaxIssues = 5
+	// IssueWindow is the period MaxIssues applies to.
+	IssueWindow = time.Hour
+
+	digits = 6
+)
+
+var (
+	ErrExpired         = errors.New("verification code has expired")
+	ErrIncorrect       = errors.New("verification code is incorrect")
+	ErrTooManyAttempts = errors.New("too many incorrect verification codes")
+	ErrTooSoon         = errors.New("verification code was requested too soon")
+)
+
+// count increments a counter, starting its expiry with the first increment.
+var count = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// Challenge is a pending verification of a phone number by a user.
+type Challenge struct {
+	User        uint   `json:"user"`
+	PhoneNumber string `json:"phone_number"`
+	Code        string `json:"code"`
+}
+
+type Store struct {
+	tStore *tokens.Store
+	redis  *redis.Client
+}
+
+func NewStore(tStore *tokens.Store, redis *redis.Client) *Store {
+	return &Store{tStore, redis}
+}
+
+// Issue creates a challenge for a user's phone number, replacing any pending one. It
+// returns the token identifying the challenge and the code to send to the phone.
+// Users and numbers have to wait Cooldown between codes, and numbers get at most
+// MaxIssues codes in IssueWindow, so codes can't be used to send SMS in bulk.
+func (s *Store) Issue(ctx context.Context, user uint, phoneNumber string) (string, string, error) {
+	for _, k := range []string{cooldownKey("user", fmt.Sprint(user)), cooldownKey("number", phoneNumber)} {
+		ok, err := s.redis.SetNX(ctx, k, 1, Cooldown).Result()
+		if err != nil {
+			return "", "", err
+		}
+
+		if !ok {
+			return "", "", ErrTooSoon
+		}
+	}
+
+	issued, err := s.incr(ctx, "phone-verification-issued:"+phoneNumber, IssueWindow)
+	if err != nil {
+		return "", "", err
+	}
+
+	if issued > MaxIssues {
+		return "", "", ErrTooSoon
+	}
+
+	code, err := newCode()
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err := s.tStore.Commission(ctx, TTL, key(user), Challenge{
+		User:        user,
+		PhoneNumber: phoneNumber,
+		Code:        hash(code),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return token, code, nil
+}
+
+// Verify checks a code against the challenge for token, which must belong to user.
+// Every try counts against the user before the code is compared, so concurrent
+// guesses can't get past MaxAttempts. The challenge is dropped once it has been
+// verified or the user has run out of attempts.
+func (s *Store) Verify(ctx context.Context, token string, user uint, code string) (Challenge, error) {
+	var c Challenge
+	if err := s.tStore.Peek(ctx, token, &c); err != nil {
+		if errors.Is(err, tokens.ErrTokenNotFound) {
+			return Challenge{}, ErrExpired
+		}
+		return Challenge{}, err
+	}
+
+	// don't let users probe challenges that aren't theirs
+	if c.User != user {
+		return Challenge{}, ErrExpired
+	}
+
+	attempts, err := s.incr(ctx, attemptsKey(user), TTL)
+	if err != nil {
+		return Challenge{}, err
+	}
+
+	if attempts > MaxAttempts {
+		return Challenge{}, ErrTooManyAttempts
+	}
+
+	if subtle.ConstantTimeCompare([]byte(c.Code), []byte(hash(code))) != 1 {
+		if attempts < MaxAttempts {
+			return Challenge{}, ErrIncorrect
+		}
+
+		if err := s.tStore.Revoke(ctx, key(user)); err != nil && !errors.Is(err, tokens.ErrTokenNotFound) {
+			return Challenge{}, err
+		}
+		return Challenge{}, ErrTooManyAttempts
+	}
+
+	if err := s.tStore.Decommission(ctx, token, &c); err != nil {
+		if errors.Is(err, tokens.ErrTokenNotFound) {
+			return Challenge{}, ErrExpired
+		}
+		return Challenge{}, err
+	}
+
+	if err := s.redis.Del(ctx, attemptsKey(user)).Err(); err != nil {
+		return Challenge{}, err
+	}
+
+	return c, nil
+}
+
+// incr counts one more against key, which is forgotten ttl after the first count.
+func (s *Store) incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return count.Run(ctx, s.redis, []string{key}, ttl.Milliseconds()).Int64()
+}
+
+func key(user uint) string {
+	return fmt.Sprintf("phone-verification:%d", user)
+}
+
+func attemptsKey(user uint) string {
+	return fmt.Sprintf("phone-verification-attempts:%d", user)
+}
+
+func cooldownKey(kind, id string) string {
+	return fmt.Sprintf("phone-verification-cooldown:%s:%s", kind, id)
+}
+
+func hash(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func newCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < digits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
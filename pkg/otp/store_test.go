@@ -0,0 +1,182 @@
+package otp
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/tsaron/anansi"
+	"github.com/tsaron/anansi/tokens"
+	"tsaron.com/godview-starter/pkg/config"
+)
+
+var store *Store
+var mem *redis.Client
+
+func afterEach(t *testing.T) {
+	if _, err := mem.FlushDB(context.TODO()).Result(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	var err error
+
+	var env config.Env
+	if err = anansi.LoadEnv(&env); err != nil {
+		panic(err)
+	}
+
+	if mem, err = config.SetupRedis(context.TODO(), env); err != nil {
+		panic(err)
+	}
+	store = NewStore(tokens.NewStore(mem, env.Secret), mem)
+
+	code := m.Run()
+
+	if err := mem.Close(); err != nil {
+		panic(err)
+	}
+
+	os.Exit(code)
+}
+
+func TestStoreVerify(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("accepts the issued code once", func(t *testing.T) {
+		defer afterEach(t)
+
+		token, code, err := store.Issue(ctx, 1, "+2348031234567")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := store.Verify(ctx, token, 1, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.PhoneNumber != "+2348031234567" {
+			t.Errorf("Expected the verified phone number to be +2348031234567, got %s", c.PhoneNumber)
+		}
+
+		if _, err := store.Verify(ctx, token, 1, code); err != ErrExpired {
+			t.Errorf("Expected reusing a code to fail with %v, got %v", ErrExpired, err)
+		}
+	})
+
+	t.Run("rejects challenges of other users", func(t *testing.T) {
+		defer afterEach(t)
+
+		token, code, err := store.Issue(ctx, 1, "+2348031234567")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.Verify(ctx, token, 2, code); err != ErrExpired {
+			t.Errorf("Expected %v, got %v", ErrExpired, err)
+		}
+	})
+
+	t.Run("drops the challenge after too many attempts", func(t *testing.T) {
+		defer afterEach(t)
+
+		token, code, err := store.Issue(ctx, 1, "+2348031234567")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wrong := "000000"
+		if code == wrong {
+			wrong = "111111"
+		}
+
+		for i := 1; i < MaxAttempts; i++ {
+			if _, err := store.Verify(ctx, token, 1, wrong); err != ErrIncorrect {
+				t.Fatalf("Expected attempt %d to fail with %v, got %v", i, ErrIncorrect, err)
+			}
+		}
+
+		if _, err := store.Verify(ctx, token, 1, wrong); err != ErrTooManyAttempts {
+			t.Errorf("Expected %v, got %v", ErrTooManyAttempts, err)
+		}
+
+		if _, err := store.Verify(ctx, token, 1, code); err != ErrExpired {
+			t.Errorf("Expected the right code to be useless after too many attempts, got %v", err)
+		}
+	})
+
+	t.Run("keeps counting attempts across challenges", func(t *testing.T) {
+		defer afterEach(t)
+
+		token, code, err := store.Issue(ctx, 1, "+2348031234567")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wrong := "000000"
+		if code == wrong {
+			wrong = "111111"
+		}
+
+		for i := 1; i < MaxAttempts; i++ {
+			if _, err := store.Verify(ctx, token, 1, wrong); err != ErrIncorrect {
+				t.Fatalf("Expected attempt %d to fail with %v, got %v", i, ErrIncorrect, err)
+			}
+		}
+
+		// skip the cooldown rather than wait it out
+		if err := mem.Del(ctx, cooldownKey("user", "1"), cooldownKey("number", "+2348031234567")).Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		token, code, err = store.Issue(ctx, 1, "+2348031234567")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.Verify(ctx, token, 1, code); err != nil {
+			t.Fatalf("Expected the last attempt to be allowed, got %v", err)
+		}
+	})
+}
+
+func TestStoreIssue(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("makes users and numbers wait between codes", func(t *testing.T) {
+		defer afterEach(t)
+
+		if _, _, err := store.Issue(ctx, 1, "+2348031234567"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := store.Issue(ctx, 1, "+2348037654321"); err != ErrTooSoon {
+			t.Errorf("Expected the same user to wait, got %v", err)
+		}
+
+		if _, _, err := store.Issue(ctx, 2, "+2348031234567"); err != ErrTooSoon {
+			t.Errorf("Expected the same number to wait, got %v", err)
+		}
+	})
+
+	t.Run("limits the codes sent to a number", func(t *testing.T) {
+		defer afterEach(t)
+
+		for i := 0; i < MaxIssues; i++ {
+			if _, _, err := store.Issue(ctx, uint(i+1), "+2348031234567"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := mem.Del(ctx, cooldownKey("number", "+2348031234567")).Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, _, err := store.Issue(ctx, 99, "+2348031234567"); err != ErrTooSoon {
+			t.Errorf("Expected %v, got %v", ErrTooSoon, err)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// DefaultRegion is the region assumed for national numbers when a workspace hasn't
+// chosen one. It matches the Nigerian numbers we accepted before regions existed.
+const DefaultRegion = "NG"
+
+// ErrInvalid is returned for numbers that can't be dialled.
+var ErrInvalid = errors.New("invalid phone number")
+
+// Normalize parses a phone number in any common format and returns it in E.164, e.g.
+// "+2348031234567". National numbers are read as belonging to region, an ISO 3166-1
+// alpha-2 code; numbers with a leading + keep their own country code.
+func Normalize(number, region string) (string, error) {
+	parsed, err := phonenumbers.Parse(number, strings.ToUpper(region))
+	if err != nil {
+		return "", ErrInvalid
+	}
+
+	if !phonenumbers.IsValidNumber(parsed) {
+		return "", ErrInvalid
+	}
+
+	return phonenumbers.Format(parsed, phonenumbers.E164), nil
+}
+
+// ValidRegion reports whether region is a region code we can parse numbers for.
+func ValidRegion(region string) bool {
+	return phonenumbers.GetSupportedRegions()[region]
+}
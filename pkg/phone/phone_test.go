@@ -0,0 +1,45 @@
+package phone
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	valid := []struct {
+		number, region, expected string
+	}{
+		{"08031234567", "NG", "+2348031234567"},
+		{"0803 123 4567", "ng", "+2348031234567"},
+		{"+234 803 123 4567", "GB", "+2348031234567"},
+		{"07911 123456", "GB", "+447911123456"},
+		{"06 12 34 56 78", "FR", "+33612345678"},
+		{"(415) 555-2671", "US", "+14155552671"},
+	}
+
+	for _, c := range valid {
+		number, err := Normalize(c.number, c.region)
+		if err != nil {
+			t.Errorf("Expected %s in %s to be valid, got %v", c.number, c.region, err)
+			continue
+		}
+
+		if number != c.expected {
+			t.Errorf("Expected %s in %s to normalise to %s, got %s", c.number, c.region, c.expected, number)
+		}
+	}
+
+	invalid := []string{"", "phone", "0803", "080312345678901234", "08031234567 and more"}
+	for _, n := range invalid {
+		if number, err := Normalize(n, "NG"); err != ErrInvalid {
+			t.Errorf("Expected %q to be invalid, got %q", n, number)
+		}
+	}
+}
+
+func TestValidRegion(t *testing.T) {
+	if !ValidRegion("NG") || !ValidRegion("FR") {
+		t.Error("Expected NG and FR to be valid regions")
+	}
+
+	if ValidRegion("ng") || ValidRegion("XX") {
+		t.Error("Expected only known, upper case regions to be valid")
+	}
+}
@@ -0,0 +1,135 @@
+package preferences
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/users"
+)
+
+// Preference is whether a user gets a category of notifications on a channel. Users
+// get everything until they say otherwise, so only changes are stored.
+type Preference struct {
+	tableName struct{} `pg:"notification_preferences"`
+
+	User      uint      `json:"-" pg:",pk"`
+	Category  string    `json:"category" pg:",pk"`
+	Channel   string    `json:"channel" pg:",pk"`
+	Enabled   bool      `json:"enabled" pg:",use_zero"`
+	Locked    bool      `json:"locked" pg:"-"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+type Repo struct {
+	db *pg.DB
+}
+
+func NewRepo(db *pg.DB) *Repo {
+	return &Repo{db}
+}
+
+// List returns a user's preference for every category and channel.
+func (r *Repo) List(ctx context.Context, user uint) ([]Preference, error) {
+	var stored []Preference
+	err := r.db.
+		ModelContext(ctx, &stored).
+		Where(`"user" = ?`, user).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	saved := make(map[string]bool)
+	for _, p := range stored {
+		saved[p.Category+"."+p.Channel] = p.Enabled
+	}
+
+	var prefs []Preference
+	for _, c := range notification.Categories {
+		for _, ch := range notification.Channels {
+			enabled, ok := saved[c.Name+"."+ch]
+			prefs = append(prefs, Preference{
+				User:     user,
+				Category: c.Name,
+				Channel:  ch,
+				Enabled:  !ok || enabled || c.Locked,
+				Locked:   c.Locked,
+			})
+		}
+	}
+
+	return prefs, nil
+}
+
+// Set saves a user's preferences. Preferences for locked categories are ignored.
+func (r *Repo) Set(ctx context.Context, user uint, prefs []Preference) error {
+	var changed []Preference
+	for _, p := range prefs {
+		if notification.Locked(p.Category) {
+			continue
+		}
+
+		p.User = user
+		p.UpdatedAt = time.Now()
+		changed = append(changed, p)
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	_, err := r.db.
+		ModelContext(ctx, &changed).
+		OnConflict(`("user", category, channel) DO UPDATE`).
+		Set("enabled = EXCLUDED.enabled, updated_at = EXCLUDED.updated_at").
+		Insert()
+
+	return err
+}
+
+// Disabled picks out the users who turned off a category on a channel.
+func (r *Repo) Disabled(ctx context.Context, ids []uint, category, channel string) ([]uint, error) {
+	if len(ids) == 0 || notification.Locked(category) {
+		return nil, nil
+	}
+
+	var disabled []uint
+	err := r.db.
+		ModelContext(ctx, (*Preference)(nil)).
+		Column("user").
+		Where(`"user" in (?)`, pg.In(ids)).
+		Where("category = ?", category).
+		Where("channel = ?", channel).
+		Where("not enabled").
+		Select(&disabled)
+
+	return disabled, err
+}
+
+// UserByEmail finds the user with an email address, returning 0 if there's no such
+// user.
+func (r *Repo) UserByEmail(ctx context.Context, email string) (uint, error) {
+	return r.user(ctx, "email_address", email)
+}
+
+// UserByPhone finds the user with a phone number, returning 0 if there's no such user.
+func (r *Repo) UserByPhone(ctx context.Context, number string) (uint, error) {
+	return r.user(ctx, "phone_number", number)
+}
+
+func (r *Repo) user(ctx context.Context, column, value string) (uint, error) {
+	var id uint
+	err := r.db.
+		ModelContext(ctx, (*users.User)(nil)).
+		Column("id").
+		Where("? = ?", pg.Ident(column), value).
+		Select(&id)
+
+	if err == pg.ErrNoRows {
+		return 0, nil
+	}
+
+	return id, err
+}
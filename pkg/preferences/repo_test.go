@@ -0,0 +1,197 @@
+package preferences
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/tsaron/anansi"
+	"github.com/tsaron/anansi/postgres"
+	"syreclabs.com/go/faker"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/users"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+var testDB *pg.DB
+
+func afterEach(t *testing.T) {
+	if err := postgres.CleanUpTables(testDB, "notification_preferences", "users", "workspaces"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	var err error
+
+	var env config.Env
+	if err = anansi.LoadEnv(&env); err != nil {
+		panic(err)
+	}
+
+	log := anansi.NewLogger(env.Name)
+
+	if testDB, err = config.SetupDB(env); err != nil {
+		panic(err)
+	}
+	log.Info().Msg("Successfully connected to postgres")
+
+	code := m.Run()
+
+	if err := testDB.Close(); err != nil {
+		log.Err(err).Msg("Failed to disconnect from postgres cleanly")
+	}
+
+	os.Exit(code)
+}
+
+func createUser(t *testing.T) *users.User {
+	ctx := context.TODO()
+
+	wk, err := workspaces.NewRepo(testDB).Create(ctx, faker.Company().Name(), faker.Internet().Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := users.NewRepo(testDB).Create(ctx, wk.ID, users.UserRequest{
+		EmailAddress: faker.Internet().Email(),
+		Role:         users.RoleMember,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+func find(prefs []Preference, category, channel string) Preference {
+	for _, p := range prefs {
+		if p.Category == category && p.Channel == channel {
+			return p
+		}
+	}
+
+	return Preference{}
+}
+
+func TestRepoSet(t *testing.T) {
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+
+	t.Run("enables everything by default", func(t *testing.T) {
+		defer afterEach(t)
+		user := createUser(t)
+
+		prefs, err := repo.List(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(prefs) != len(notification.Categories)*len(notification.Channels) {
+			t.Errorf("Expected every category on every channel, got %v", prefs)
+		}
+
+		for _, p := range prefs {
+			if !p.Enabled {
+				t.Errorf("Expected %s on %s to be enabled", p.Category, p.Channel)
+			}
+		}
+	})
+
+	t.Run("keeps locked categories on", func(t *testing.T) {
+		defer afterEach(t)
+		user := createUser(t)
+
+		err := repo.Set(ctx, user.ID, []Preference{
+			{Category: notification.CategoryMembers, Channel: notification.ChannelEmail, Enabled: false},
+			{Category: notification.CategorySecurity, Channel: notification.ChannelEmail, Enabled: false},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		prefs, err := repo.List(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if find(prefs, notification.CategoryMembers, notification.ChannelEmail).Enabled {
+			t.Error("Expected member emails to be off")
+		}
+
+		if p := find(prefs, notification.CategorySecurity, notification.ChannelEmail); !p.Enabled || !p.Locked {
+			t.Errorf("Expected security emails to stay on, got %v", p)
+		}
+
+		if !find(prefs, notification.CategoryMembers, notification.ChannelInApp).Enabled {
+			t.Error("Expected other channels to be left alone")
+		}
+	})
+}
+
+type recorder []notification.TemplateMail
+
+func (r *recorder) Send(ctx context.Context, m notification.TemplateMail) error {
+	*r = append(*r, m)
+	return nil
+}
+
+func TestMailer(t *testing.T) {
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+	links := Links{API: "https://api.example.com/v1/unsubscribe", Page: "https://app.example.com/unsubscribe"}
+
+	t.Run("adds unsubscribe links", func(t *testing.T) {
+		defer afterEach(t)
+		user := createUser(t)
+
+		var sent recorder
+		mailer := Mailer(&sent, repo, NewSigner([]byte("secret")), links)
+
+		err := mailer.Send(ctx, notification.TemplateMail{ReceiverEmail: user.EmailAddress, Category: notification.CategoryMembers})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(sent) != 1 {
+			t.Fatalf("Expected the mail to be sent, got %d mails", len(sent))
+		}
+
+		if !strings.HasPrefix(sent[0].UnsubscribeURL, links.Page+"/") {
+			t.Errorf("Expected a link to the unsubscribe page, got %s", sent[0].UnsubscribeURL)
+		}
+
+		if h := sent[0].Headers["List-Unsubscribe"]; !strings.HasPrefix(h, "<"+links.API+"/") {
+			t.Errorf("Expected a one-click unsubscribe header, got %s", h)
+		}
+	})
+
+	t.Run("drops mail the receiver opted out of", func(t *testing.T) {
+		defer afterEach(t)
+		user := createUser(t)
+
+		err := repo.Set(ctx, user.ID, []Preference{
+			{Category: notification.CategoryMembers, Channel: notification.ChannelEmail, Enabled: false},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var sent recorder
+		mailer := Mailer(&sent, repo, NewSigner([]byte("secret")), links)
+
+		for _, category := range []string{notification.CategoryMembers, notification.CategorySecurity} {
+			err := mailer.Send(ctx, notification.TemplateMail{ReceiverEmail: user.EmailAddress, Category: category})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if len(sent) != 1 || sent[0].Category != notification.CategorySecurity || sent[0].UnsubscribeURL != "" {
+			t.Errorf("Expected only the security mail, without a link, got %v", sent)
+		}
+	})
+}
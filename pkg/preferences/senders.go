@@ -0,0 +1,110 @@
+package preferences
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"tsaron.com/godview-starter/pkg/notification"
+)
+
+// Links are where unsubscribe tokens are sent. API takes the POST mail clients make
+// for one-click unsubscribes, and Page is the client page linked in the mail itself.
+type Links struct {
+	API  string
+	Page string
+}
+
+type mailer struct {
+	next   notification.Mailer
+	repo   *Repo
+	signer *Signer
+	links  Links
+}
+
+// Mailer drops mail its receiver has opted out of, and adds unsubscribe links to the
+// mail they can opt out of.
+func Mailer(next notification.Mailer, repo *Repo, signer *Signer, links Links) notification.Mailer {
+	return &mailer{next, repo, signer, links}
+}
+
+func (m *mailer) Send(ctx context.Context, mail notification.TemplateMail) error {
+	if mail.Category == "" || notification.Locked(mail.Category) {
+		return m.next.Send(ctx, mail)
+	}
+
+	user, err := m.repo.UserByEmail(ctx, mail.ReceiverEmail)
+	if err != nil {
+		return err
+	}
+
+	if user == 0 {
+		return m.next.Send(ctx, mail)
+	}
+
+	if skip, err := optedOut(ctx, m.repo, user, mail.Category, notification.ChannelEmail); err != nil || skip {
+		return err
+	}
+
+	token := m.signer.Token(user, mail.Category)
+	mail.UnsubscribeURL = m.links.Page + "/" + token
+
+	headers := map[string]string{
+		"List-Unsubscribe":      "<" + m.links.API + "/" + token + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+	for k, v := range mail.Headers {
+		headers[k] = v
+	}
+	mail.Headers = headers
+
+	return m.next.Send(ctx, mail)
+}
+
+type smsSender struct {
+	next notification.SMSSender
+	repo *Repo
+}
+
+// SMS drops messages their receiver has opted out of.
+func SMS(next notification.SMSSender, repo *Repo) notification.SMSSender {
+	return &smsSender{next, repo}
+}
+
+func (s *smsSender) SendSMS(ctx context.Context, m notification.SMS) error {
+	if m.Category == "" || notification.Locked(m.Category) {
+		return s.next.SendSMS(ctx, m)
+	}
+
+	user, err := s.repo.UserByPhone(ctx, m.To)
+	if err != nil {
+		return err
+	}
+
+	if user != 0 {
+		if skip, err := optedOut(ctx, s.repo, user, m.Category, notification.ChannelSMS); err != nil || skip {
+			return err
+		}
+	}
+
+	return s.next.SendSMS(ctx, m)
+}
+
+// optedOut checks whether a user turned off a category on a channel. Skipping is
+// what they asked for, so it isn't an error.
+func optedOut(ctx context.Context, repo *Repo, user uint, category, channel string) (bool, error) {
+	disabled, err := repo.Disabled(ctx, []uint{user}, category, channel)
+	if err != nil {
+		return false, err
+	}
+
+	if len(disabled) > 0 {
+		zerolog.Ctx(ctx).Debug().
+			Uint("user", user).
+			Str("category", category).
+			Str("channel", channel).
+			Msg("skipped notification the user opted out of")
+		return true, nil
+	}
+
+	return false, nil
+}
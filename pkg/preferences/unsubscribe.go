@@ -0,0 +1,61 @@
+package preferences
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidToken = errors.New("unsubscribe token is invalid")
+
+// Signer creates and checks the tokens in unsubscribe links, so people can opt out
+// of a category without logging in.
+type Signer struct {
+	secret []byte
+}
+
+func NewSigner(secret []byte) *Signer {
+	return &Signer{secret}
+}
+
+// Token creates the token for turning off a category of emails to a user. Tokens
+// don't expire, since links in old emails should keep working.
+func (s *Signer) Token(user uint, category string) string {
+	payload := fmt.Sprintf("%d.%s", user, category)
+	return payload + "." + s.sign(payload)
+}
+
+// Verify checks a token created by Token, returning the user and category in it.
+func (s *Signer) Verify(token string) (uint, string, error) {
+	i := strings.LastIndex(token, ".")
+	if i < 0 {
+		return 0, "", ErrInvalidToken
+	}
+
+	payload, sig := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(s.sign(payload)), []byte(sig)) {
+		return 0, "", ErrInvalidToken
+	}
+
+	parts := strings.SplitN(payload, ".", 2)
+	if len(parts) != 2 {
+		return 0, "", ErrInvalidToken
+	}
+
+	user, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidToken
+	}
+
+	return uint(user), parts[1], nil
+}
+
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "unsubscribe\n%s", payload)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
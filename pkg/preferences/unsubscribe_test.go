@@ -0,0 +1,35 @@
+package preferences
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSigner(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+	token := signer.Token(42, "members")
+
+	user, category, err := signer.Verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user != 42 || category != "members" {
+		t.Errorf("Expected user 42 and members, got %d and %s", user, category)
+	}
+
+	forged := strings.Replace(token, "42.", "43.", 1)
+	if _, _, err := signer.Verify(forged); err != ErrInvalidToken {
+		t.Errorf("Expected a changed user to be rejected, got %v", err)
+	}
+
+	if _, _, err := NewSigner([]byte("other")).Verify(token); err != ErrInvalidToken {
+		t.Errorf("Expected tokens from another secret to be rejected, got %v", err)
+	}
+
+	for _, bad := range []string{"", "nodots", "x.members." + signer.sign("x.members")} {
+		if _, _, err := signer.Verify(bad); err != ErrInvalidToken {
+			t.Errorf("Expected %q to be rejected, got %v", bad, err)
+		}
+	}
+}
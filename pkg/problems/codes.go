@@ -0,0 +1,31 @@
+package problems
+
+import "net/http"
+
+// Codes for problems any endpoint can run into.
+var (
+	BadRequest           = Register("bad_request", http.StatusBadRequest, "Your request is invalid")
+	MalformedBody        = Register("malformed_body", http.StatusBadRequest, "We cannot parse your request body")
+	InvalidQuery         = Register("invalid_query", http.StatusBadRequest, "We could not parse your request query")
+	ValidationFailed     = Register("validation_failed", http.StatusBadRequest, "We could not validate your request")
+	Unauthorized         = Register("unauthorized", http.StatusUnauthorized, "You need to be logged in to do this")
+	Forbidden            = Register("forbidden", http.StatusForbidden, "You are not allowed to do this")
+	NotFound             = Register("not_found", http.StatusNotFound, "Whoops!! This route doesn't exist")
+	MethodNotAllowed     = Register("method_not_allowed", http.StatusMethodNotAllowed, "This route doesn't support this method")
+	Conflict             = Register("conflict", http.StatusConflict, "Your request conflicts with existing data")
+	UnsupportedMediaType = Register("unsupported_media_type", http.StatusUnsupportedMediaType, "Your request body must be JSON")
+	InternalError        = Register("internal_error", http.StatusInternalServerError, "Something went wrong on our end")
+	Timeout              = Register("timeout", http.StatusGatewayTimeout, "Your request took too long to complete")
+)
+
+// byStatus picks the generic code for errors that only carry a status.
+var byStatus = map[int]Code{
+	http.StatusBadRequest:           BadRequest,
+	http.StatusUnauthorized:         Unauthorized,
+	http.StatusForbidden:            Forbidden,
+	http.StatusNotFound:             NotFound,
+	http.StatusMethodNotAllowed:     MethodNotAllowed,
+	http.StatusConflict:             Conflict,
+	http.StatusUnsupportedMediaType: UnsupportedMediaType,
+	http.StatusGatewayTimeout:       Timeout,
+}
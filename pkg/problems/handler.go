@@ -0,0 +1,72 @@
+package problems
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/rs/zerolog"
+)
+
+// HandlerFunc is an http handler that reports failure by returning an error rather
+// than writing a response.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Handle adapts h to an http.HandlerFunc, responding to any error it returns with the
+// matching problem.
+func Handle(h HandlerFunc) http.HandlerFunc {
+	return h.ServeHTTP
+}
+
+func (h HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h(w, r); err != nil {
+		Report(w, r, err)
+	}
+}
+
+// Report responds to err with its problem. Errors that end up as server errors are
+// logged with the request ID, as clients only ever see a generic message for them.
+func Report(w http.ResponseWriter, r *http.Request, err error) {
+	p := From(r.Context(), err)
+	if p.Status >= http.StatusInternalServerError {
+		zerolog.Ctx(r.Context()).Err(err).
+			Str("request_id", middleware.GetReqID(r.Context())).
+			Msg("")
+	}
+
+	Send(w, r, p)
+}
+
+type mapping struct {
+	match func(error) bool
+	code  Code
+}
+
+var mappings []mapping
+
+// Map translates errors that match target, as decided by errors.Is, into code.
+func Map(target error, code Code) {
+	MapFunc(func(err error) bool { return errors.Is(err, target) }, code)
+}
+
+// MapFunc translates errors accepted by match into code. It's meant for error types
+// that can't be compared with errors.Is.
+func MapFunc(match func(error) bool, code Code) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	mappings = append(mappings, mapping{match, code})
+}
+
+func translate(err error) (Code, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, m := range mappings {
+		if m.match(err) {
+			return m.code, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,72 @@
+package problems
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var errGone = errors.New("token not found")
+
+type errTaken string
+
+func (e errTaken) Error() string { return string(e) + " is taken" }
+
+func init() {
+	Map(errGone, NotFound)
+	MapFunc(func(err error) bool {
+		var e errTaken
+		return errors.As(err, &e)
+	}, Conflict)
+}
+
+func handle(t *testing.T, err error) (*httptest.ResponseRecorder, Problem) {
+	handler := Handle(func(w http.ResponseWriter, r *http.Request) error {
+		return err
+	})
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/invitations", nil))
+
+	var p Problem
+	if res.Body.Len() != 0 {
+		if err := json.Unmarshal(res.Body.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return res, p
+}
+
+func TestHandle(t *testing.T) {
+	t.Run("leaves successful responses alone", func(t *testing.T) {
+		res, _ := handle(t, nil)
+		if res.Code != http.StatusOK || res.Body.Len() != 0 {
+			t.Errorf("Expected an untouched response, got %d %s", res.Code, res.Body)
+		}
+	})
+
+	t.Run("maps registered errors", func(t *testing.T) {
+		_, p := handle(t, fmt.Errorf("extending invitation: %w", errGone))
+		if p.Code != NotFound || p.Instance != "/invitations" {
+			t.Errorf("Expected a not_found problem, got %v", p)
+		}
+	})
+
+	t.Run("maps registered error types", func(t *testing.T) {
+		_, p := handle(t, errTaken("jane@example.com"))
+		if p.Code != Conflict {
+			t.Errorf("Expected a conflict problem, got %v", p)
+		}
+	})
+
+	t.Run("hides unknown errors", func(t *testing.T) {
+		res, p := handle(t, errors.New("pq: connection refused"))
+		if res.Code != http.StatusInternalServerError || p.Detail != InternalError.Error() {
+			t.Errorf("Expected a generic internal error, got %v", p)
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package problems
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"tsaron.com/godview-starter/pkg/i18n"
+)
+
+// ContentType is the media type of problem responses, as defined by RFC 7807.
+const ContentType = "application/problem+json"
+
+// TypeBase prefixes the code of a problem to form its type URI. It points at the
+// endpoint serving the catalogue.
+var TypeBase = "/api/v1/errors#"
+
+// Code identifies a kind of problem. Codes are stable and safe for clients to switch on.
+type Code string
+
+// Entry is a code as listed in the catalogue.
+type Entry struct {
+	Code   Code   `json:"code"`
+	Status int    `json:"status"`
+	Title  string `json:"title"`
+}
+
+var (
+	mu        sync.RWMutex
+	catalogue = make(map[Code]Entry)
+)
+
+// Register adds a code to the catalogue. Registering a code twice is a programming
+// error and panics.
+func Register(code Code, status int, title string) Code {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := catalogue[code]; ok {
+		panic(fmt.Sprintf("problem code %q has already been registered", string(code)))
+	}
+
+	catalogue[code] = Entry{code, status, title}
+	return code
+}
+
+// Catalogue lists every registered code, sorted by code.
+func Catalogue() []Entry {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	entries := make([]Entry, 0, len(catalogue))
+	for _, e := range catalogue {
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Code < entries[j].Code })
+	return entries
+}
+
+func lookup(code Code) Entry {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if e, ok := catalogue[code]; ok {
+		return e
+	}
+
+	return catalogue[InternalError]
+}
+
+// Error makes a code usable as an error, with the catalogue's title as its detail.
+func (c Code) Error() string {
+	return lookup(c).Title
+}
+
+// FieldError explains why a single field of the request is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	params map[string]interface{}
+}
+
+// Problem is an RFC 7807 problem details object, extended with a catalogued code
+// and the list of invalid fields.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     Code         `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	Err      error        `json:"-"`
+	// DetailKey names the catalogue message for a detail that isn't the title
+	DetailKey string `json:"-"`
+}
+
+// New creates a problem for a code. The detail defaults to the code's title.
+func New(code Code, detail string) Problem {
+	e := lookup(code)
+	if detail == "" {
+		detail = e.Title
+	}
+
+	return Problem{
+		Type:   TypeBase + string(e.Code),
+		Title:  e.Title,
+		Status: e.Status,
+		Detail: detail,
+		Code:   e.Code,
+	}
+}
+
+// Localized creates a problem for a code whose detail is the message for key in the
+// i18n catalogues.
+func Localized(code Code, key string) Problem {
+	p := New(code, i18n.T(i18n.Default, key))
+	p.DetailKey = key
+
+	return p
+}
+
+// Wrap creates a problem for a code, keeping err for logging.
+func Wrap(code Code, err error) Problem {
+	p := New(code, "")
+	p.Err = err
+
+	return p
+}
+
+func (p Problem) Error() string {
+	if p.Err == nil {
+		return p.Detail
+	}
+
+	return fmt.Sprintf("%s: %v", p.Detail, p.Err)
+}
+
+func (p Problem) Unwrap() error { return p.Err }
+
+// Validation creates a validation_failed problem listing every invalid field in err.
+func Validation(err error) Problem {
+	p := New(ValidationFailed, "")
+	p.Errors = Fields(err)
+	p.Err = err
+
+	return p
+}
+
+// Fields flattens ozzo validation errors into a list of field errors. Nested fields
+// are joined with dots, e.g. "0.email_address" for the first item of a list.
+func Fields(err error) []FieldError {
+	var fields []FieldError
+	collect("", err, &fields)
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	return fields
+}
+
+func collect(prefix string, err error, fields *[]FieldError) {
+	switch e := err.(type) {
+	case ozzo.Errors:
+		for name, inner := range e {
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			collect(name, inner, fields)
+		}
+	case ozzo.Error:
+		*fields = append(*fields, FieldError{Field: prefix, Code: e.Code(), Message: e.Error(), params: e.Params()})
+	case nil:
+	default:
+		*fields = append(*fields, FieldError{Field: prefix, Code: string(ValidationFailed), Message: e.Error()})
+	}
+}
+
+// Send writes a problem as the response, in the locale of the request.
+func Send(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	p = localize(i18n.FromContext(r.Context()), p)
+
+	raw, err := json.Marshal(p)
+	if err != nil {
+		panic(err)
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+
+	// we don't have a plan for when writes fail
+	_, _ = w.Write(raw)
+}
+
+// localize translates the human readable parts of p. Messages are written in the
+// default locale to begin with.
+func localize(locale string, p Problem) Problem {
+	if locale == i18n.Default {
+		return p
+	}
+
+	title := p.Title
+	if msg, ok := i18n.Translate(locale, "problem."+string(p.Code), nil); ok {
+		p.Title = msg
+	}
+
+	switch {
+	case p.DetailKey != "":
+		if msg, ok := i18n.Translate(locale, p.DetailKey, nil); ok {
+			p.Detail = msg
+		}
+	case p.Detail == title:
+		p.Detail = p.Title
+	}
+
+	if len(p.Errors) > 0 {
+		fields := make([]FieldError, len(p.Errors))
+		for i, f := range p.Errors {
+			if msg, ok := i18n.Translate(locale, "field."+f.Code, f.params); ok {
+				f.Message = msg
+			}
+			fields[i] = f
+		}
+		p.Errors = fields
+	}
+
+	return p
+}
@@ -0,0 +1,191 @@
+package problems
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/i18n"
+)
+
+type invite struct {
+	EmailAddress string `json:"email_address"`
+	Role         string `json:"role"`
+}
+
+func (i invite) Validate() error {
+	return ozzo.ValidateStruct(&i,
+		ozzo.Field(&i.EmailAddress, ozzo.Required, is.Email),
+		ozzo.Field(&i.Role, ozzo.Required),
+	)
+}
+
+func serve(t *testing.T, err error) Problem {
+	handler := Recoverer("prod")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(err)
+	}))
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/invitations", nil))
+
+	if ct := res.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Expected content type %s, got %s", ContentType, ct)
+	}
+
+	var p Problem
+	if err := json.Unmarshal(res.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Status != res.Code {
+		t.Errorf("Expected body status %d to match response status %d", p.Status, res.Code)
+	}
+
+	return p
+}
+
+func TestFields(t *testing.T) {
+	err := ozzo.Validate([]invite{{EmailAddress: "jane@example.com", Role: "admin"}, {EmailAddress: "jane"}})
+	fields := Fields(err)
+
+	if len(fields) != 2 {
+		t.Fatalf("Expected 2 invalid fields, got %v", fields)
+	}
+
+	if fields[0].Field != "1.email_address" || fields[0].Code != "validation_is_email" {
+		t.Errorf("Expected the second email address to be invalid, got %v", fields[0])
+	}
+
+	if fields[1].Field != "1.role" || fields[1].Code != "validation_required" {
+		t.Errorf("Expected the second role to be required, got %v", fields[1])
+	}
+}
+
+func TestRecoverer(t *testing.T) {
+	t.Run("sends registered codes", func(t *testing.T) {
+		p := serve(t, Conflict)
+		if p.Code != Conflict || p.Status != http.StatusConflict || p.Type != TypeBase+"conflict" {
+			t.Errorf("Expected a conflict problem, got %v", p)
+		}
+	})
+
+	t.Run("maps anansi validation errors to fields", func(t *testing.T) {
+		p := serve(t, anansi.APIError{
+			Code:    http.StatusBadRequest,
+			Message: "We could not validate your request.",
+			Meta:    ozzo.Validate(invite{}),
+		})
+
+		if p.Code != ValidationFailed || len(p.Errors) != 2 {
+			t.Errorf("Expected a validation problem with 2 fields, got %v", p)
+		}
+	})
+
+	t.Run("maps anansi errors by status", func(t *testing.T) {
+		p := serve(t, anansi.APIError{Code: http.StatusUnauthorized, Message: "Your token is invalid"})
+		if p.Code != Unauthorized || p.Detail != "Your token is invalid" {
+			t.Errorf("Expected an unauthorized problem, got %v", p)
+		}
+	})
+
+	t.Run("hides unknown errors", func(t *testing.T) {
+		p := serve(t, errors.New("pq: connection refused"))
+		if p.Code != InternalError || p.Detail != InternalError.Error() {
+			t.Errorf("Expected a generic internal error, got %v", p)
+		}
+	})
+}
+
+func TestFrom(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 0)
+	defer cancel()
+	<-ctx.Done()
+
+	if p := From(ctx, context.DeadlineExceeded); p.Code != Timeout {
+		t.Errorf("Expected errors after the deadline to be timeouts, got %s", p.Code)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a code twice to panic")
+		}
+	}()
+
+	Register("conflict", http.StatusConflict, "Duplicate")
+}
+
+func TestLocalize(t *testing.T) {
+	serveIn := func(locale string, err error) Problem {
+		handler := Recoverer("prod")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(err)
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/invitations", nil)
+		req = req.WithContext(i18n.WithLocale(req.Context(), locale))
+
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		var p Problem
+		if err := json.Unmarshal(res.Body.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	t.Run("translates titles and details", func(t *testing.T) {
+		p := serveIn("fr", anansi.APIError{
+			Code:    http.StatusBadRequest,
+			Message: "We could not validate your request.",
+			Meta:    ozzo.Validate(invite{EmailAddress: "jane"}),
+		})
+
+		if p.Title != "Nous n'avons pas pu valider votre requête" {
+			t.Errorf("Expected a French title, got %q", p.Title)
+		}
+
+		if len(p.Errors) != 2 || p.Errors[0].Message != "doit être une adresse e-mail valide" {
+			t.Errorf("Expected French field messages, got %v", p.Errors)
+		}
+	})
+
+	t.Run("fills in rule params", func(t *testing.T) {
+		type name struct {
+			First string `json:"first"`
+		}
+		n := name{"J"}
+
+		p := serveIn("fr", anansi.APIError{
+			Code:    http.StatusBadRequest,
+			Message: "We could not validate your request.",
+			Meta:    ozzo.ValidateStruct(&n, ozzo.Field(&n.First, ozzo.Length(2, 20))),
+		})
+
+		if len(p.Errors) != 1 || p.Errors[0].Message != "la longueur doit être comprise entre 2 et 20" {
+			t.Errorf("Expected the length in the message, got %v", p.Errors)
+		}
+	})
+
+	t.Run("translates details by key", func(t *testing.T) {
+		p := serveIn("fr", Localized(Forbidden, "admin_required.invite_users"))
+
+		if p.Detail != "Vous n'êtes pas autorisé à inviter d'autres utilisateurs" {
+			t.Errorf("Expected the detail from the catalogue, got %q", p.Detail)
+		}
+	})
+
+	t.Run("leaves English alone", func(t *testing.T) {
+		p := serveIn("en", anansi.APIError{Code: http.StatusUnauthorized, Message: "Your token is invalid"})
+		if p.Detail != "Your token is invalid" {
+			t.Errorf("Expected the original detail, got %q", p.Detail)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package problems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/tsaron/anansi"
+)
+
+// Recoverer turns panics from handlers into problem responses, including the APIErrors
+// anansi panics with while reading requests and sessions. Unknown errors are logged with
+// the request ID and hidden behind a generic internal_error.
+func Recoverer(env string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rvr := recover()
+				if rvr == nil {
+					return
+				}
+
+				if rvr == http.ErrAbortHandler {
+					panic(rvr)
+				}
+
+				err, ok := rvr.(error)
+				if !ok {
+					err = fmt.Errorf("%v", rvr)
+				}
+
+				// give dev a chance to trace unknown errors
+				if env == "dev" || env == "test" {
+					if p := From(r.Context(), err); p.Status >= http.StatusInternalServerError {
+						debug.PrintStack()
+					}
+				}
+
+				Report(w, r, err)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// From converts any error into a problem, consulting the errors registered with Map
+// before falling back to an internal_error.
+func From(ctx context.Context, err error) Problem {
+	var p Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	var code Code
+	if errors.As(err, &code) {
+		return New(code, "")
+	}
+
+	if code, ok := translate(err); ok {
+		return Wrap(code, err)
+	}
+
+	var apiErr anansi.APIError
+	if errors.As(err, &apiErr) {
+		return fromAPIError(apiErr)
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return Wrap(Timeout, err)
+	}
+
+	return Wrap(InternalError, err)
+}
+
+func fromAPIError(e anansi.APIError) Problem {
+	if meta, ok := e.Meta.(ozzo.Errors); ok {
+		return Validation(meta)
+	}
+
+	code, ok := byStatus[e.Code]
+	switch {
+	case !ok:
+		code = InternalError
+	case e.Code == http.StatusBadRequest && e.Message == "We cannot parse your request body.":
+		code = MalformedBody
+	case e.Code == http.StatusBadRequest && e.Message == "We could not parse your request query.":
+		code = InvalidQuery
+	}
+
+	p := New(code, e.Message)
+	p.Err = e.Err
+
+	return p
+}
+
+// NotFoundHandler responds to unknown routes with a not_found problem.
+func NotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	Send(w, r, New(NotFound, ""))
+}
+
+// MethodNotAllowedHandler responds to unsupported methods with a method_not_allowed problem.
+func MethodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	Send(w, r, New(MethodNotAllowed, ""))
+}
@@ -0,0 +1,161 @@
+package rest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/rs/zerolog"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/audit"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+)
+
+var csvHeader = []string{
+	"id", "created_at", "workspace", "actor", "action", "target", "ip_address", "user_agent", "metadata",
+}
+
+type auditQuery struct {
+	Actor   uint      `key:"actor"`
+	Action  string    `key:"action"`
+	Target  string    `key:"target"`
+	From    time.Time `key:"from"`
+	To      time.Time `key:"to"`
+	Page    int       `key:"page" default:"1"`
+	PerPage int       `key:"per_page" default:"20"`
+}
+
+func (q auditQuery) filter(workspace uint) audit.Filter {
+	return audit.Filter{
+		Workspace: workspace,
+		Actor:     q.Actor,
+		Action:    q.Action,
+		Target:    q.Target,
+		From:      q.From,
+		To:        q.To,
+	}
+}
+
+func AuditEvents(r *chi.Mux, app *config.App) {
+	aRepo := audit.NewRepo(app.DB)
+
+	r.Route("/audit-events", func(r chi.Router) {
+		r.Get("/", listAuditEvents(app.Auth, aRepo))
+		r.Get("/export", exportAuditEvents(app.Auth, aRepo))
+	})
+}
+
+func listAuditEvents(auth *anansi.SessionStore, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "view_audit_events"); err != nil {
+			return err
+		}
+
+		var query auditQuery
+		anansi.ReadQuery(r, &query)
+		offset, limit, err := pageBounds(query.Page, query.PerPage)
+		if err != nil {
+			return err
+		}
+
+		events, err := aRepo.List(r.Context(), query.filter(session.Workspace), offset, limit)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, events)
+		return nil
+	})
+}
+
+func exportAuditEvents(auth *anansi.SessionStore, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "export_audit_events"); err != nil {
+			return err
+		}
+
+		var query auditQuery
+		anansi.ReadQuery(r, &query)
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-events-%s.csv"`, time.Now().Format("20060102")))
+
+		out := csv.NewWriter(w)
+		if err := out.Write(csvHeader); err != nil {
+			return err
+		}
+
+		err := aRepo.Each(r.Context(), query.filter(session.Workspace), func(e *audit.Event) error {
+			meta, err := json.Marshal(e.Metadata)
+			if err != nil {
+				return err
+			}
+
+			row := []string{
+				strconv.FormatUint(uint64(e.ID), 10),
+				e.CreatedAt.Format(time.RFC3339),
+				strconv.FormatUint(uint64(e.Workspace), 10),
+				strconv.FormatUint(uint64(e.Actor), 10),
+				csvCell(e.Action),
+				csvCell(e.Target),
+				csvCell(e.IPAddress),
+				csvCell(e.UserAgent),
+				csvCell(string(meta)),
+			}
+			if err := out.Write(row); err != nil {
+				return err
+			}
+
+			// push rows to the client as we go rather than buffering the export
+			out.Flush()
+			return out.Error()
+		})
+
+		out.Flush()
+		if err == nil {
+			err = out.Error()
+		}
+
+		// the status has been sent at this point, so all we can do is log
+		if err != nil {
+			zerolog.Ctx(r.Context()).Err(err).Msg("failed to export audit events")
+		}
+
+		return nil
+	})
+}
+
+// csvCell stops spreadsheets from running cells that start like a formula, since
+// names and emails in the export come from users.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// recordEvent saves an audit event for the request. Failing to record an event
+// shouldn't undo an action that has already happened, so errors are only logged.
+func recordEvent(r *http.Request, aRepo *audit.Repo, e audit.Event) {
+	e.IPAddress = r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		e.IPAddress = host
+	}
+	e.UserAgent = r.UserAgent()
+
+	if _, err := aRepo.Record(r.Context(), e); err != nil {
+		zerolog.Ctx(r.Context()).Err(err).Str("action", e.Action).Msg("failed to record audit event")
+	}
+}
@@ -0,0 +1,21 @@
+package rest
+
+import "testing"
+
+func TestCSVCell(t *testing.T) {
+	cells := map[string]string{
+		"=HYPERLINK(\"http://evil\")": "'=HYPERLINK(\"http://evil\")",
+		"+2348012345678":              "'+2348012345678",
+		"-1":                          "'-1",
+		"@SUM(A1)":                    "'@SUM(A1)",
+		"\tcmd":                       "'\tcmd",
+		"jane@example.com":            "jane@example.com",
+		"":                            "",
+	}
+
+	for in, want := range cells {
+		if got := csvCell(in); got != want {
+			t.Errorf("Expected %q to be written as %q, got %q", in, want, got)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/audit"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/i18n"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+var (
+	hexColor    = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+	errHexColor = ozzo.NewError("validation_is_hex_color", "must be a hex colour such as #37352f")
+)
+
+type previewQuery struct {
+	Format string `key:"format" default:"html"`
+	Locale string `key:"locale"`
+}
+
+type BrandingDTO struct {
+	BrandName   string `json:"brand_name" mod:"trim"`
+	AccentColor string `json:"accent_color" mod:"smalltext"`
+	ReplyTo     string `json:"reply_to" mod:"smalltext"`
+}
+
+func (t *BrandingDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.BrandName, ozzo.Length(1, 100)),
+		ozzo.Field(&t.AccentColor, ozzo.Match(hexColor).ErrorObject(errHexColor)),
+		ozzo.Field(&t.ReplyTo, is.Email),
+	)
+}
+
+func changeBranding(auth *anansi.SessionStore, wRepo *workspaces.Repo, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "change_branding"); err != nil {
+			return err
+		}
+
+		var dto BrandingDTO
+		anansi.ReadJSON(r, &dto)
+
+		workspace, err := wRepo.ChangeBranding(r.Context(), session.Workspace, dto.BrandName, dto.AccentColor, dto.ReplyTo)
+		if err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionWorkspaceBrand,
+			Metadata: map[string]interface{}{
+				"brand_name":   workspace.BrandName,
+				"accent_color": workspace.AccentColor,
+				"reply_to":     workspace.ReplyTo,
+			},
+		})
+
+		anansi.SendSuccess(r, w, workspace)
+		return nil
+	})
+}
+
+func previewTemplate(auth *anansi.SessionStore, wRepo *workspaces.Repo, env *config.Env, templates *notification.Templates) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "preview_branding"); err != nil {
+			return err
+		}
+
+		workspace, err := wRepo.Get(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		name := anansi.StringParam(r, "template")
+		data, ok := notification.Sample(name)
+		if !ok {
+			return errTemplateNotFound
+		}
+
+		var query previewQuery
+		anansi.ReadQuery(r, &query)
+
+		locale := i18n.Resolve(query.Locale, workspace.Locale)
+		msg, err := templates.Render(name, locale, workspace.Branding(env.PublicURL), data)
+		if err != nil {
+			return err
+		}
+
+		if query.Format == "text" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			_, err = io.WriteString(w, msg.Text)
+			return err
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; img-src *; style-src 'unsafe-inline'")
+		w.WriteHeader(http.StatusOK)
+		_, err = io.WriteString(w, msg.HTML)
+
+		return err
+	})
+}
+
+// workspaceMail gets the branding and default locale for mail sent on behalf of a
+// workspace.
+func workspaceMail(r *http.Request, wRepo *workspaces.Repo, env *config.Env, id uint) (notification.Branding, string, error) {
+	workspace, err := wRepo.Get(r.Context(), id)
+	if err != nil || workspace == nil {
+		return notification.Branding{}, i18n.Default, err
+	}
+
+	return workspace.Branding(env.PublicURL), workspace.Locale, nil
+}
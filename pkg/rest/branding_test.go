@@ -0,0 +1,20 @@
+package rest
+
+import (
+	"testing"
+
+	"tsaron.com/godview-starter/pkg/notification"
+)
+
+func TestMailTemplates(t *testing.T) {
+	templates, err := notification.LoadTemplates(notification.Embedded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the packages that send mail declare their templates' data, and this package
+	// imports all of them
+	if err := templates.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,65 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/users"
+)
+
+type pageQuery struct {
+	Page    int `key:"page" default:"1"`
+	PerPage int `key:"per_page" default:"20"`
+}
+
+// requireAdmin stops members from doing what only admins can. action names the
+// message explaining what they can't do, under "admin_required." in the catalogues.
+func requireAdmin(session sessions.Session, action string) error {
+	if session.Role != users.RoleAdmin && session.Role != users.RoleOwner {
+		return problems.Localized(errAdminRequired, "admin_required."+action)
+	}
+
+	return nil
+}
+
+// requireOwner keeps what only the owner of a workspace can do from everyone else.
+func requireOwner(session sessions.Session) error {
+	if session.Role != users.RoleOwner {
+		return errOwnerRequired
+	}
+
+	return nil
+}
+
+// pageBounds converts a page query into an offset and limit, rejecting unreasonable pages.
+func pageBounds(page, perPage int) (int, int, error) {
+	if page < 1 || perPage < 1 || perPage > 100 {
+		return 0, 0, errInvalidPage
+	}
+
+	return (page - 1) * perPage, perPage, nil
+}
+
+// sessionKey returns the key of a bearer session, or an empty string for any other scheme.
+func sessionKey(r *http.Request) string {
+	parts := strings.Fields(r.Header.Get("Authorization"))
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return ""
+	}
+
+	return parts[1]
+}
+
+// memberJoined is the MemberJoined payload. Every webhook and member of the workspace
+// gets it, so it leaves out the contact details the user only shares with admins.
+func memberJoined(user *users.User) map[string]interface{} {
+	return map[string]interface{}{
+		"user":          user.ID,
+		"email_address": user.EmailAddress,
+		"role":          user.Role,
+		"first_name":    user.FirstName,
+		"last_name":     user.LastName,
+	}
+}
@@ -0,0 +1,247 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/audit"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/domains"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/users"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+type DomainDTO struct {
+	Name string `json:"name" mod:"smalltext"`
+}
+
+func (t *DomainDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.Name, ozzo.Required, is.Domain),
+	)
+}
+
+type DomainJoinDTO struct {
+	JoinMode string `json:"join_mode" mod:"smalltext"`
+	Role     string `json:"role" mod:"smalltext"`
+}
+
+func (t *DomainJoinDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.JoinMode, ozzo.Required, ozzo.In(domains.JoinAuto, domains.JoinApproval)),
+		ozzo.Field(&t.Role, ozzo.Required, ozzo.In("member", "admin")),
+	)
+}
+
+// claimedDomain shows admins the TXT record that verifies a domain.
+type claimedDomain struct {
+	domains.Domain
+	Record string `json:"record"`
+}
+
+func claimed(d *domains.Domain) claimedDomain {
+	return claimedDomain{*d, d.Record()}
+}
+
+func Domains(r *chi.Mux, app *config.App, resolver domains.Resolver) {
+	dRepo := domains.NewRepo(app.DB)
+	wRepo := workspaces.NewRepo(app.DB)
+	aRepo := audit.NewRepo(app.DB)
+
+	r.Route("/workspace/domains", func(r chi.Router) {
+		r.Post("/", addDomain(app.Auth, dRepo, aRepo))
+		r.Get("/", listDomains(app.Auth, dRepo))
+		r.Post("/{id}/verify", verifyDomain(app.Auth, dRepo, aRepo, resolver))
+		r.Patch("/{id}", changeDomainJoin(app.Auth, dRepo, wRepo, aRepo))
+		r.Delete("/{id}", removeDomain(app.Auth, dRepo, aRepo))
+	})
+}
+
+func addDomain(auth *anansi.SessionStore, dRepo *domains.Repo, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "manage_domains"); err != nil {
+			return err
+		}
+
+		var dto DomainDTO
+		anansi.ReadJSON(r, &dto)
+
+		token, err := anansi.RandomString(32)
+		if err != nil {
+			return err
+		}
+
+		d, err := dRepo.Create(r.Context(), session.Workspace, dto.Name, token)
+		if err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionDomainAdded,
+			Target:    d.Name,
+		})
+
+		anansi.SendSuccess(r, w, claimed(d))
+		return nil
+	})
+}
+
+func listDomains(auth *anansi.SessionStore, dRepo *domains.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "manage_domains"); err != nil {
+			return err
+		}
+
+		ds, err := dRepo.List(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		list := []claimedDomain{}
+		for i := range ds {
+			list = append(list, claimed(&ds[i]))
+		}
+
+		anansi.SendSuccess(r, w, list)
+		return nil
+	})
+}
+
+func verifyDomain(auth *anansi.SessionStore, dRepo *domains.Repo, aRepo *audit.Repo, resolver domains.Resolver) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "manage_domains"); err != nil {
+			return err
+		}
+
+		d, err := loadDomain(r, dRepo, session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		if d.VerifiedAt != nil {
+			anansi.SendSuccess(r, w, claimed(d))
+			return nil
+		}
+
+		if err := domains.Verify(r.Context(), resolver, d); err != nil {
+			return err
+		}
+
+		if d, err = dRepo.MarkVerified(r.Context(), session.Workspace, d.ID); err != nil {
+			return err
+		}
+
+		// the domain was removed while we checked its records
+		if d == nil {
+			return errDomainNotFound
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionDomainVerified,
+			Target:    d.Name,
+		})
+
+		anansi.SendSuccess(r, w, claimed(d))
+		return nil
+	})
+}
+
+func changeDomainJoin(auth *anansi.SessionStore, dRepo *domains.Repo, wRepo *workspaces.Repo, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "manage_domains"); err != nil {
+			return err
+		}
+
+		var dto DomainJoinDTO
+		anansi.ReadJSON(r, &dto)
+
+		workspace, err := wRepo.Get(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		// people joining by domain are invited by it, so it follows the same policy
+		if session.Role != users.RoleOwner && !workspace.InvitationPolicy.AllowsRole(dto.Role) {
+			return problems.Validation(ozzo.Errors{"role": errRoleNotAllowed})
+		}
+
+		d, err := dRepo.SetJoin(r.Context(), session.Workspace, anansi.IDParam(r, "id"), dto.JoinMode, dto.Role)
+		if err != nil {
+			return err
+		}
+
+		if d == nil {
+			return errDomainNotFound
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionDomainJoinChanged,
+			Target:    d.Name,
+			Metadata:  map[string]interface{}{"join_mode": d.JoinMode, "role": d.Role},
+		})
+
+		anansi.SendSuccess(r, w, claimed(d))
+		return nil
+	})
+}
+
+func removeDomain(auth *anansi.SessionStore, dRepo *domains.Repo, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "manage_domains"); err != nil {
+			return err
+		}
+
+		d, err := dRepo.Delete(r.Context(), session.Workspace, anansi.IDParam(r, "id"))
+		if err != nil {
+			return err
+		}
+
+		if d == nil {
+			return errDomainNotFound
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionDomainRemoved,
+			Target:    d.Name,
+		})
+
+		anansi.SendSuccess(r, w, claimed(d))
+		return nil
+	})
+}
+
+func loadDomain(r *http.Request, dRepo *domains.Repo, workspace uint) (*domains.Domain, error) {
+	d, err := dRepo.Get(r.Context(), workspace, anansi.IDParam(r, "id"))
+	if err != nil {
+		return nil, err
+	}
+
+	if d == nil {
+		return nil, errDomainNotFound
+	}
+
+	return d, nil
+}
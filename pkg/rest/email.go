@@ -0,0 +1,163 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/rs/zerolog"
+	"github.com/tsaron/anansi"
+	"github.com/tsaron/anansi/tokens"
+	"tsaron.com/godview-starter/pkg/audit"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/i18n"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/users"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+type EmailChangeDTO struct {
+	EmailAddress string `json:"email_address" mod:"smalltext"`
+	Password     string `json:"password"`
+}
+
+func (t *EmailChangeDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.EmailAddress, ozzo.Required, is.Email),
+		ozzo.Field(&t.Password, ozzo.Required),
+	)
+}
+
+func EmailChanges(r *chi.Mux, app *config.App, sStore *sessions.Store, mailer notification.Mailer) {
+	uRepo := users.NewRepo(app.DB)
+	wRepo := workspaces.NewRepo(app.DB)
+	aRepo := audit.NewRepo(app.DB)
+
+	r.Route("/email-changes", func(r chi.Router) {
+		r.Post("/", changeEmail(app.Auth, app.Tokens, uRepo, wRepo, aRepo, app.Env, mailer))
+		r.Patch("/{token}/confirm", confirmEmailChange(app.Tokens, uRepo, sStore, aRepo))
+		r.Patch("/{token}/cancel", cancelEmailChange(app.Tokens, aRepo))
+	})
+}
+
+func changeEmail(auth *anansi.SessionStore, tStore *tokens.Store, uRepo *users.Repo, wRepo *workspaces.Repo, aRepo *audit.Repo, env *config.Env, mailer notification.Mailer) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		var dto EmailChangeDTO
+		anansi.ReadJSON(r, &dto)
+
+		user, err := uRepo.Get(r.Context(), session.Workspace, session.User)
+		if err != nil {
+			return err
+		}
+
+		if user == nil {
+			return errUserNotFound
+		}
+
+		if err := users.ValidatePassword(dto.Password, user.Password); err != nil {
+			return errWrongPassword
+		}
+
+		if dto.EmailAddress == user.EmailAddress {
+			return errSameEmail
+		}
+
+		existing, err := uRepo.GetByEmail(r.Context(), dto.EmailAddress)
+		if err != nil {
+			return err
+		}
+
+		// respond as if all went well so nobody can use this to find out who has an account
+		if existing != nil {
+			zerolog.Ctx(r.Context()).Info().Uint("user", user.ID).Msg("email change to a registered address ignored")
+
+			anansi.SendSuccess(r, w, users.PlanEmailChange(user, dto.EmailAddress))
+			return nil
+		}
+
+		brand, locale, err := workspaceMail(r, wRepo, env, session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		change, confirm, cancel, err := users.NewEmailChange(r.Context(), tStore, user, dto.EmailAddress)
+		if err != nil {
+			return err
+		}
+
+		if err := users.SendEmailChange(r.Context(), mailer, env.ClientEmailPage, change, confirm, cancel, user, brand, i18n.Resolve(user.Locale, locale)); err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionEmailChangeRequested,
+			Target:    user.EmailAddress,
+			Metadata:  map[string]interface{}{"to": change.NewAddress},
+		})
+
+		anansi.SendSuccess(r, w, change)
+		return nil
+	})
+}
+
+func confirmEmailChange(tStore *tokens.Store, uRepo *users.Repo, sStore *sessions.Store, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		change, err := users.ConfirmEmailChange(r.Context(), tStore, anansi.StringParam(r, "token"))
+		if err != nil {
+			return err
+		}
+
+		user, err := uRepo.ChangeEmail(r.Context(), change.Workspace, change.User, change.EmailAddress, change.NewAddress)
+		if err != nil {
+			return err
+		}
+
+		// the user changed their address some other way in the meantime
+		if user == nil {
+			return users.ErrEmailChangeExpired
+		}
+
+		// sessions are keyed by email address, so this signs the user out everywhere
+		if err := sStore.Revoke(r.Context(), change.EmailAddress); err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: change.Workspace,
+			Actor:     change.User,
+			Action:    audit.ActionEmailChanged,
+			Target:    change.EmailAddress,
+			Metadata:  map[string]interface{}{"to": change.NewAddress},
+		})
+
+		anansi.SendSuccess(r, w, user)
+		return nil
+	})
+}
+
+func cancelEmailChange(tStore *tokens.Store, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		change, err := users.CancelEmailChange(r.Context(), tStore, anansi.StringParam(r, "token"))
+		if err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: change.Workspace,
+			Action:    audit.ActionEmailChangeCancelled,
+			Target:    change.EmailAddress,
+			Metadata:  map[string]interface{}{"to": change.NewAddress},
+		})
+
+		anansi.SendSuccess(r, w, change)
+		return nil
+	})
+}
@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+
+	"tsaron.com/godview-starter/pkg/domains"
+	"tsaron.com/godview-starter/pkg/invitations"
+	"tsaron.com/godview-starter/pkg/joinlinks"
+	"tsaron.com/godview-starter/pkg/joinrequests"
+	"tsaron.com/godview-starter/pkg/maillog"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/otp"
+	"tsaron.com/godview-starter/pkg/preferences"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/signups"
+	"tsaron.com/godview-starter/pkg/storage"
+	"tsaron.com/godview-starter/pkg/users"
+)
+
+var (
+	errInvitationExpired    = problems.Register("invitation_expired", http.StatusUnauthorized, "Your invitation token has expired")
+	errPhoneInUse           = problems.Register("phone_in_use", http.StatusConflict, "This phone number is already in use")
+	errEmailInUse           = problems.Register("email_in_use", http.StatusConflict, "One of these email addresses has already been registered")
+	errInvalidCredentials   = problems.Register("invalid_credentials", http.StatusUnauthorized, "Your email address or password is incorrect")
+	errAdminRequired        = problems.Register("admin_required", http.StatusForbidden, "Only workspace admins can do this")
+	errOwnRole              = problems.Register("own_role_change", http.StatusForbidden, "You cannot change your own role")
+	errJoinLinkNotFound     = problems.Register("join_link_not_found", http.StatusNotFound, "There is no such join link in your workspace")
+	errJoinLinkUnavailable  = problems.Register("join_link_unavailable", http.StatusGone, "This join link has expired, been revoked or used up")
+	errJoinLinkDomain       = problems.Register("join_link_domain", http.StatusForbidden, "This join link is only for email addresses at its domain")
+	errSignupExpired        = problems.Register("signup_expired", http.StatusUnauthorized, "Your signup link has expired, sign up again")
+	errDomainNotFound       = problems.Register("domain_not_found", http.StatusNotFound, "There is no such domain in your workspace")
+	errDomainAdded          = problems.Register("domain_added", http.StatusConflict, "This domain has already been added to your workspace")
+	errDomainClaimed        = problems.Register("domain_claimed", http.StatusConflict, "Another workspace has already verified this domain")
+	errDomainUnverified     = problems.Register("domain_unverified", http.StatusUnprocessableEntity, "We couldn't find the verification TXT record on this domain yet")
+	errNoDomainWorkspace    = problems.Register("domain_workspace_not_found", http.StatusNotFound, "No workspace lets people at this email's domain join")
+	errJoinApproval         = problems.Register("join_approval_required", http.StatusForbidden, "This workspace approves people at your domain before they join, ask to join instead")
+	errJoinRequestNotFound  = problems.Register("join_request_not_found", http.StatusNotFound, "There is no such join request in your workspace")
+	errJoinRequestPending   = problems.Register("join_request_pending", http.StatusConflict, "You've already asked to join this workspace, an admin will get back to you")
+	errJoinRequestDecided   = problems.Register("join_request_decided", http.StatusConflict, "This join request has already been approved or denied")
+	errJoinRequestUnneeded  = problems.Register("join_request_unneeded", http.StatusConflict, "You can join this workspace without asking")
+	errRequestNotApproved   = problems.Register("join_request_not_approved", http.StatusConflict, "Only approved join requests can be sent another invitation")
+	errRequestRegistered    = problems.Register("join_request_registered", http.StatusConflict, "This requester has already set up their profile or left the workspace")
+	errOwnerRequired        = problems.Register("owner_required", http.StatusForbidden, "Only the workspace owner can do this")
+	errInvitationExtended   = problems.Register("invitation_extension_limit", http.StatusConflict, "This invitation can't be extended again, ask for a new one")
+	errOwnerRole            = problems.Register("owner_role_locked", http.StatusForbidden, "The workspace owner's role cannot be changed")
+	errUserNotFound         = problems.Register("user_not_found", http.StatusNotFound, "There is no such user in your workspace")
+	errWebhookNotFound      = problems.Register("webhook_not_found", http.StatusNotFound, "There is no such webhook in your workspace")
+	errDeliveryNotFound     = problems.Register("delivery_not_found", http.StatusNotFound, "There is no such delivery for this webhook")
+	errWebhookDisabled      = problems.Register("webhook_disabled", http.StatusConflict, "Enable this webhook before redelivering events to it")
+	errInvalidPage          = problems.Register("invalid_pagination", http.StatusBadRequest, "page must be at least 1 and per_page must be between 1 and 100")
+	errStreamingUnsupported = problems.Register("streaming_unsupported", http.StatusNotImplemented, "Streaming is not supported on this connection")
+	errPhoneMissing         = problems.Register("phone_missing", http.StatusBadRequest, "Add a phone number to your profile before verifying it")
+	errPhoneVerified        = problems.Register("phone_already_verified", http.StatusConflict, "Your phone number has already been verified")
+	errCodeExpired          = problems.Register("verification_expired", http.StatusGone, "This verification code has expired, request a new one")
+	errCodeIncorrect        = problems.Register("verification_incorrect", http.StatusBadRequest, "This verification code is incorrect")
+	errTooManyAttempts      = problems.Register("verification_attempts_exceeded", http.StatusTooManyRequests, "Too many incorrect codes, wait a few minutes and request a new one")
+	errCodeTooSoon          = problems.Register("verification_too_soon", http.StatusTooManyRequests, "You've asked for a code too recently, wait a little before asking again")
+	errWrongPassword        = problems.Register("password_incorrect", http.StatusForbidden, "Your password is incorrect")
+	errSameEmail            = problems.Register("email_unchanged", http.StatusBadRequest, "This is already your email address")
+	errEmailChangeExpired   = problems.Register("email_change_expired", http.StatusGone, "This email change has expired or has already been used")
+	errFileMissing          = problems.Register("file_missing", http.StatusBadRequest, "Upload the file in the file field of a multipart form")
+	errFileTooLarge         = problems.Register("file_too_large", http.StatusRequestEntityTooLarge, "Files can't be larger than 5MB")
+	errUnsupportedImage     = problems.Register("unsupported_image", http.StatusUnsupportedMediaType, "Images must be PNG, JPEG, GIF or WebP")
+	errImageDimensions      = problems.Register("image_too_large", http.StatusUnprocessableEntity, "Images can't be wider or taller than 8000 pixels")
+	errNoImage              = problems.Register("image_not_found", http.StatusNotFound, "There is no image here yet")
+	errFileLinkInvalid      = problems.Register("file_link_invalid", http.StatusForbidden, "This file link is invalid or has expired")
+	errFileNotFound         = problems.Register("file_not_found", http.StatusNotFound, "This file doesn't exist")
+	errTemplateNotFound     = problems.Register("template_not_found", http.StatusNotFound, "There is no email template with this name")
+	errNotificationNotFound = problems.Register("notification_not_found", http.StatusNotFound, "There is no such notification in your inbox")
+	errCategoryLocked       = problems.Register("notification_category_locked", http.StatusForbidden, "Security notifications can't be turned off")
+	errUnsubscribeInvalid   = problems.Register("unsubscribe_link_invalid", http.StatusBadRequest, "This unsubscribe link is invalid")
+	errMailSuppressed       = problems.Register("email_suppressed", http.StatusUnprocessableEntity, "This email address bounced or reported our emails as spam, so we can't email it")
+	errMailEventsDisabled   = problems.Register("mail_events_disabled", http.StatusNotFound, "Mail events aren't set up on this server")
+	errMailEventSignature   = problems.Register("mail_event_signature_invalid", http.StatusUnauthorized, "The signature of these mail events is invalid or has expired")
+	errMailNotFound         = problems.Register("mail_not_found", http.StatusNotFound, "There is no such email in your workspace")
+	errMailRedacted         = problems.Register("mail_redacted", http.StatusGone, "This email's details have been removed, so it can't be resent")
+	errMailNotResendable    = problems.Register("mail_not_resendable", http.StatusUnprocessableEntity, "This email has a one-time link in it, so send a new one from where it came from instead")
+)
+
+func init() {
+	problems.Map(invitations.ErrExpired, errInvitationExpired)
+	problems.Map(invitations.ErrExtendedTooMuch, errInvitationExtended)
+	problems.Map(joinlinks.ErrUnavailable, errJoinLinkUnavailable)
+	problems.Map(joinlinks.ErrDomain, errJoinLinkDomain)
+	problems.Map(signups.ErrExpired, errSignupExpired)
+	problems.Map(domains.ErrAdded, errDomainAdded)
+	problems.Map(domains.ErrClaimed, errDomainClaimed)
+	problems.Map(domains.ErrUnverified, errDomainUnverified)
+	problems.Map(domains.ErrNoWorkspace, errNoDomainWorkspace)
+	problems.Map(domains.ErrApprovalRequired, errJoinApproval)
+	problems.Map(joinrequests.ErrPending, errJoinRequestPending)
+	problems.Map(joinrequests.ErrDecided, errJoinRequestDecided)
+	problems.Map(users.ErrExistingPhoneNumber, errPhoneInUse)
+	problems.Map(users.ErrEmailChangeExpired, errEmailChangeExpired)
+	problems.Map(notification.ErrUnknownTemplate, errTemplateNotFound)
+	problems.Map(notification.ErrSuppressed, errMailSuppressed)
+	problems.Map(notification.ErrInvalidEventSignature, errMailEventSignature)
+	problems.Map(storage.ErrUnsupportedImage, errUnsupportedImage)
+	problems.Map(storage.ErrImageTooLarge, errImageDimensions)
+	problems.Map(storage.ErrURLExpired, errFileLinkInvalid)
+	problems.Map(storage.ErrInvalidSignature, errFileLinkInvalid)
+	problems.Map(storage.ErrInvalidKey, errFileNotFound)
+	problems.Map(storage.ErrNotFound, errFileNotFound)
+	problems.Map(preferences.ErrInvalidToken, errUnsubscribeInvalid)
+	problems.Map(otp.ErrExpired, errCodeExpired)
+	problems.Map(otp.ErrIncorrect, errCodeIncorrect)
+	problems.Map(otp.ErrTooManyAttempts, errTooManyAttempts)
+	problems.Map(otp.ErrTooSoon, errCodeTooSoon)
+	problems.Map(maillog.ErrNotResendable, errMailNotResendable)
+	problems.MapFunc(func(err error) bool {
+		var e users.ErrEmail
+		return errors.As(err, &e)
+	}, errEmailInUse)
+}
@@ -0,0 +1,23 @@
+package rest
+
+import (
+	"testing"
+
+	"tsaron.com/godview-starter/pkg/i18n"
+	"tsaron.com/godview-starter/pkg/problems"
+)
+
+func TestProblemMessages(t *testing.T) {
+	// the codes of every package are registered once this one is loaded
+	for _, e := range problems.Catalogue() {
+		msg, ok := i18n.Catalogue(i18n.Default)["problem."+string(e.Code)]
+		if !ok {
+			t.Errorf("Expected a message for %s", e.Code)
+			continue
+		}
+
+		if msg != e.Title {
+			t.Errorf("Expected the message for %s to match its title %q, got %q", e.Code, e.Title, msg)
+		}
+	}
+}
@@ -1,31 +1,47 @@
 package rest
 
 import (
-	"errors"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/go-chi/chi"
 	ozzo "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/audit"
 	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/events"
 	"tsaron.com/godview-starter/pkg/invitations"
+	"tsaron.com/godview-starter/pkg/maillog"
 	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/phone"
+	"tsaron.com/godview-starter/pkg/problems"
 	"tsaron.com/godview-starter/pkg/sessions"
 	"tsaron.com/godview-starter/pkg/users"
+	"tsaron.com/godview-starter/pkg/workspaces"
 )
 
 var (
-	isPhone        = regexp.MustCompile("0[789][01][0-9]{8,8}")
-	errPhone       = ozzo.NewError("validation_is_phone", "must be a valid phone number(080xxxxxxxx)")
+	// isPhone only checks the shape of a number, it's normalised against the
+	// workspace's region once we know which workspace it belongs to.
+	isPhone        = regexp.MustCompile(`^\+?[0-9][0-9 ().-]{4,24}$`)
+	errPhone       = ozzo.NewError("validation_is_phone", "must be a valid phone number")
 	phoneValidator = ozzo.NewStringRuleWithError(
 		func(p string) bool {
 			return isPhone.MatchString(p)
 		},
 		errPhone,
 	)
+
+	errRegion       = ozzo.NewError("validation_is_region", "must be a two letter country code such as NG")
+	regionValidator = ozzo.NewStringRuleWithError(phone.ValidRegion, errRegion)
+
+	errSuppressed = ozzo.NewError("validation_email_suppressed", "bounced or reported our emails as spam, so we can't email it")
+
+	errRoleNotAllowed = ozzo.NewError("validation_role_not_allowed", "can't be granted to people invited to this workspace")
+	errEmailDomain    = ozzo.NewError("validation_email_domain", "must be at one of the domains this workspace invites from")
 )
 
 type InvitationDTO struct {
@@ -59,37 +75,58 @@ func (t *RegistrationDTO) Validate() error {
 }
 
 func Invitations(r *chi.Mux, app *config.App, sStore *sessions.Store, mailer notification.Mailer) {
-	ivStore := invitations.NewStore(app.Tokens)
+	ivStore := invitations.NewStore(app.Tokens, app.Redis)
 	uRepo := users.NewRepo(app.DB)
+	wRepo := workspaces.NewRepo(app.DB)
+	aRepo := audit.NewRepo(app.DB)
+	mlRepo := maillog.NewRepo(app.DB)
 
 	r.Route("/invitations", func(r chi.Router) {
-		r.Post("/", inviteUsers(app.Auth, uRepo, ivStore, app.Env, mailer))
-		r.Patch("/{token}/extend", extendInvitation(ivStore))
-		r.Patch("/{token}/accept", acceptInvitation(ivStore, uRepo, sStore))
+		r.Post("/", inviteUsers(app.Auth, uRepo, wRepo, ivStore, aRepo, mlRepo, app.Events, app.Env, mailer))
+		r.Get("/bounces", listBouncedInvitations(app.Auth, mlRepo))
+		r.Patch("/{token}/extend", extendInvitation(ivStore, wRepo, aRepo))
+		r.Patch("/{token}/accept", acceptInvitation(ivStore, uRepo, wRepo, sStore, aRepo, app.Events))
 	})
 }
 
-func extendInvitation(ivStore *invitations.Store) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+func extendInvitation(ivStore *invitations.Store, wRepo *workspaces.Repo, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
 		token := anansi.StringParam(r, "token")
 
-		iv, err := ivStore.Extend(r.Context(), token)
+		iv, err := ivStore.View(r.Context(), token)
 		if err != nil {
-			if errors.Is(err, invitations.ErrExpired) {
-				panic(anansi.APIError{
-					Code:    http.StatusUnauthorized,
-					Message: "Your invitation token has expired",
-				})
-			}
-			panic(err)
+			return err
 		}
 
+		workspace, err := wRepo.Get(r.Context(), iv.Workspace)
+		if err != nil {
+			return err
+		}
+
+		// the workspace is gone, so there's nothing left to extend
+		if workspace == nil {
+			return errInvitationExpired
+		}
+
+		policy := workspace.InvitationPolicy
+		iv, err = ivStore.Extend(r.Context(), token, policy.Extension(), policy.MaxExtensions)
+		if err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: iv.Workspace,
+			Action:    audit.ActionInvitationExtend,
+			Target:    iv.EmailAddress,
+		})
+
 		anansi.SendSuccess(r, w, iv)
-	}
+		return nil
+	})
 }
 
-func acceptInvitation(ivStore *invitations.Store, uRepo *users.Repo, sStore *sessions.Store) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+func acceptInvitation(ivStore *invitations.Store, uRepo *users.Repo, wRepo *workspaces.Repo, sStore *sessions.Store, aRepo *audit.Repo, bus *events.Bus) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
 		var dto RegistrationDTO
 		anansi.ReadJSON(r, &dto)
 
@@ -97,60 +134,114 @@ func acceptInvitation(ivStore *invitations.Store, uRepo *users.Repo, sStore *ses
 
 		iv, err := ivStore.View(r.Context(), token)
 		if err != nil {
-			if errors.Is(err, invitations.ErrExpired) {
-				panic(anansi.APIError{
-					Code:    http.StatusUnauthorized,
-					Message: "Your invitation token has expired",
-				})
-			}
-			panic(err)
+			return err
+		}
+
+		workspace, err := wRepo.Get(r.Context(), iv.Workspace)
+		if err != nil {
+			return err
+		}
+
+		// the workspace is gone, so there's nothing left to accept
+		if workspace == nil {
+			return errInvitationExpired
+		}
+
+		number, err := phone.Normalize(dto.PhoneNumber, workspace.Region)
+		if err != nil {
+			return problems.Validation(ozzo.Errors{"phone_number": errPhone})
 		}
 
 		user, err := uRepo.Register(r.Context(), iv.EmailAddress, users.Registration{
 			FirstName:   dto.FirstName,
 			LastName:    dto.LastName,
-			PhoneNumber: dto.PhoneNumber,
+			PhoneNumber: number,
 			Password:    dto.Password,
 		})
 		if err != nil {
-			if errors.Is(err, users.ErrExistingPhoneNumber) {
-				panic(anansi.APIError{
-					Code:    http.StatusConflict,
-					Message: err.Error(),
-				})
-			} else {
-				panic(err)
-			}
+			return err
 		}
 
 		if err := ivStore.Revoke(r.Context(), user.EmailAddress); err != nil {
-			panic(err)
+			return err
 		}
 
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: user.Workspace,
+			Actor:     user.ID,
+			Action:    audit.ActionUserRegistered,
+			Target:    user.EmailAddress,
+		})
+		// the token would let anyone watching events sign in as the new user
+		bus.Publish(r.Context(), events.InvitationAccepted, user.Workspace, map[string]interface{}{
+			"email_address": iv.EmailAddress,
+			"user":          user.ID,
+		})
+		bus.Publish(r.Context(), events.MemberJoined, user.Workspace, memberJoined(user))
+
 		session, err := sStore.Create(r.Context(), user)
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		anansi.SendSuccess(r, w, session)
-	}
+		return nil
+	})
 }
 
-func inviteUsers(auth *anansi.SessionStore, uRepo *users.Repo, ivStore *invitations.Store, env *config.Env, mailer notification.Mailer) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+func inviteUsers(auth *anansi.SessionStore, uRepo *users.Repo, wRepo *workspaces.Repo, ivStore *invitations.Store, aRepo *audit.Repo, mlRepo *maillog.Repo, bus *events.Bus, env *config.Env, mailer notification.Mailer) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
 		var session sessions.Session
 		auth.Load(r, &session)
 
-		if session.Role == "member" {
-			panic(anansi.APIError{
-				Code:    http.StatusForbidden,
-				Message: "You are not allowed to invite other users",
-			})
+		if err := requireAdmin(session, "invite_users"); err != nil {
+			return err
 		}
 
 		var dtos []InvitationDTO
 		anansi.ReadJSON(r, &dtos)
 
+		workspace, err := wRepo.Get(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+		policy := workspace.InvitationPolicy
+
+		// catch invitations the policy forbids and addresses we can't mail before
+		// creating anyone
+		invalid := ozzo.Errors{}
+		for i, dto := range dtos {
+			errs := ozzo.Errors{}
+
+			// the owner sets the policy, so it only holds back admins
+			if session.Role != users.RoleOwner && !policy.AllowsRole(dto.Role) {
+				errs["role"] = errRoleNotAllowed
+			}
+
+			if !policy.AllowsEmail(dto.EmailAddress) {
+				errs["email_address"] = errEmailDomain
+			} else {
+				suppressed, err := mlRepo.Suppressed(r.Context(), dto.EmailAddress)
+				if err != nil {
+					return err
+				}
+
+				if suppressed {
+					errs["email_address"] = errSuppressed
+				}
+			}
+
+			if len(errs) > 0 {
+				invalid[strconv.Itoa(i)] = errs
+			}
+		}
+		if len(invalid) > 0 {
+			return problems.Validation(invalid)
+		}
+
+		// invited users haven't picked a language yet
+		brand, locale := workspace.Branding(env.PublicURL), workspace.Locale
+
 		// create the invited users
 		var reqs []users.UserRequest
 		for _, dto := range dtos {
@@ -161,31 +252,64 @@ func inviteUsers(auth *anansi.SessionStore, uRepo *users.Repo, ivStore *invitati
 		}
 		ux, err := uRepo.CreateMany(r.Context(), session.Workspace, reqs)
 		if err != nil {
-			if errMail, ok := err.(users.ErrEmail); ok {
-				panic(anansi.APIError{
-					Code:    http.StatusConflict,
-					Message: errMail.Error(),
-				})
-			} else {
-				panic(err)
-			}
+			return err
 		}
 
 		// send them mail invitations
 		var ivs []invitations.Invitation
 		for _, u := range ux {
-			iv, err := ivStore.Create(r.Context(), session.Workspace, session.CompanyName, u.EmailAddress)
+			iv, err := ivStore.Create(r.Context(), session.Workspace, session.CompanyName, u.EmailAddress, policy.TTL())
 			if err != nil {
-				panic(err)
+				return err
 			}
 
-			if err := invitations.SendInvitation(mailer, env.ClientUserPage, iv); err != nil {
-				panic(err)
+			if err := invitations.SendInvitation(r.Context(), mailer, env.ClientUserPage, iv, brand, locale); err != nil {
+				return err
 			}
 
+			recordEvent(r, aRepo, audit.Event{
+				Workspace: session.Workspace,
+				Actor:     session.User,
+				Action:    audit.ActionInvitationSent,
+				Target:    u.EmailAddress,
+				Metadata:  map[string]interface{}{"role": u.Role},
+			})
+			bus.Publish(r.Context(), events.InvitationSent, session.Workspace, map[string]interface{}{
+				"email_address": u.EmailAddress,
+				"role":          u.Role,
+				"invited_by":    session.User,
+			})
+
 			ivs = append(ivs, iv)
 		}
 
 		anansi.SendSuccess(r, w, ivs)
-	}
+		return nil
+	})
+}
+
+// listBouncedInvitations shows admins the invitations that never reached their invitee.
+func listBouncedInvitations(auth *anansi.SessionStore, mlRepo *maillog.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "invite_users"); err != nil {
+			return err
+		}
+
+		var query pageQuery
+		anansi.ReadQuery(r, &query)
+		offset, limit, err := pageBounds(query.Page, query.PerPage)
+		if err != nil {
+			return err
+		}
+
+		messages, err := mlRepo.Undelivered(r.Context(), session.Workspace, "invitation", offset, limit)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, messages)
+		return nil
+	})
 }
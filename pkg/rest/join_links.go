@@ -0,0 +1,244 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/audit"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/joinlinks"
+	"tsaron.com/godview-starter/pkg/maillog"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/signups"
+	"tsaron.com/godview-starter/pkg/users"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+type JoinLinkDTO struct {
+	Role           string `json:"role" mod:"smalltext"`
+	MaxUses        int    `json:"max_uses"`
+	ExpiresInHours int    `json:"expires_in_hours"`
+	Domain         string `json:"domain" mod:"smalltext"`
+}
+
+func (t *JoinLinkDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.Role, ozzo.Required, ozzo.In("member", "admin")),
+		ozzo.Field(&t.MaxUses, ozzo.Required, ozzo.Min(1), ozzo.Max(1000)),
+		ozzo.Field(&t.ExpiresInHours, ozzo.Required, ozzo.Min(1), ozzo.Max(24*90)),
+		ozzo.Field(&t.Domain, is.Domain),
+	)
+}
+
+type JoinDTO struct {
+	EmailAddress string `json:"email_address" mod:"smalltext"`
+}
+
+func (t *JoinDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.EmailAddress, ozzo.Required, is.Email),
+	)
+}
+
+// joined tells someone who used a join link to look for the signup email, without
+// the token that proves they own the address.
+type joined struct {
+	EmailAddress string `json:"email_address"`
+	CompanyName  string `json:"company_name"`
+}
+
+func JoinLinks(r *chi.Mux, app *config.App, mailer notification.Mailer) {
+	jlRepo := joinlinks.NewRepo(app.DB)
+	suStore := signups.NewStore(app.Tokens, app.Redis)
+	uRepo := users.NewRepo(app.DB)
+	wRepo := workspaces.NewRepo(app.DB)
+	aRepo := audit.NewRepo(app.DB)
+	mlRepo := maillog.NewRepo(app.DB)
+
+	r.Route("/join-links", func(r chi.Router) {
+		r.Post("/", createJoinLink(app.Auth, jlRepo, wRepo, aRepo))
+		r.Get("/", listJoinLinks(app.Auth, jlRepo))
+		r.Delete("/{id}", revokeJoinLink(app.Auth, jlRepo, aRepo))
+		r.Post("/{token}/accept", acceptJoinLink(jlRepo, mlRepo, suStore, uRepo, wRepo, app.Env, mailer))
+	})
+}
+
+func createJoinLink(auth *anansi.SessionStore, jlRepo *joinlinks.Repo, wRepo *workspaces.Repo, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "manage_join_links"); err != nil {
+			return err
+		}
+
+		var dto JoinLinkDTO
+		anansi.ReadJSON(r, &dto)
+
+		workspace, err := wRepo.Get(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		// links are invitations to whoever has them, so they follow the same policy
+		if session.Role != users.RoleOwner && !workspace.InvitationPolicy.AllowsRole(dto.Role) {
+			return problems.Validation(ozzo.Errors{"role": errRoleNotAllowed})
+		}
+
+		token, err := anansi.RandomString(32)
+		if err != nil {
+			return err
+		}
+
+		link, err := jlRepo.Create(r.Context(), &joinlinks.Link{
+			Workspace: session.Workspace,
+			CreatedBy: session.User,
+			Token:     token,
+			Role:      dto.Role,
+			MaxUses:   dto.MaxUses,
+			Domain:    dto.Domain,
+			ExpiresAt: time.Now().Add(time.Duration(dto.ExpiresInHours) * time.Hour),
+		})
+		if err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionJoinLinkCreated,
+			Target:    link.Domain,
+			Metadata:  map[string]interface{}{"id": link.ID, "role": link.Role, "max_uses": link.MaxUses},
+		})
+
+		anansi.SendSuccess(r, w, link)
+		return nil
+	})
+}
+
+func listJoinLinks(auth *anansi.SessionStore, jlRepo *joinlinks.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "manage_join_links"); err != nil {
+			return err
+		}
+
+		var query pageQuery
+		anansi.ReadQuery(r, &query)
+		offset, limit, err := pageBounds(query.Page, query.PerPage)
+		if err != nil {
+			return err
+		}
+
+		links, err := jlRepo.List(r.Context(), session.Workspace, offset, limit)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, links)
+		return nil
+	})
+}
+
+func revokeJoinLink(auth *anansi.SessionStore, jlRepo *joinlinks.Repo, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "manage_join_links"); err != nil {
+			return err
+		}
+
+		link, err := jlRepo.Revoke(r.Context(), session.Workspace, anansi.IDParam(r, "id"))
+		if err != nil {
+			return err
+		}
+
+		if link == nil {
+			return errJoinLinkNotFound
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionJoinLinkRevoked,
+			Target:    link.Domain,
+			Metadata:  map[string]interface{}{"id": link.ID, "uses": link.Uses},
+		})
+
+		anansi.SendSuccess(r, w, link)
+		return nil
+	})
+}
+
+// acceptJoinLink emails whoever wants to use a join link a signup to confirm their
+// address. Nothing is taken from the link until they confirm, and the response is the
+// same whether or not the address already has an account.
+func acceptJoinLink(jlRepo *joinlinks.Repo, mlRepo *maillog.Repo, suStore *signups.Store, uRepo *users.Repo, wRepo *workspaces.Repo, env *config.Env, mailer notification.Mailer) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var dto JoinDTO
+		anansi.ReadJSON(r, &dto)
+
+		token := anansi.StringParam(r, "token")
+		email := strings.ToLower(dto.EmailAddress)
+
+		link, err := jlRepo.Find(r.Context(), token)
+		if err != nil {
+			return err
+		}
+
+		if link == nil || !link.Available() {
+			return errJoinLinkUnavailable
+		}
+
+		if !link.Allows(email) {
+			return joinlinks.ErrDomain
+		}
+
+		workspace, err := wRepo.Get(r.Context(), link.Workspace)
+		if err != nil {
+			return err
+		}
+
+		if workspace == nil {
+			return errJoinLinkUnavailable
+		}
+
+		if err := checkJoiner(r, mlRepo, workspace, email); err != nil {
+			return err
+		}
+
+		if err := startSignup(r, suStore, uRepo, env, mailer, email, token, workspace); err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, joined{email, workspace.CompanyName})
+		return nil
+	})
+}
+
+// checkJoiner stops people joining without an invitation when an invitation couldn't
+// have been sent to them.
+func checkJoiner(r *http.Request, mlRepo *maillog.Repo, workspace *workspaces.Workspace, email string) error {
+	if !workspace.InvitationPolicy.AllowsEmail(email) {
+		return problems.Validation(ozzo.Errors{"email_address": errEmailDomain})
+	}
+
+	// don't take a place for an address the invitation can't reach
+	suppressed, err := mlRepo.Suppressed(r.Context(), email)
+	if err != nil {
+		return err
+	}
+
+	if suppressed {
+		return problems.Validation(ozzo.Errors{"email_address": errSuppressed})
+	}
+
+	return nil
+}
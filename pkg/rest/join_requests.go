@@ -0,0 +1,250 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/rs/zerolog"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/audit"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/events"
+	"tsaron.com/godview-starter/pkg/invitations"
+	"tsaron.com/godview-starter/pkg/joinrequests"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/users"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+type JoinRequestDTO struct {
+	FirstName string `json:"first_name" mod:"trim"`
+	LastName  string `json:"last_name" mod:"trim"`
+}
+
+func (t *JoinRequestDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.FirstName, ozzo.Required, ozzo.Length(1, 100)),
+		ozzo.Field(&t.LastName, ozzo.Required, ozzo.Length(1, 100)),
+	)
+}
+
+type joinRequestQuery struct {
+	Status  string `key:"status" default:"pending"`
+	Page    int    `key:"page" default:"1"`
+	PerPage int    `key:"per_page" default:"20"`
+}
+
+func JoinRequests(r *chi.Mux, app *config.App, mailer notification.Mailer) {
+	jrRepo := joinrequests.NewRepo(app.DB)
+	ivStore := invitations.NewStore(app.Tokens, app.Redis)
+	uRepo := users.NewRepo(app.DB)
+	wRepo := workspaces.NewRepo(app.DB)
+	aRepo := audit.NewRepo(app.DB)
+
+	r.Route("/join-requests", func(r chi.Router) {
+		r.Get("/", listJoinRequests(app.Auth, jrRepo))
+		r.Post("/{id}/approve", approveJoinRequest(app.Auth, jrRepo, ivStore, uRepo, wRepo, aRepo, app.Events, app.Env, mailer))
+		r.Post("/{id}/reinvite", reinviteJoinRequest(app.Auth, jrRepo, ivStore, uRepo, wRepo, aRepo, app.Env, mailer))
+		r.Post("/{id}/deny", denyJoinRequest(app.Auth, jrRepo, wRepo, aRepo, app.Env, mailer))
+	})
+}
+
+func listJoinRequests(auth *anansi.SessionStore, jrRepo *joinrequests.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "review_join_requests"); err != nil {
+			return err
+		}
+
+		var query joinRequestQuery
+		anansi.ReadQuery(r, &query)
+		offset, limit, err := pageBounds(query.Page, query.PerPage)
+		if err != nil {
+			return err
+		}
+
+		requests, err := jrRepo.List(r.Context(), session.Workspace, query.Status, offset, limit)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, requests)
+		return nil
+	})
+}
+
+// approveJoinRequest turns the requester into a user with the chosen role and sends
+// them an invitation to set up their profile. If the invitation fails the request
+// stays approved, and admins can send it again with reinviteJoinRequest.
+func approveJoinRequest(auth *anansi.SessionStore, jrRepo *joinrequests.Repo, ivStore *invitations.Store, uRepo *users.Repo, wRepo *workspaces.Repo, aRepo *audit.Repo, bus *events.Bus, env *config.Env, mailer notification.Mailer) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "review_join_requests"); err != nil {
+			return err
+		}
+
+		var dto RoleDTO
+		anansi.ReadJSON(r, &dto)
+
+		req, err := loadJoinRequest(r, jrRepo, session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		workspace, err := wRepo.Get(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		// approving a request is inviting the requester, so it follows the same policy
+		if session.Role != users.RoleOwner && !workspace.InvitationPolicy.AllowsRole(dto.Role) {
+			return problems.Validation(ozzo.Errors{"role": errRoleNotAllowed})
+		}
+
+		claim := jrRepo.Approve(r.Context(), session.Workspace, req.ID, session.User, dto.Role)
+		user, err := uRepo.JoinNamed(r.Context(), req.EmailAddress, req.FirstName, req.LastName, claim)
+		if err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionJoinRequestApproved,
+			Target:    user.EmailAddress,
+			Metadata:  map[string]interface{}{"id": req.ID, "role": user.Role},
+		})
+		bus.Publish(r.Context(), events.MemberJoined, user.Workspace, memberJoined(user))
+
+		if err := inviteRequester(r, ivStore, env, mailer, workspace, req); err != nil {
+			return err
+		}
+
+		if req, err = jrRepo.Get(r.Context(), session.Workspace, req.ID); err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, req)
+		return nil
+	})
+}
+
+// reinviteJoinRequest sends an approved requester who hasn't set up their profile a
+// new invitation, for when the one sent on approval failed or expired.
+func reinviteJoinRequest(auth *anansi.SessionStore, jrRepo *joinrequests.Repo, ivStore *invitations.Store, uRepo *users.Repo, wRepo *workspaces.Repo, aRepo *audit.Repo, env *config.Env, mailer notification.Mailer) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "review_join_requests"); err != nil {
+			return err
+		}
+
+		req, err := loadJoinRequest(r, jrRepo, session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		if req.Status != joinrequests.StatusApproved {
+			return errRequestNotApproved
+		}
+
+		user, err := uRepo.GetByEmail(r.Context(), req.EmailAddress)
+		if err != nil {
+			return err
+		}
+
+		// they've set up a profile, left, or moved to another address since
+		if user == nil || user.Workspace != session.Workspace || len(user.Password) != 0 {
+			return errRequestRegistered
+		}
+
+		workspace, err := wRepo.Get(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		if err := inviteRequester(r, ivStore, env, mailer, workspace, req); err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionInvitationSent,
+			Target:    user.EmailAddress,
+			Metadata:  map[string]interface{}{"role": user.Role, "join_request": req.ID},
+		})
+
+		anansi.SendSuccess(r, w, req)
+		return nil
+	})
+}
+
+func denyJoinRequest(auth *anansi.SessionStore, jrRepo *joinrequests.Repo, wRepo *workspaces.Repo, aRepo *audit.Repo, env *config.Env, mailer notification.Mailer) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "review_join_requests"); err != nil {
+			return err
+		}
+
+		req, err := loadJoinRequest(r, jrRepo, session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		if req, err = jrRepo.Deny(r.Context(), session.Workspace, req.ID, session.User); err != nil {
+			return err
+		}
+
+		workspace, err := wRepo.Get(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionJoinRequestDenied,
+			Target:    req.EmailAddress,
+			Metadata:  map[string]interface{}{"id": req.ID},
+		})
+
+		// the request is decided either way, so there's nothing to retry
+		if err := joinrequests.SendDenied(r.Context(), mailer, req, workspace.CompanyName, workspace.Branding(env.PublicURL), workspace.Locale); err != nil {
+			zerolog.Ctx(r.Context()).Err(err).Uint("request", req.ID).Msg("failed to mail join request denial")
+		}
+
+		anansi.SendSuccess(r, w, req)
+		return nil
+	})
+}
+
+// inviteRequester invites an approved requester to set up their profile.
+func inviteRequester(r *http.Request, ivStore *invitations.Store, env *config.Env, mailer notification.Mailer, workspace *workspaces.Workspace, req *joinrequests.Request) error {
+	iv, err := ivStore.Create(r.Context(), workspace.ID, workspace.CompanyName, req.EmailAddress, workspace.InvitationPolicy.TTL())
+	if err != nil {
+		return err
+	}
+
+	// the requester hasn't picked a language yet
+	return joinrequests.SendApproved(r.Context(), mailer, env.ClientUserPage, req, iv, workspace.Branding(env.PublicURL), workspace.Locale)
+}
+
+func loadJoinRequest(r *http.Request, jrRepo *joinrequests.Repo, workspace uint) (*joinrequests.Request, error) {
+	req, err := jrRepo.Get(r.Context(), workspace, anansi.IDParam(r, "id"))
+	if err != nil {
+		return nil, err
+	}
+
+	if req == nil {
+		return nil, errJoinRequestNotFound
+	}
+
+	return req, nil
+}
@@ -0,0 +1,228 @@
+package rest
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/rs/zerolog"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/audit"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/maillog"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+const (
+	// how old a signed batch of mail events can be before we treat it as replayed
+	mailEventTolerance = time.Minute * 10
+	maxMailEventsSize  = 1 << 20
+)
+
+type mailQuery struct {
+	Receiver string    `key:"receiver"`
+	Template string    `key:"template"`
+	Status   string    `key:"status"`
+	From     time.Time `key:"from"`
+	To       time.Time `key:"to"`
+	Page     int       `key:"page" default:"1"`
+	PerPage  int       `key:"per_page" default:"20"`
+}
+
+func (q mailQuery) filter(workspace uint) maillog.Filter {
+	return maillog.Filter{
+		Workspace: workspace,
+		Receiver:  q.Receiver,
+		Template:  q.Template,
+		Status:    q.Status,
+		From:      q.From,
+		To:        q.To,
+	}
+}
+
+type mailEventsReceived struct {
+	Received int `json:"received"`
+}
+
+func MailEvents(r *chi.Mux, app *config.App) {
+	mlRepo := maillog.NewRepo(app.DB)
+
+	var key *ecdsa.PublicKey
+	if app.Env.SendgridEventKey != "" {
+		var err error
+		if key, err = notification.ParseEventKey(app.Env.SendgridEventKey); err != nil {
+			panic(err)
+		}
+	}
+
+	r.Post("/mail/events", receiveMailEvents(key, mlRepo))
+}
+
+// receiveMailEvents takes SendGrid's event webhook. Events about mail we don't know
+// are still used to suppress bad addresses.
+func receiveMailEvents(key *ecdsa.PublicKey, mlRepo *maillog.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		if key == nil {
+			return errMailEventsDisabled
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMailEventsSize))
+		if err != nil {
+			return problems.Wrap(problems.MalformedBody, err)
+		}
+
+		evs, err := notification.VerifyEvents(key, body,
+			r.Header.Get(notification.EventSignatureHeader),
+			r.Header.Get(notification.EventTimestampHeader),
+			mailEventTolerance,
+		)
+		if errors.Is(err, notification.ErrMalformedEvents) {
+			return problems.Wrap(problems.MalformedBody, err)
+		}
+		if err != nil {
+			return err
+		}
+
+		log := zerolog.Ctx(r.Context())
+		for _, e := range evs {
+			// SendGrid retries the whole batch on failure, so one bad event shouldn't block the rest
+			if err := mlRepo.Apply(r.Context(), e); err != nil {
+				log.Err(err).Str("event", e.Event).Str("mail_id", e.MailID).Msg("failed to apply mail event")
+			}
+		}
+
+		anansi.SendSuccess(r, w, mailEventsReceived{len(evs)})
+		return nil
+	})
+}
+
+func MailLog(r *chi.Mux, app *config.App, mailer notification.Mailer) {
+	mlRepo := maillog.NewRepo(app.DB)
+	wRepo := workspaces.NewRepo(app.DB)
+	aRepo := audit.NewRepo(app.DB)
+
+	r.Route("/mail-messages", func(r chi.Router) {
+		r.Get("/", listMailMessages(app.Auth, mlRepo))
+		r.Post("/{id}/resend", resendMail(app.Auth, mlRepo, wRepo, aRepo, app.Env, mailer))
+	})
+}
+
+func listMailMessages(auth *anansi.SessionStore, mlRepo *maillog.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "view_mail_log"); err != nil {
+			return err
+		}
+
+		var query mailQuery
+		anansi.ReadQuery(r, &query)
+		offset, limit, err := pageBounds(query.Page, query.PerPage)
+		if err != nil {
+			return err
+		}
+
+		messages, err := mlRepo.List(r.Context(), query.filter(session.Workspace), offset, limit)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, messages)
+		return nil
+	})
+}
+
+// resendMail sends a logged mail again with the workspace's current branding, and
+// responds with the log of the new mail.
+func resendMail(auth *anansi.SessionStore, mlRepo *maillog.Repo, wRepo *workspaces.Repo, aRepo *audit.Repo, env *config.Env, mailer notification.Mailer) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "resend_mail"); err != nil {
+			return err
+		}
+
+		msg, err := mlRepo.Get(r.Context(), session.Workspace, anansi.IDParam(r, "id"))
+		if err != nil {
+			return err
+		}
+
+		if msg == nil {
+			return errMailNotFound
+		}
+
+		if msg.RedactedAt != nil {
+			return errMailRedacted
+		}
+
+		if !maillog.Resendable(msg.Template) {
+			return maillog.ErrNotResendable
+		}
+
+		brand, locale, err := workspaceMail(r, wRepo, env, session.Workspace)
+		if err != nil {
+			return err
+		}
+		if msg.Locale != "" {
+			locale = msg.Locale
+		}
+
+		sentAt := time.Now()
+		err = mailer.Send(r.Context(), notification.TemplateMail{
+			Sender:        senderOf(msg),
+			Branding:      brand,
+			Locale:        locale,
+			Workspace:     msg.Workspace,
+			Category:      msg.Category,
+			ReceiverName:  msg.ReceiverName,
+			ReceiverEmail: msg.Receiver,
+			Template:      msg.Template,
+			TemplateData:  msg.Data,
+			CustomArgs: map[string]string{
+				maillog.ResentFromArg: strconv.FormatUint(uint64(msg.ID), 10),
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionMailResent,
+			Target:    msg.Receiver,
+			Metadata:  map[string]interface{}{"mail_id": msg.ID, "template": msg.Template},
+		})
+
+		resent, err := mlRepo.LastResend(r.Context(), msg.ID, sentAt)
+		if err != nil {
+			return err
+		}
+
+		// mail that skipped the log, such as to opted out receivers, has nothing newer to show
+		if resent == nil {
+			resent = msg
+		}
+
+		anansi.SendSuccess(r, w, resent)
+		return nil
+	})
+}
+
+// senderOf picks the sender a logged mail came from, which is the postmaster unless
+// it was a notification.
+func senderOf(msg *maillog.Message) *mail.Email {
+	if notification.SenderNotify != nil && msg.Sender == notification.SenderNotify.Address {
+		return notification.SenderNotify
+	}
+
+	return notification.SenderPostmaster
+}
@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/inbox"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+)
+
+type inboxPage struct {
+	Unread        int                  `json:"unread"`
+	Notifications []inbox.Notification `json:"notifications"`
+}
+
+type markedRead struct {
+	Count int `json:"count"`
+}
+
+func Notifications(r *chi.Mux, app *config.App) {
+	nRepo := inbox.NewRepo(app.DB)
+
+	r.Route("/notifications", func(r chi.Router) {
+		r.Get("/", listNotifications(app.Auth, nRepo))
+		r.Patch("/read", markAllRead(app.Auth, nRepo))
+		r.Patch("/{id}/read", markRead(app.Auth, nRepo))
+		r.Delete("/{id}", deleteNotification(app.Auth, nRepo))
+	})
+}
+
+func listNotifications(auth *anansi.SessionStore, nRepo *inbox.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		var query pageQuery
+		anansi.ReadQuery(r, &query)
+		offset, limit, err := pageBounds(query.Page, query.PerPage)
+		if err != nil {
+			return err
+		}
+
+		ns, err := nRepo.List(r.Context(), session.User, offset, limit)
+		if err != nil {
+			return err
+		}
+
+		unread, err := nRepo.Unread(r.Context(), session.User)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, inboxPage{unread, ns})
+		return nil
+	})
+}
+
+func markRead(auth *anansi.SessionStore, nRepo *inbox.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		n, err := nRepo.MarkRead(r.Context(), session.User, anansi.IDParam(r, "id"))
+		if err != nil {
+			return err
+		}
+
+		if n == nil {
+			return errNotificationNotFound
+		}
+
+		anansi.SendSuccess(r, w, n)
+		return nil
+	})
+}
+
+func markAllRead(auth *anansi.SessionStore, nRepo *inbox.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		count, err := nRepo.MarkAllRead(r.Context(), session.User)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, markedRead{count})
+		return nil
+	})
+}
+
+func deleteNotification(auth *anansi.SessionStore, nRepo *inbox.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		n, err := nRepo.Delete(r.Context(), session.User, anansi.IDParam(r, "id"))
+		if err != nil {
+			return err
+		}
+
+		if n == nil {
+			return errNotificationNotFound
+		}
+
+		anansi.SendSuccess(r, w, n)
+		return nil
+	})
+}
@@ -0,0 +1,172 @@
+package rest
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/audit"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/domains"
+	"tsaron.com/godview-starter/pkg/inbox"
+	"tsaron.com/godview-starter/pkg/invitations"
+	"tsaron.com/godview-starter/pkg/joinlinks"
+	"tsaron.com/godview-starter/pkg/joinrequests"
+	"tsaron.com/godview-starter/pkg/maillog"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/openapi"
+	"tsaron.com/godview-starter/pkg/preferences"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/storage"
+	"tsaron.com/godview-starter/pkg/stream"
+	"tsaron.com/godview-starter/pkg/users"
+	"tsaron.com/godview-starter/pkg/webhooks"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+//go:embed docs/index.html
+var docsPage []byte
+
+// operations documents every route in the API. Adding a route without an entry
+// here fails the tests.
+var operations = []openapi.Operation{
+	{Method: "POST", Path: "/invitations", Tag: "invitations", Summary: "Invite users to the workspace", Request: []InvitationDTO{}, Response: []invitations.Invitation{}},
+	{Method: "PATCH", Path: "/invitations/{token}/extend", Tag: "invitations", Summary: "Extend an invitation", Public: true, Response: invitations.Invitation{}},
+	{Method: "PATCH", Path: "/invitations/{token}/accept", Tag: "invitations", Summary: "Accept an invitation and set up a profile", Public: true, Request: RegistrationDTO{}, Response: sessions.Session{}},
+	{Method: "GET", Path: "/invitations/bounces", Tag: "invitations", Summary: "List invitation emails that bounced or were dropped", Query: pageQuery{}, Response: []maillog.Message{}},
+
+	{Method: "POST", Path: "/join-links", Tag: "invitations", Summary: "Create a link anyone can use to join the workspace", Request: JoinLinkDTO{}, Response: joinlinks.Link{}},
+	{Method: "GET", Path: "/join-links", Tag: "invitations", Summary: "List the workspace's join links", Query: pageQuery{}, Response: []joinlinks.Link{}},
+	{Method: "DELETE", Path: "/join-links/{id}", Tag: "invitations", Summary: "Revoke a join link", Response: joinlinks.Link{}},
+	{Method: "POST", Path: "/join-links/{token}/accept", Tag: "invitations", Summary: "Join a workspace through a link, which emails a signup to confirm the address", Public: true, Request: JoinDTO{}, Response: joined{}},
+
+	{Method: "POST", Path: "/signups", Tag: "invitations", Summary: "Sign up to the workspace that verified your email's domain, which emails a signup to confirm the address", Public: true, Request: JoinDTO{}, Response: joined{}},
+	{Method: "GET", Path: "/signups/{token}", Tag: "invitations", Summary: "View the workspace a confirmed signup can join", Public: true, Response: signupOffer{}},
+	{Method: "POST", Path: "/signups/{token}/join", Tag: "invitations", Summary: "Confirm a signup and set up a profile in the workspace it's for", Public: true, Request: RegistrationDTO{}, Response: sessions.Session{}},
+	{Method: "POST", Path: "/signups/{token}/request", Tag: "invitations", Summary: "Ask the admins of the workspace a confirmed signup is offered to let you in", Public: true, Request: JoinRequestDTO{}, Response: joinrequests.Request{}},
+
+	{Method: "GET", Path: "/join-requests", Tag: "invitations", Summary: "List requests to join the workspace, pending ones by default", Query: joinRequestQuery{}, Response: []joinrequests.Request{}},
+	{Method: "POST", Path: "/join-requests/{id}/approve", Tag: "invitations", Summary: "Let a requester in with a role, emailing them an invitation", Request: RoleDTO{}, Response: joinrequests.Request{}},
+	{Method: "POST", Path: "/join-requests/{id}/reinvite", Tag: "invitations", Summary: "Email an approved requester who hasn't set up their profile a new invitation", Response: joinrequests.Request{}},
+	{Method: "POST", Path: "/join-requests/{id}/deny", Tag: "invitations", Summary: "Turn down a join request, emailing the requester", Response: joinrequests.Request{}},
+
+	{Method: "POST", Path: "/sessions", Tag: "sessions", Summary: "Log in", Public: true, Request: LoginDTO{}, Response: sessions.Session{}},
+	{Method: "DELETE", Path: "/sessions", Tag: "sessions", Summary: "Log out"},
+
+	{Method: "GET", Path: "/me", Tag: "users", Summary: "View your profile", Response: users.User{}},
+	{Method: "PATCH", Path: "/me", Tag: "users", Summary: "Update your profile", Request: ProfileDTO{}, Response: users.User{}},
+	{Method: "POST", Path: "/me/password", Tag: "users", Summary: "Change your password", Request: PasswordChangeDTO{}, Response: users.User{}},
+	{Method: "PUT", Path: "/me/avatar", Tag: "users", Summary: "Upload your avatar", Upload: "file", Response: signedImage{}},
+	{Method: "DELETE", Path: "/me/avatar", Tag: "users", Summary: "Remove your avatar", Response: users.User{}},
+	{Method: "GET", Path: "/users/{id}/avatar", Tag: "users", Summary: "Get expiring links to a user's avatar", Response: signedImage{}},
+	{Method: "POST", Path: "/me/phone/verification", Tag: "users", Summary: "Text a verification code to your phone number", Response: phoneChallenge{}},
+	{Method: "POST", Path: "/me/phone/verification/{token}", Tag: "users", Summary: "Verify your phone number with the code you received", Request: PhoneCodeDTO{}, Response: users.User{}},
+	{Method: "POST", Path: "/email-changes", Tag: "users", Summary: "Change your email address once the new one is confirmed", Request: EmailChangeDTO{}, Response: users.EmailChange{}},
+	{Method: "PATCH", Path: "/email-changes/{token}/confirm", Tag: "users", Summary: "Confirm a new email address, signing out all sessions", Public: true, Response: users.User{}},
+	{Method: "PATCH", Path: "/email-changes/{token}/cancel", Tag: "users", Summary: "Cancel an email change from the old address", Public: true, Response: users.EmailChange{}},
+	{Method: "PATCH", Path: "/users/{id}/role", Tag: "users", Summary: "Change a user's role", Request: RoleDTO{}, Response: users.User{}},
+
+	{Method: "GET", Path: "/workspace", Tag: "workspace", Summary: "View the current workspace", Response: workspaces.Workspace{}},
+	{Method: "PATCH", Path: "/workspace/name", Tag: "workspace", Summary: "Rename the workspace", Request: WorkspaceNameDTO{}, Response: workspaces.Workspace{}},
+	{Method: "PATCH", Path: "/workspace/region", Tag: "workspace", Summary: "Set the region used to read national phone numbers", Request: WorkspaceRegionDTO{}, Response: workspaces.Workspace{}},
+	{Method: "PATCH", Path: "/workspace/locale", Tag: "workspace", Summary: "Set the language of the workspace's emails and messages", Request: WorkspaceLocaleDTO{}, Response: workspaces.Workspace{}},
+
+	{Method: "PUT", Path: "/workspace/logo", Tag: "workspace", Summary: "Upload the workspace logo", Upload: "file", Response: signedImage{}},
+	{Method: "DELETE", Path: "/workspace/logo", Tag: "workspace", Summary: "Remove the workspace logo", Response: workspaces.Workspace{}},
+	{Method: "GET", Path: "/workspace/logo", Tag: "workspace", Summary: "Get expiring links to the workspace logo", Response: signedImage{}},
+	{Method: "PUT", Path: "/workspace/branding", Tag: "workspace", Summary: "Change how the workspace's emails look", Request: BrandingDTO{}, Response: workspaces.Workspace{}},
+	{Method: "PUT", Path: "/workspace/invitation-policy", Tag: "workspace", Summary: "Set how long invitations last, who can be invited and as what", Request: InvitationPolicyDTO{}, Response: workspaces.Workspace{}},
+	{Method: "POST", Path: "/workspace/domains", Tag: "workspace", Summary: "Claim an email domain for the workspace", Request: DomainDTO{}, Response: claimedDomain{}},
+	{Method: "GET", Path: "/workspace/domains", Tag: "workspace", Summary: "List the workspace's email domains", Response: []claimedDomain{}},
+	{Method: "POST", Path: "/workspace/domains/{id}/verify", Tag: "workspace", Summary: "Verify a domain by its DNS TXT record", Response: claimedDomain{}},
+	{Method: "PATCH", Path: "/workspace/domains/{id}", Tag: "workspace", Summary: "Choose how people at a domain join and with what role", Request: DomainJoinDTO{}, Response: claimedDomain{}},
+	{Method: "DELETE", Path: "/workspace/domains/{id}", Tag: "workspace", Summary: "Remove a domain from the workspace", Response: claimedDomain{}},
+	{Method: "GET", Path: "/workspace/branding/preview/{template}", Tag: "workspace", Summary: "Render an email template with sample data and the workspace's branding", Query: previewQuery{}, Produces: "text/html"},
+	{Method: "GET", Path: "/workspaces/{id}/logo", Tag: "workspace", Summary: "Redirect to a workspace's logo, for use in emails", Public: true},
+	{Method: "GET", Path: "/files/{path}", Tag: "files", Summary: "Download a file through a signed link", Public: true, Produces: "application/octet-stream"},
+
+	{Method: "GET", Path: "/audit-events", Tag: "audit", Summary: "List audit events", Query: auditQuery{}, Response: []audit.Event{}},
+	{Method: "GET", Path: "/audit-events/export", Tag: "audit", Summary: "Export audit events as CSV", Query: auditQuery{}, Produces: "text/csv"},
+
+	{Method: "POST", Path: "/webhooks", Tag: "webhooks", Summary: "Register a webhook endpoint", Request: WebhookDTO{}, Response: createdWebhook{}},
+	{Method: "GET", Path: "/webhooks", Tag: "webhooks", Summary: "List webhook endpoints", Response: []webhooks.Endpoint{}},
+	{Method: "PATCH", Path: "/webhooks/{id}", Tag: "webhooks", Summary: "Update or re-enable a webhook endpoint", Request: WebhookUpdateDTO{}, Response: webhooks.Endpoint{}},
+	{Method: "DELETE", Path: "/webhooks/{id}", Tag: "webhooks", Summary: "Remove a webhook endpoint", Response: webhooks.Endpoint{}},
+	{Method: "GET", Path: "/webhooks/{id}/deliveries", Tag: "webhooks", Summary: "List deliveries to a webhook endpoint", Query: pageQuery{}, Response: []webhooks.Delivery{}},
+	{Method: "POST", Path: "/webhooks/{id}/deliveries/{delivery}/redeliver", Tag: "webhooks", Summary: "Redeliver an event", Response: webhooks.Delivery{}},
+
+	{Method: "GET", Path: "/me/notifications", Tag: "notifications", Summary: "List which notifications you get on each channel", Response: []preferences.Preference{}},
+	{Method: "PUT", Path: "/me/notifications", Tag: "notifications", Summary: "Choose which notifications you get on each channel", Request: PreferencesDTO{}, Response: []preferences.Preference{}},
+	{Method: "POST", Path: "/unsubscribe/{token}", Tag: "notifications", Summary: "Stop the emails an unsubscribe link was sent with", Public: true, Response: unsubscribed{}},
+	{Method: "GET", Path: "/notifications", Tag: "notifications", Summary: "List your notifications, unread ones first", Query: pageQuery{}, Response: inboxPage{}},
+	{Method: "PATCH", Path: "/notifications/read", Tag: "notifications", Summary: "Mark all your notifications as read", Response: markedRead{}},
+	{Method: "PATCH", Path: "/notifications/{id}/read", Tag: "notifications", Summary: "Mark a notification as read", Response: inbox.Notification{}},
+	{Method: "DELETE", Path: "/notifications/{id}", Tag: "notifications", Summary: "Remove a notification", Response: inbox.Notification{}},
+
+	{Method: "GET", Path: "/events/stream", Tag: "events", Summary: "Stream workspace activity and your new notifications as server-sent events", Produces: "text/event-stream"},
+
+	{Method: "GET", Path: "/mail-messages", Tag: "mail", Summary: "Search the emails sent for the workspace", Query: mailQuery{}, Response: []maillog.Message{}},
+	{Method: "POST", Path: "/mail-messages/{id}/resend", Tag: "mail", Summary: "Send an email again with the workspace's current branding", Response: maillog.Message{}},
+	{Method: "POST", Path: "/mail/events", Tag: "mail", Summary: "Receive SendGrid's signed delivery events", Public: true, Request: []notification.Event{}, Response: mailEventsReceived{}},
+
+	{Method: "GET", Path: "/errors", Tag: "docs", Summary: "List every error code the API can respond with", Public: true, Response: []problems.Entry{}},
+	{Method: "GET", Path: "/openapi.json", Tag: "docs", Summary: "This document", Public: true, Response: openapi.Document{}},
+	{Method: "GET", Path: "/docs", Tag: "docs", Summary: "API reference", Public: true, Produces: "text/html"},
+}
+
+// Routes sets up every route of the API.
+// Resolver looks up the DNS records domains and webhooks are checked against.
+// net.DefaultResolver is one.
+type Resolver interface {
+	domains.Resolver
+	webhooks.Resolver
+}
+
+func Routes(r *chi.Mux, app *config.App, sStore *sessions.Store, mailer notification.Mailer, templates *notification.Templates, sms notification.SMSSender, blob storage.Blob, broker *stream.Broker, resolver Resolver) {
+	Invitations(r, app, sStore, mailer)
+	JoinLinks(r, app, mailer)
+	Signups(r, app, sStore, mailer)
+	Domains(r, app, resolver)
+	JoinRequests(r, app, mailer)
+	Sessions(r, app, sStore)
+	Users(r, app)
+	Profile(r, app, sStore)
+	PhoneVerification(r, app, sms)
+	EmailChanges(r, app, sStore, mailer)
+	Uploads(r, app, blob)
+	Workspaces(r, app, templates)
+	AuditEvents(r, app)
+	Webhooks(r, app, resolver)
+	Notifications(r, app)
+	Preferences(r, app)
+	MailEvents(r, app)
+	MailLog(r, app, mailer)
+	Stream(r, app, sStore, broker)
+	Problems(r)
+	OpenAPI(r, app)
+}
+
+func OpenAPI(r *chi.Mux, app *config.App) {
+	doc := document(app.Env.Name)
+
+	r.Get("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		anansi.SendSuccess(r, w, doc)
+	})
+
+	r.Get("/docs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		// we don't have a plan for when writes fail
+		_, _ = w.Write(docsPage)
+	})
+}
+
+func document(name string) *openapi.Document {
+	return openapi.New(
+		openapi.Info{Title: name, Version: "1"},
+		"/api/v1",
+		problems.Problem{},
+		operations,
+	)
+}
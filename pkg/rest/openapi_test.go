@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/openapi"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/stream"
+)
+
+func testRouter() *chi.Mux {
+	router := chi.NewRouter()
+	app := &config.App{Env: &config.Env{Name: "godview-starter"}}
+	Routes(router, app, &sessions.Store{}, nil, nil, nil, nil, &stream.Broker{}, nil)
+
+	return router
+}
+
+func TestOperationsCoverRoutes(t *testing.T) {
+	documented := make(map[string]bool)
+	for _, op := range operations {
+		documented[op.Method+" "+openapi.Normalize(op.Path)] = true
+	}
+
+	routed := make(map[string]bool)
+	err := chi.Walk(testRouter(), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		key := method + " " + openapi.Normalize(route)
+		routed[key] = true
+
+		if !documented[key] {
+			t.Errorf("%s has no entry in the OpenAPI operations", key)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key := range documented {
+		if !routed[key] {
+			t.Errorf("%s is documented but not routed", key)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	doc := document("godview-starter")
+
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("marks required fields from validation rules", func(t *testing.T) {
+		schema := doc.Components.Schemas["RegistrationDTO"]
+		required, _ := schema["required"].([]string)
+
+		if len(required) != 4 || strings.Contains(strings.Join(required, ","), "company_name") {
+			t.Errorf("Expected every field but company_name to be required, got %v", required)
+		}
+	})
+
+	t.Run("describes string rules", func(t *testing.T) {
+		props := doc.Components.Schemas["RegistrationDTO"]["properties"].(openapi.Schema)
+		password := props["password"].(openapi.Schema)
+
+		if password["minLength"] != 8 || password["maxLength"] != 64 {
+			t.Errorf("Expected password length to be 8-64, got %v-%v", password["minLength"], password["maxLength"])
+		}
+
+		email := doc.Components.Schemas["InvitationDTO"]["properties"].(openapi.Schema)["email_address"].(openapi.Schema)
+		if email["format"] != "email" {
+			t.Errorf("Expected email_address to have the email format, got %v", email["format"])
+		}
+	})
+
+	t.Run("documents path parameters", func(t *testing.T) {
+		op := doc.Paths["/invitations/{token}/accept"]["patch"]
+		params, _ := op["parameters"].([]openapi.Schema)
+
+		if len(params) != 1 || params[0]["name"] != "token" {
+			t.Errorf("Expected a token path parameter, got %v", params)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/audit"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/otp"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/users"
+)
+
+type PhoneCodeDTO struct {
+	Code string `json:"code" mod:"trim"`
+}
+
+func (t *PhoneCodeDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.Code, ozzo.Required, ozzo.Length(6, 6), is.Digit),
+	)
+}
+
+type phoneChallenge struct {
+	Token       string    `json:"token"`
+	PhoneNumber string    `json:"phone_number"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func PhoneVerification(r *chi.Mux, app *config.App, sms notification.SMSSender) {
+	oStore := otp.NewStore(app.Tokens, app.Redis)
+	uRepo := users.NewRepo(app.DB)
+	aRepo := audit.NewRepo(app.DB)
+
+	r.Post("/me/phone/verification", startPhoneVerification(app.Auth, uRepo, oStore, sms))
+	r.Post("/me/phone/verification/{token}", verifyPhone(app.Auth, uRepo, oStore, aRepo))
+}
+
+func startPhoneVerification(auth *anansi.SessionStore, uRepo *users.Repo, oStore *otp.Store, sms notification.SMSSender) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		user, err := uRepo.Get(r.Context(), session.Workspace, session.User)
+		if err != nil {
+			return err
+		}
+
+		if user == nil {
+			return errUserNotFound
+		}
+
+		if user.PhoneNumber == "" {
+			return errPhoneMissing
+		}
+
+		if user.PhoneVerifiedAt != nil {
+			return errPhoneVerified
+		}
+
+		token, code, err := oStore.Issue(r.Context(), user.ID, user.PhoneNumber)
+		if err != nil {
+			return err
+		}
+
+		err = sms.SendSMS(r.Context(), notification.SMS{
+			To:       user.PhoneNumber,
+			Body:     fmt.Sprintf("Your %s verification code is %s. It expires in %d minutes.", session.CompanyName, code, int(otp.TTL.Minutes())),
+			Category: notification.CategorySecurity,
+		})
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, phoneChallenge{token, user.PhoneNumber, time.Now().Add(otp.TTL)})
+		return nil
+	})
+}
+
+func verifyPhone(auth *anansi.SessionStore, uRepo *users.Repo, oStore *otp.Store, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		var dto PhoneCodeDTO
+		anansi.ReadJSON(r, &dto)
+
+		c, err := oStore.Verify(r.Context(), anansi.StringParam(r, "token"), session.User, dto.Code)
+		if err != nil {
+			return err
+		}
+
+		user, err := uRepo.VerifyPhone(r.Context(), session.Workspace, session.User, c.PhoneNumber)
+		if err != nil {
+			return err
+		}
+
+		// the number changed after the code was sent
+		if user == nil {
+			return otp.ErrExpired
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionPhoneVerified,
+			Target:    user.PhoneNumber,
+		})
+
+		anansi.SendSuccess(r, w, user)
+		return nil
+	})
+}
@@ -0,0 +1,140 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/preferences"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+)
+
+var (
+	categoryNames = func() []interface{} {
+		var names []interface{}
+		for _, c := range notification.Categories {
+			names = append(names, c.Name)
+		}
+		return names
+	}()
+
+	channelNames = func() []interface{} {
+		var names []interface{}
+		for _, c := range notification.Channels {
+			names = append(names, c)
+		}
+		return names
+	}()
+)
+
+type PreferenceDTO struct {
+	Category string `json:"category"`
+	Channel  string `json:"channel"`
+	Enabled  bool   `json:"enabled"`
+}
+
+func (t PreferenceDTO) Validate() error {
+	return ozzo.ValidateStruct(&t,
+		ozzo.Field(&t.Category, ozzo.Required, ozzo.In(categoryNames...)),
+		ozzo.Field(&t.Channel, ozzo.Required, ozzo.In(channelNames...)),
+	)
+}
+
+type PreferencesDTO struct {
+	Preferences []PreferenceDTO `json:"preferences"`
+}
+
+func (t *PreferencesDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.Preferences, ozzo.Required),
+	)
+}
+
+type unsubscribed struct {
+	Category string `json:"category"`
+}
+
+func Preferences(r *chi.Mux, app *config.App) {
+	pRepo := preferences.NewRepo(app.DB)
+	signer := preferences.NewSigner(app.Env.Secret)
+
+	r.Get("/me/notifications", listPreferences(app.Auth, pRepo))
+	r.Put("/me/notifications", setPreferences(app.Auth, pRepo))
+	r.Post("/unsubscribe/{token}", unsubscribe(pRepo, signer))
+}
+
+func listPreferences(auth *anansi.SessionStore, pRepo *preferences.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		prefs, err := pRepo.List(r.Context(), session.User)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, prefs)
+		return nil
+	})
+}
+
+func setPreferences(auth *anansi.SessionStore, pRepo *preferences.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		var dto PreferencesDTO
+		anansi.ReadJSON(r, &dto)
+
+		var prefs []preferences.Preference
+		for _, p := range dto.Preferences {
+			if !p.Enabled && notification.Locked(p.Category) {
+				return errCategoryLocked
+			}
+
+			prefs = append(prefs, preferences.Preference{
+				Category: p.Category,
+				Channel:  p.Channel,
+				Enabled:  p.Enabled,
+			})
+		}
+
+		if err := pRepo.Set(r.Context(), session.User, prefs); err != nil {
+			return err
+		}
+
+		saved, err := pRepo.List(r.Context(), session.User)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, saved)
+		return nil
+	})
+}
+
+// unsubscribe turns off a category of emails for whoever the link in one was sent to.
+// Mail clients call it for one-click unsubscribes, and the client page linked in the
+// mail calls it once the receiver confirms.
+func unsubscribe(pRepo *preferences.Repo, signer *preferences.Signer) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		user, category, err := signer.Verify(chi.URLParam(r, "token"))
+		if err != nil {
+			return err
+		}
+
+		err = pRepo.Set(r.Context(), user, []preferences.Preference{
+			{Category: category, Channel: notification.ChannelEmail, Enabled: false},
+		})
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, unsubscribed{category})
+		return nil
+	})
+}
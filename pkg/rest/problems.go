@@ -0,0 +1,17 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/problems"
+)
+
+func Problems(r *chi.Mux) {
+	r.Get("/errors", listProblems)
+}
+
+func listProblems(w http.ResponseWriter, r *http.Request) {
+	anansi.SendSuccess(r, w, problems.Catalogue())
+}
@@ -0,0 +1,212 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/tsaron/anansi"
+	"golang.org/x/text/language"
+	"tsaron.com/godview-starter/pkg/audit"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/phone"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/users"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+var (
+	errTimezone       = ozzo.NewError("validation_is_timezone", "must be an IANA time zone such as Africa/Lagos")
+	timezoneValidator = ozzo.NewStringRuleWithError(
+		func(tz string) bool {
+			_, err := time.LoadLocation(tz)
+			return err == nil && tz != "Local"
+		},
+		errTimezone,
+	)
+
+	errLocale       = ozzo.NewError("validation_is_locale", "must be a language tag such as en or fr-FR")
+	localeValidator = ozzo.NewStringRuleWithError(
+		func(l string) bool {
+			_, err := language.Parse(l)
+			return err == nil
+		},
+		errLocale,
+	)
+)
+
+// ProfileDTO changes only the fields that are set.
+type ProfileDTO struct {
+	FirstName   *string `json:"first_name" mod:"trim"`
+	LastName    *string `json:"last_name" mod:"trim"`
+	PhoneNumber *string `json:"phone_number" mod:"trim"`
+	Timezone    *string `json:"timezone" mod:"trim"`
+	Locale      *string `json:"locale" mod:"trim"`
+}
+
+func (t *ProfileDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.FirstName, ozzo.NilOrNotEmpty),
+		ozzo.Field(&t.LastName, ozzo.NilOrNotEmpty),
+		ozzo.Field(&t.PhoneNumber, ozzo.NilOrNotEmpty, phoneValidator),
+		ozzo.Field(&t.Timezone, timezoneValidator),
+		ozzo.Field(&t.Locale, localeValidator),
+	)
+}
+
+type PasswordChangeDTO struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+func (t *PasswordChangeDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.CurrentPassword, ozzo.Required),
+		ozzo.Field(&t.NewPassword, ozzo.Required, ozzo.Length(8, 64)),
+	)
+}
+
+func Profile(r *chi.Mux, app *config.App, sStore *sessions.Store) {
+	uRepo := users.NewRepo(app.DB)
+	wRepo := workspaces.NewRepo(app.DB)
+	aRepo := audit.NewRepo(app.DB)
+
+	r.Get("/me", getProfile(app.Auth, uRepo))
+	r.Patch("/me", updateProfile(app.Auth, uRepo, wRepo, sStore))
+	r.Post("/me/password", changePassword(app.Auth, uRepo, aRepo))
+}
+
+func getProfile(auth *anansi.SessionStore, uRepo *users.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		user, err := loadProfile(r, uRepo, session)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, user)
+		return nil
+	})
+}
+
+func updateProfile(auth *anansi.SessionStore, uRepo *users.Repo, wRepo *workspaces.Repo, sStore *sessions.Store) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		var dto ProfileDTO
+		anansi.ReadJSON(r, &dto)
+
+		user, err := loadProfile(r, uRepo, session)
+		if err != nil {
+			return err
+		}
+
+		profile := users.Profile{
+			FirstName:   user.FirstName,
+			LastName:    user.LastName,
+			PhoneNumber: user.PhoneNumber,
+			Timezone:    user.Timezone,
+			Locale:      user.Locale,
+		}
+
+		if dto.FirstName != nil {
+			profile.FirstName = *dto.FirstName
+		}
+
+		if dto.LastName != nil {
+			profile.LastName = *dto.LastName
+		}
+
+		if dto.Timezone != nil {
+			profile.Timezone = *dto.Timezone
+		}
+
+		if dto.Locale != nil {
+			profile.Locale = ""
+			if *dto.Locale != "" {
+				profile.Locale = language.Make(*dto.Locale).String()
+			}
+		}
+
+		if dto.PhoneNumber != nil {
+			workspace, err := wRepo.Get(r.Context(), session.Workspace)
+			if err != nil {
+				return err
+			}
+
+			if profile.PhoneNumber, err = phone.Normalize(*dto.PhoneNumber, workspace.Region); err != nil {
+				return problems.Validation(ozzo.Errors{"phone_number": errPhone})
+			}
+		}
+
+		if user, err = uRepo.UpdateProfile(r.Context(), session.Workspace, session.User, profile); err != nil {
+			return err
+		}
+
+		if user == nil {
+			return errUserNotFound
+		}
+
+		if err := sStore.Refresh(r.Context(), user); err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, user)
+		return nil
+	})
+}
+
+func changePassword(auth *anansi.SessionStore, uRepo *users.Repo, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		var dto PasswordChangeDTO
+		anansi.ReadJSON(r, &dto)
+
+		user, err := loadProfile(r, uRepo, session)
+		if err != nil {
+			return err
+		}
+
+		if err := users.ValidatePassword(dto.CurrentPassword, user.Password); err != nil {
+			return errWrongPassword
+		}
+
+		if user, err = uRepo.ChangePassword(r.Context(), session.Workspace, session.User, dto.NewPassword); err != nil {
+			return err
+		}
+
+		if user == nil {
+			return errUserNotFound
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionPasswordChanged,
+			Target:    user.EmailAddress,
+		})
+
+		anansi.SendSuccess(r, w, user)
+		return nil
+	})
+}
+
+func loadProfile(r *http.Request, uRepo *users.Repo, session sessions.Session) (*users.User, error) {
+	user, err := uRepo.Get(r.Context(), session.Workspace, session.User)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, errUserNotFound
+	}
+
+	return user, nil
+}
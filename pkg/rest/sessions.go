@@ -0,0 +1,96 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/audit"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/users"
+)
+
+type LoginDTO struct {
+	EmailAddress string `json:"email_address" mod:"smalltext"`
+	Password     string `json:"password"`
+}
+
+func (t *LoginDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.EmailAddress, ozzo.Required, is.Email),
+		ozzo.Field(&t.Password, ozzo.Required),
+	)
+}
+
+func Sessions(r *chi.Mux, app *config.App, sStore *sessions.Store) {
+	uRepo := users.NewRepo(app.DB)
+	aRepo := audit.NewRepo(app.DB)
+
+	r.Route("/sessions", func(r chi.Router) {
+		r.Post("/", login(uRepo, sStore, aRepo))
+		r.Delete("/", logout(app.Auth, sStore))
+	})
+}
+
+func login(uRepo *users.Repo, sStore *sessions.Store, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var dto LoginDTO
+		anansi.ReadJSON(r, &dto)
+
+		user, err := uRepo.GetByEmail(r.Context(), dto.EmailAddress)
+		if err != nil {
+			return err
+		}
+
+		if user == nil {
+			return errInvalidCredentials
+		}
+
+		if err := users.ValidatePassword(dto.Password, user.Password); err != nil {
+			recordEvent(r, aRepo, audit.Event{
+				Workspace: user.Workspace,
+				Action:    audit.ActionUserLoginFailed,
+				Target:    user.EmailAddress,
+				Metadata:  map[string]interface{}{"reason": err.Error()},
+			})
+
+			return errInvalidCredentials
+		}
+
+		session, err := sStore.Create(r.Context(), user)
+		if err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: user.Workspace,
+			Actor:     user.ID,
+			Action:    audit.ActionUserLogin,
+			Target:    user.EmailAddress,
+		})
+
+		anansi.SendSuccess(r, w, session)
+		return nil
+	})
+}
+
+func logout(auth *anansi.SessionStore, sStore *sessions.Store) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		// headless tokens can't be revoked, they simply expire
+		if key := sessionKey(r); key != "" {
+			if err := sStore.End(r.Context(), key); err != nil {
+				return err
+			}
+		}
+
+		anansi.SendSuccess(r, w, nil)
+		return nil
+	})
+}
@@ -0,0 +1,315 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/rs/zerolog"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/audit"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/domains"
+	"tsaron.com/godview-starter/pkg/events"
+	"tsaron.com/godview-starter/pkg/joinlinks"
+	"tsaron.com/godview-starter/pkg/joinrequests"
+	"tsaron.com/godview-starter/pkg/maillog"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/phone"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/signups"
+	"tsaron.com/godview-starter/pkg/users"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+// signupOffer is the workspace a confirmed signup can join.
+type signupOffer struct {
+	EmailAddress string `json:"email_address"`
+	CompanyName  string `json:"company_name"`
+	Role         string `json:"role"`
+	// Approval is whether the workspace's admins have to let them in
+	Approval bool `json:"approval"`
+
+	workspace *workspaces.Workspace
+	link      *joinlinks.Link
+	domain    *domains.Domain
+}
+
+func Signups(r *chi.Mux, app *config.App, sStore *sessions.Store, mailer notification.Mailer) {
+	suStore := signups.NewStore(app.Tokens, app.Redis)
+	jlRepo := joinlinks.NewRepo(app.DB)
+	dRepo := domains.NewRepo(app.DB)
+	uRepo := users.NewRepo(app.DB)
+	wRepo := workspaces.NewRepo(app.DB)
+	aRepo := audit.NewRepo(app.DB)
+	mlRepo := maillog.NewRepo(app.DB)
+	jrRepo := joinrequests.NewRepo(app.DB)
+
+	r.Route("/signups", func(r chi.Router) {
+		r.Post("/", signUp(suStore, dRepo, mlRepo, uRepo, wRepo, app.Env, mailer))
+		r.Get("/{token}", viewSignup(suStore, jlRepo, dRepo, wRepo))
+		r.Post("/{token}/join", joinWorkspace(suStore, jlRepo, dRepo, uRepo, wRepo, sStore, aRepo, app.Events))
+		r.Post("/{token}/request", requestToJoin(suStore, jlRepo, dRepo, jrRepo, mlRepo, uRepo, wRepo, aRepo, app.Events, app.Env, mailer))
+	})
+}
+
+// signUp emails someone at a verified domain a signup to confirm their address, after
+// which they're offered its workspace. The response is the same whether or not the
+// address already has an account.
+func signUp(suStore *signups.Store, dRepo *domains.Repo, mlRepo *maillog.Repo, uRepo *users.Repo, wRepo *workspaces.Repo, env *config.Env, mailer notification.Mailer) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var dto JoinDTO
+		anansi.ReadJSON(r, &dto)
+
+		email := strings.ToLower(dto.EmailAddress)
+
+		d, err := dRepo.Verified(r.Context(), email)
+		if err != nil {
+			return err
+		}
+
+		if d == nil {
+			return domains.ErrNoWorkspace
+		}
+
+		workspace, err := wRepo.Get(r.Context(), d.Workspace)
+		if err != nil {
+			return err
+		}
+
+		if workspace == nil {
+			return domains.ErrNoWorkspace
+		}
+
+		if err := checkJoiner(r, mlRepo, workspace, email); err != nil {
+			return err
+		}
+
+		if err := startSignup(r, suStore, uRepo, env, mailer, email, "", workspace); err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, joined{email, workspace.CompanyName})
+		return nil
+	})
+}
+
+func viewSignup(suStore *signups.Store, jlRepo *joinlinks.Repo, dRepo *domains.Repo, wRepo *workspaces.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		offer, err := loadSignupOffer(r, suStore, jlRepo, dRepo, wRepo)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, offer)
+		return nil
+	})
+}
+
+// joinWorkspace creates the user of a confirmed signup in the workspace they were
+// offered, taking their place in it only now that they own the address.
+func joinWorkspace(suStore *signups.Store, jlRepo *joinlinks.Repo, dRepo *domains.Repo, uRepo *users.Repo, wRepo *workspaces.Repo, sStore *sessions.Store, aRepo *audit.Repo, bus *events.Bus) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var dto RegistrationDTO
+		anansi.ReadJSON(r, &dto)
+
+		offer, err := loadSignupOffer(r, suStore, jlRepo, dRepo, wRepo)
+		if err != nil {
+			return err
+		}
+
+		if offer.Approval {
+			return domains.ErrApprovalRequired
+		}
+
+		number, err := phone.Normalize(dto.PhoneNumber, offer.workspace.Region)
+		if err != nil {
+			return problems.Validation(ozzo.Errors{"phone_number": errPhone})
+		}
+
+		var claim users.Claim
+		var action string
+		var meta map[string]interface{}
+		if offer.link != nil {
+			claim = jlRepo.Claim(r.Context(), offer.link.Token, offer.EmailAddress)
+			action, meta = audit.ActionJoinLinkUsed, map[string]interface{}{"id": offer.link.ID}
+		} else {
+			claim = dRepo.Claim(r.Context(), offer.EmailAddress)
+			action, meta = audit.ActionDomainJoined, map[string]interface{}{"domain": offer.domain.Name}
+		}
+
+		user, err := uRepo.JoinRegistered(r.Context(), offer.EmailAddress, users.Registration{
+			FirstName:   dto.FirstName,
+			LastName:    dto.LastName,
+			PhoneNumber: number,
+			Password:    dto.Password,
+		}, claim)
+		if err != nil {
+			return err
+		}
+
+		if err := suStore.End(r.Context(), user.EmailAddress); err != nil {
+			return err
+		}
+
+		meta["role"] = user.Role
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: user.Workspace,
+			Actor:     user.ID,
+			Action:    action,
+			Target:    user.EmailAddress,
+			Metadata:  meta,
+		})
+		bus.Publish(r.Context(), events.MemberJoined, user.Workspace, memberJoined(user))
+
+		session, err := sStore.Create(r.Context(), user)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, session)
+		return nil
+	})
+}
+
+// requestToJoin asks the admins of the workspace that verified a confirmed signup's
+// domain to let them in.
+func requestToJoin(suStore *signups.Store, jlRepo *joinlinks.Repo, dRepo *domains.Repo, jrRepo *joinrequests.Repo, mlRepo *maillog.Repo, uRepo *users.Repo, wRepo *workspaces.Repo, aRepo *audit.Repo, bus *events.Bus, env *config.Env, mailer notification.Mailer) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var dto JoinRequestDTO
+		anansi.ReadJSON(r, &dto)
+
+		offer, err := loadSignupOffer(r, suStore, jlRepo, dRepo, wRepo)
+		if err != nil {
+			return err
+		}
+
+		if !offer.Approval {
+			return errJoinRequestUnneeded
+		}
+
+		// they own the address, so they can know it has an account
+		existing, err := uRepo.GetByEmail(r.Context(), offer.EmailAddress)
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			return users.ErrEmail(offer.EmailAddress)
+		}
+
+		workspace := offer.workspace
+		if err := checkJoiner(r, mlRepo, workspace, offer.EmailAddress); err != nil {
+			return err
+		}
+
+		req, err := jrRepo.Create(r.Context(), &joinrequests.Request{
+			Workspace:    workspace.ID,
+			EmailAddress: offer.EmailAddress,
+			FirstName:    dto.FirstName,
+			LastName:     dto.LastName,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := suStore.End(r.Context(), req.EmailAddress); err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: workspace.ID,
+			Action:    audit.ActionJoinRequested,
+			Target:    req.EmailAddress,
+			Metadata:  map[string]interface{}{"id": req.ID},
+		})
+		bus.Publish(r.Context(), events.JoinRequested, workspace.ID, req)
+
+		admins, err := uRepo.Admins(r.Context(), workspace.ID)
+		if err != nil {
+			return err
+		}
+
+		// the request is saved, so an admin we can't mail can still find it in the app
+		log := zerolog.Ctx(r.Context())
+		brand := workspace.Branding(env.PublicURL)
+		for _, admin := range admins {
+			if err := joinrequests.SendRequest(r.Context(), mailer, env.ClientRequestPage, req, workspace.CompanyName, admin, brand, workspace.Locale); err != nil {
+				log.Err(err).Uint("admin", admin.ID).Msg("failed to mail join request")
+			}
+		}
+
+		anansi.SendSuccess(r, w, req)
+		return nil
+	})
+}
+
+// loadSignupOffer finds the workspace the signup in the URL can join: the one of the
+// join link it started from, or the one that verified its domain.
+func loadSignupOffer(r *http.Request, suStore *signups.Store, jlRepo *joinlinks.Repo, dRepo *domains.Repo, wRepo *workspaces.Repo) (*signupOffer, error) {
+	su, err := suStore.View(r.Context(), anansi.StringParam(r, "token"))
+	if err != nil {
+		return nil, err
+	}
+
+	offer := &signupOffer{EmailAddress: su.EmailAddress}
+	wkID := uint(0)
+
+	if su.Link != "" {
+		if offer.link, err = jlRepo.Find(r.Context(), su.Link); err != nil {
+			return nil, err
+		}
+
+		if offer.link == nil || !offer.link.Available() {
+			return nil, errJoinLinkUnavailable
+		}
+
+		wkID, offer.Role = offer.link.Workspace, offer.link.Role
+	} else {
+		if offer.domain, err = dRepo.Verified(r.Context(), su.EmailAddress); err != nil {
+			return nil, err
+		}
+
+		if offer.domain == nil {
+			return nil, domains.ErrNoWorkspace
+		}
+
+		wkID, offer.Role = offer.domain.Workspace, offer.domain.Role
+		offer.Approval = offer.domain.JoinMode != domains.JoinAuto
+	}
+
+	if offer.workspace, err = wRepo.Get(r.Context(), wkID); err != nil {
+		return nil, err
+	}
+
+	if offer.workspace == nil {
+		return nil, errSignupExpired
+	}
+
+	offer.CompanyName = offer.workspace.CompanyName
+	return offer, nil
+}
+
+// startSignup emails email a signup for workspace. Addresses that already have an
+// account get nothing, so callers can answer the same either way.
+func startSignup(r *http.Request, suStore *signups.Store, uRepo *users.Repo, env *config.Env, mailer notification.Mailer, email, link string, workspace *workspaces.Workspace) error {
+	existing, err := uRepo.GetByEmail(r.Context(), email)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		zerolog.Ctx(r.Context()).Info().Msg("skipped signup for a registered address")
+		return nil
+	}
+
+	token, err := suStore.Start(r.Context(), email, link)
+	if err != nil || token == "" {
+		return err
+	}
+
+	// people signing up haven't picked a language yet
+	return signups.SendSignup(r.Context(), mailer, env.ClientSignupPage, token, email, workspace.CompanyName, workspace.ID, workspace.Branding(env.PublicURL), workspace.Locale)
+}
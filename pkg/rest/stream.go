@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/rs/zerolog"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/stream"
+)
+
+const heartbeatInterval = time.Second * 15
+
+// streamID is the shape of a Redis stream ID, <ms>-<seq>.
+var streamID = regexp.MustCompile(`^[0-9]+-[0-9]+$`)
+
+func Stream(r *chi.Mux, app *config.App, sStore *sessions.Store, broker *stream.Broker) {
+	r.Get("/events/stream", streamEvents(app.Auth, sStore, broker))
+}
+
+func streamEvents(auth *anansi.SessionStore, sStore *sessions.Store, broker *stream.Broker) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			return errStreamingUnsupported
+		}
+
+		ctx := r.Context()
+		messages, err := broker.Subscribe(ctx, session.Workspace, session.User, lastEventID(r))
+		if err != nil {
+			return err
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		// the request timeout closes streams periodically, so ask clients to reconnect quickly
+		fmt.Fprint(w, "retry: 3000\n\n")
+		flusher.Flush()
+
+		log := zerolog.Ctx(ctx)
+		key := sessionKey(r)
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case msg, ok := <-messages:
+				if !ok {
+					return nil
+				}
+
+				// pushes to the user have no ID, so they don't move Last-Event-ID
+				if msg.ID != "" {
+					fmt.Fprintf(w, "id: %s\n", msg.ID)
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.Type, msg.Event)
+				flusher.Flush()
+			case <-heartbeat.C:
+				if key != "" {
+					active, err := sStore.Active(ctx, key)
+					if err != nil {
+						log.Err(err).Msg("failed to check session for event stream")
+					} else if !active {
+						fmt.Fprint(w, "event: session_revoked\ndata: {}\n\n")
+						flusher.Flush()
+						return nil
+					}
+				}
+
+				fmt.Fprint(w, ": heartbeat\n\n")
+				flusher.Flush()
+			}
+		}
+	})
+}
+
+// lastEventID returns the ID of the last event a reconnecting client saw, ignoring IDs
+// we couldn't have sent so they get live events instead of an error.
+func lastEventID(r *http.Request) string {
+	id := r.Header.Get("Last-Event-ID")
+	if !streamID.MatchString(id) {
+		return ""
+	}
+
+	return id
+}
@@ -0,0 +1,28 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLastEventID(t *testing.T) {
+	cases := map[string]string{
+		"":                "",
+		"1700000000000-0": "1700000000000-0",
+		"1700000000000-3": "1700000000000-3",
+		"1700000000000":   "",
+		"-":               "",
+		"abc-1":           "",
+		"1-2-3":           "",
+		"+":               "",
+	}
+
+	for header, expected := range cases {
+		r := httptest.NewRequest("GET", "/events/stream", nil)
+		r.Header.Set("Last-Event-ID", header)
+
+		if id := lastEventID(r); id != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, header, id)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/rs/zerolog"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/storage"
+	"tsaron.com/godview-starter/pkg/users"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+const (
+	maxUploadSize = 5 << 20
+	maxImageSide  = 512
+	thumbnailSide = 128
+	signedURLTTL  = time.Minute * 15
+)
+
+type signedImage struct {
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func Uploads(r *chi.Mux, app *config.App, blob storage.Blob) {
+	uRepo := users.NewRepo(app.DB)
+	wRepo := workspaces.NewRepo(app.DB)
+
+	r.Put("/me/avatar", putAvatar(app.Auth, uRepo, blob))
+	r.Delete("/me/avatar", deleteAvatar(app.Auth, uRepo, blob))
+	r.Get("/users/{id}/avatar", getAvatar(app.Auth, uRepo, blob))
+
+	r.Put("/workspace/logo", putLogo(app.Auth, wRepo, blob))
+	r.Delete("/workspace/logo", deleteLogo(app.Auth, wRepo, blob))
+	r.Get("/workspace/logo", getLogo(app.Auth, wRepo, blob))
+	r.Get("/workspaces/{id}/logo", redirectLogo(wRepo, blob))
+
+	r.Get("/files/*", serveFile(blob))
+}
+
+func putAvatar(auth *anansi.SessionStore, uRepo *users.Repo, blob storage.Blob) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		img, err := readImage(w, r, true)
+		if err != nil {
+			return err
+		}
+
+		user, err := loadProfile(r, uRepo, session)
+		if err != nil {
+			return err
+		}
+
+		key := fmt.Sprintf("avatars/%d/%d/%s%s", session.Workspace, session.User, anansi.UUID(), img.Extension)
+		if err := storeImage(r.Context(), blob, key, img); err != nil {
+			return err
+		}
+
+		if _, err := uRepo.SetAvatar(r.Context(), session.Workspace, session.User, key); err != nil {
+			return err
+		}
+		removeImage(r.Context(), blob, user.Avatar)
+
+		signed, err := signImage(r.Context(), blob, key)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, signed)
+		return nil
+	})
+}
+
+func deleteAvatar(auth *anansi.SessionStore, uRepo *users.Repo, blob storage.Blob) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		user, err := loadProfile(r, uRepo, session)
+		if err != nil {
+			return err
+		}
+
+		previous := user.Avatar
+		if user, err = uRepo.SetAvatar(r.Context(), session.Workspace, session.User, ""); err != nil {
+			return err
+		}
+		removeImage(r.Context(), blob, previous)
+
+		anansi.SendSuccess(r, w, user)
+		return nil
+	})
+}
+
+func getAvatar(auth *anansi.SessionStore, uRepo *users.Repo, blob storage.Blob) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		user, err := uRepo.Get(r.Context(), session.Workspace, anansi.IDParam(r, "id"))
+		if err != nil {
+			return err
+		}
+
+		if user == nil {
+			return errUserNotFound
+		}
+
+		if user.Avatar == "" {
+			return errNoImage
+		}
+
+		signed, err := signImage(r.Context(), blob, user.Avatar)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, signed)
+		return nil
+	})
+}
+
+func putLogo(auth *anansi.SessionStore, wRepo *workspaces.Repo, blob storage.Blob) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "change_logo"); err != nil {
+			return err
+		}
+
+		img, err := readImage(w, r, false)
+		if err != nil {
+			return err
+		}
+
+		workspace, err := wRepo.Get(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		key := fmt.Sprintf("logos/%d/%s%s", session.Workspace, anansi.UUID(), img.Extension)
+		if err := storeImage(r.Context(), blob, key, img); err != nil {
+			return err
+		}
+
+		if _, err := wRepo.SetLogo(r.Context(), session.Workspace, key); err != nil {
+			return err
+		}
+		removeImage(r.Context(), blob, workspace.Logo)
+
+		signed, err := signImage(r.Context(), blob, key)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, signed)
+		return nil
+	})
+}
+
+func deleteLogo(auth *anansi.SessionStore, wRepo *workspaces.Repo, blob storage.Blob) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "change_logo"); err != nil {
+			return err
+		}
+
+		workspace, err := wRepo.Get(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		previous := workspace.Logo
+		if workspace, err = wRepo.SetLogo(r.Context(), session.Workspace, ""); err != nil {
+			return err
+		}
+		removeImage(r.Context(), blob, previous)
+
+		anansi.SendSuccess(r, w, workspace)
+		return nil
+	})
+}
+
+func getLogo(auth *anansi.SessionStore, wRepo *workspaces.Repo, blob storage.Blob) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		workspace, err := wRepo.Get(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		if workspace == nil || workspace.Logo == "" {
+			return errNoImage
+		}
+
+		signed, err := signImage(r.Context(), blob, workspace.Logo)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, signed)
+		return nil
+	})
+}
+
+// redirectLogo sends anyone to a fresh link to a workspace's logo thumbnail. Emails
+// link here as signed links would expire in people's inboxes.
+func redirectLogo(wRepo *workspaces.Repo, blob storage.Blob) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		workspace, err := wRepo.Get(r.Context(), anansi.IDParam(r, "id"))
+		if err != nil {
+			return err
+		}
+
+		if workspace == nil || workspace.Logo == "" {
+			return errNoImage
+		}
+
+		url, err := blob.SignedURL(r.Context(), storage.ThumbnailKey(workspace.Logo), signedURLTTL)
+		if err != nil {
+			return err
+		}
+
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(signedURLTTL.Seconds()/2)))
+		http.Redirect(w, r, url, http.StatusFound)
+		return nil
+	})
+}
+
+// serveFile serves files from local storage through the URLs it signs. Other backends
+// sign URLs of their own.
+func serveFile(blob storage.Blob) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		local, ok := blob.(*storage.Local)
+		if !ok {
+			return problems.NotFound
+		}
+
+		key := chi.URLParam(r, "*")
+		q := r.URL.Query()
+		if err := local.Verify(key, q.Get("expires"), q.Get("signature")); err != nil {
+			return err
+		}
+
+		f, info, err := local.Get(r.Context(), key)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		w.Header().Set("Content-Type", info.ContentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(signedURLTTL.Seconds())))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+
+		// the status has been sent at this point, so all we can do is log
+		if _, err := io.Copy(w, f); err != nil {
+			zerolog.Ctx(r.Context()).Err(err).Str("key", key).Msg("failed to serve file")
+		}
+
+		return nil
+	})
+}
+
+// readImage reads the image uploaded in the "file" field of a multipart form, sized
+// for storage. Avatars get square thumbnails.
+func readImage(w http.ResponseWriter, r *http.Request, square bool) (storage.Image, error) {
+	// leave room for the rest of the multipart body
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize+64<<10)
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			return storage.Image{}, errFileTooLarge
+		case errors.Is(err, http.ErrMissingFile), errors.Is(err, http.ErrNotMultipart):
+			return storage.Image{}, errFileMissing
+		default:
+			return storage.Image{}, problems.Wrap(problems.MalformedBody, err)
+		}
+	}
+	defer file.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(file, maxUploadSize+1))
+	if err != nil {
+		return storage.Image{}, err
+	}
+
+	if len(raw) > maxUploadSize {
+		return storage.Image{}, errFileTooLarge
+	}
+
+	return storage.PrepareImage(raw, maxImageSide, thumbnailSide, square)
+}
+
+func storeImage(ctx context.Context, blob storage.Blob, key string, img storage.Image) error {
+	if err := blob.Put(ctx, key, bytes.NewReader(img.Original), int64(len(img.Original)), img.ContentType); err != nil {
+		return err
+	}
+
+	thumb := storage.ThumbnailKey(key)
+	return blob.Put(ctx, thumb, bytes.NewReader(img.Thumbnail), int64(len(img.Thumbnail)), img.ContentType)
+}
+
+// removeImage deletes a replaced image and its thumbnail. It's too late to fail the
+// request by then, so errors are only logged.
+func removeImage(ctx context.Context, blob storage.Blob, key string) {
+	if key == "" {
+		return
+	}
+
+	for _, k := range []string{key, storage.ThumbnailKey(key)} {
+		if err := blob.Delete(ctx, k); err != nil {
+			zerolog.Ctx(ctx).Err(err).Str("key", k).Msg("failed to delete replaced image")
+		}
+	}
+}
+
+func signImage(ctx context.Context, blob storage.Blob, key string) (signedImage, error) {
+	url, err := blob.SignedURL(ctx, key, signedURLTTL)
+	if err != nil {
+		return signedImage{}, err
+	}
+
+	thumb, err := blob.SignedURL(ctx, storage.ThumbnailKey(key), signedURLTTL)
+	if err != nil {
+		return signedImage{}, err
+	}
+
+	return signedImage{url, thumb, time.Now().Add(signedURLTTL)}, nil
+}
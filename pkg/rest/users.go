@@ -0,0 +1,88 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/audit"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/events"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/users"
+)
+
+type RoleDTO struct {
+	Role string `json:"role" mod:"smalltext"`
+}
+
+func (t *RoleDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.Role, ozzo.Required, ozzo.In(users.RoleMember, users.RoleAdmin)),
+	)
+}
+
+func Users(r *chi.Mux, app *config.App) {
+	uRepo := users.NewRepo(app.DB)
+	aRepo := audit.NewRepo(app.DB)
+
+	r.Route("/users", func(r chi.Router) {
+		r.Patch("/{id}/role", changeRole(app.Auth, uRepo, aRepo, app.Events))
+	})
+}
+
+func changeRole(auth *anansi.SessionStore, uRepo *users.Repo, aRepo *audit.Repo, bus *events.Bus) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "change_roles"); err != nil {
+			return err
+		}
+
+		var dto RoleDTO
+		anansi.ReadJSON(r, &dto)
+
+		id := anansi.IDParam(r, "id")
+		if id == session.User {
+			return errOwnRole
+		}
+
+		user, err := uRepo.Get(r.Context(), session.Workspace, id)
+		if err != nil {
+			return err
+		}
+
+		if user == nil {
+			return errUserNotFound
+		}
+
+		if user.Role == users.RoleOwner {
+			return errOwnerRole
+		}
+
+		previous := user.Role
+		if user, err = uRepo.ChangeRole(r.Context(), session.Workspace, id, dto.Role); err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionUserRoleChanged,
+			Target:    user.EmailAddress,
+			Metadata:  map[string]interface{}{"from": previous, "to": user.Role},
+		})
+		bus.Publish(r.Context(), events.MemberRoleChanged, session.Workspace, map[string]interface{}{
+			"user":          user.ID,
+			"email_address": user.EmailAddress,
+			"from":          previous,
+			"to":            user.Role,
+			"changed_by":    session.User,
+		})
+
+		anansi.SendSuccess(r, w, user)
+		return nil
+	})
+}
@@ -0,0 +1,288 @@
+package rest
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/go-chi/chi"
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/events"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/webhooks"
+)
+
+var httpURL = regexp.MustCompile("^https?://")
+
+var (
+	errURLPrivate    = ozzo.NewError("validation_url_private", "must be a public address, not one on a private network")
+	errURLUnresolved = ozzo.NewError("validation_url_unresolved", "must have a host we can look up")
+)
+
+var eventTypes = func() []interface{} {
+	var types []interface{}
+	for _, t := range events.Types {
+		types = append(types, t)
+	}
+	return types
+}()
+
+type WebhookDTO struct {
+	URL    string   `json:"url" mod:"trim"`
+	Events []string `json:"events"`
+}
+
+func (t *WebhookDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.URL, ozzo.Required, is.URL, ozzo.Match(httpURL)),
+		ozzo.Field(&t.Events, ozzo.Required, ozzo.Each(ozzo.In(eventTypes...))),
+	)
+}
+
+type createdWebhook struct {
+	*webhooks.Endpoint
+	Secret string `json:"secret"`
+}
+
+type WebhookUpdateDTO struct {
+	URL     string   `json:"url" mod:"trim"`
+	Events  []string `json:"events"`
+	Enabled *bool    `json:"enabled"`
+}
+
+func (t *WebhookUpdateDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.URL, is.URL, ozzo.Match(httpURL)),
+		ozzo.Field(&t.Events, ozzo.Each(ozzo.In(eventTypes...))),
+	)
+}
+
+func Webhooks(r *chi.Mux, app *config.App, resolver webhooks.Resolver) {
+	whRepo := webhooks.NewRepo(app.DB)
+
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Post("/", createWebhook(app.Auth, whRepo, resolver))
+		r.Get("/", listWebhooks(app.Auth, whRepo))
+		r.Patch("/{id}", updateWebhook(app.Auth, whRepo, resolver))
+		r.Delete("/{id}", deleteWebhook(app.Auth, whRepo))
+		r.Get("/{id}/deliveries", listDeliveries(app.Auth, whRepo))
+		r.Post("/{id}/deliveries/{delivery}/redeliver", redeliver(app.Auth, whRepo))
+	})
+}
+
+func createWebhook(auth *anansi.SessionStore, whRepo *webhooks.Repo, resolver webhooks.Resolver) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "manage_webhooks"); err != nil {
+			return err
+		}
+
+		var dto WebhookDTO
+		anansi.ReadJSON(r, &dto)
+
+		if err := checkWebhookURL(r, resolver, dto.URL); err != nil {
+			return err
+		}
+
+		secret, err := anansi.RandomString(48)
+		if err != nil {
+			return err
+		}
+
+		ep, err := whRepo.CreateEndpoint(r.Context(), &webhooks.Endpoint{
+			Workspace: session.Workspace,
+			URL:       dto.URL,
+			Secret:    "whsec_" + secret,
+			Events:    dto.Events,
+		})
+		if err != nil {
+			return err
+		}
+
+		// this is the only time the secret is shown
+		anansi.SendSuccess(r, w, createdWebhook{ep, ep.Secret})
+		return nil
+	})
+}
+
+func listWebhooks(auth *anansi.SessionStore, whRepo *webhooks.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "manage_webhooks"); err != nil {
+			return err
+		}
+
+		endpoints, err := whRepo.ListEndpoints(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, endpoints)
+		return nil
+	})
+}
+
+func updateWebhook(auth *anansi.SessionStore, whRepo *webhooks.Repo, resolver webhooks.Resolver) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "manage_webhooks"); err != nil {
+			return err
+		}
+
+		var dto WebhookUpdateDTO
+		anansi.ReadJSON(r, &dto)
+
+		ep, err := loadWebhook(r, whRepo, session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		if dto.URL != "" {
+			if err := checkWebhookURL(r, resolver, dto.URL); err != nil {
+				return err
+			}
+			ep.URL = dto.URL
+		}
+
+		if len(dto.Events) != 0 {
+			ep.Events = dto.Events
+		}
+
+		if dto.Enabled != nil {
+			if *dto.Enabled {
+				ep.DisabledAt = nil
+			} else if ep.DisabledAt == nil {
+				now := time.Now()
+				ep.DisabledAt = &now
+			}
+		}
+
+		if ep, err = whRepo.UpdateEndpoint(r.Context(), ep); err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, ep)
+		return nil
+	})
+}
+
+func deleteWebhook(auth *anansi.SessionStore, whRepo *webhooks.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "manage_webhooks"); err != nil {
+			return err
+		}
+
+		ep, err := loadWebhook(r, whRepo, session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		if err := whRepo.DeleteEndpoint(r.Context(), session.Workspace, ep.ID); err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, ep)
+		return nil
+	})
+}
+
+func listDeliveries(auth *anansi.SessionStore, whRepo *webhooks.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "manage_webhooks"); err != nil {
+			return err
+		}
+
+		var query pageQuery
+		anansi.ReadQuery(r, &query)
+		offset, limit, err := pageBounds(query.Page, query.PerPage)
+		if err != nil {
+			return err
+		}
+
+		ep, err := loadWebhook(r, whRepo, session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		deliveries, err := whRepo.ListDeliveries(r.Context(), ep.ID, offset, limit)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, deliveries)
+		return nil
+	})
+}
+
+func redeliver(auth *anansi.SessionStore, whRepo *webhooks.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "manage_webhooks"); err != nil {
+			return err
+		}
+
+		ep, err := loadWebhook(r, whRepo, session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		if ep.DisabledAt != nil {
+			return errWebhookDisabled
+		}
+
+		d, err := whRepo.Redeliver(r.Context(), ep.ID, anansi.IDParam(r, "delivery"))
+		if err != nil {
+			return err
+		}
+
+		if d == nil {
+			return errDeliveryNotFound
+		}
+
+		anansi.SendSuccess(r, w, d)
+		return nil
+	})
+}
+
+func loadWebhook(r *http.Request, whRepo *webhooks.Repo, workspace uint) (*webhooks.Endpoint, error) {
+	ep, err := whRepo.GetEndpoint(r.Context(), workspace, anansi.IDParam(r, "id"))
+	if err != nil {
+		return nil, err
+	}
+
+	if ep == nil {
+		return nil, errWebhookNotFound
+	}
+
+	return ep, nil
+}
+
+// checkWebhookURL turns away endpoints on private networks while the admin is there
+// to see why. The worker checks again when it connects.
+func checkWebhookURL(r *http.Request, resolver webhooks.Resolver, url string) error {
+	err := webhooks.CheckURL(r.Context(), resolver, url)
+
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(err, webhooks.ErrPrivateAddress):
+		return problems.Validation(ozzo.Errors{"url": errURLPrivate})
+	case errors.As(err, &dnsErr):
+		return problems.Validation(ozzo.Errors{"url": errURLUnresolved})
+	}
+
+	return err
+}
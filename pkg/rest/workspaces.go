@@ -0,0 +1,252 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+	ozzo "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/audit"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/i18n"
+	"tsaron.com/godview-starter/pkg/notification"
+	"tsaron.com/godview-starter/pkg/problems"
+	"tsaron.com/godview-starter/pkg/sessions"
+	"tsaron.com/godview-starter/pkg/workspaces"
+)
+
+type WorkspaceNameDTO struct {
+	CompanyName string `json:"company_name" mod:"trim"`
+}
+
+func (t *WorkspaceNameDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.CompanyName, ozzo.Required, ozzo.Length(1, 100)),
+	)
+}
+
+type WorkspaceRegionDTO struct {
+	Region string `json:"region" mod:"trim,ucase"`
+}
+
+func (t *WorkspaceRegionDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.Region, ozzo.Required, regionValidator),
+	)
+}
+
+type WorkspaceLocaleDTO struct {
+	Locale string `json:"locale" mod:"smalltext"`
+}
+
+func (t *WorkspaceLocaleDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.Locale, ozzo.Required, ozzo.In(supportedLocales()...)),
+	)
+}
+
+type InvitationPolicyDTO struct {
+	TTLHours       int      `json:"ttl_hours"`
+	ExtensionHours int      `json:"extension_hours"`
+	MaxExtensions  int      `json:"max_extensions"`
+	Roles          []string `json:"roles"`
+	Domains        []string `json:"domains"`
+}
+
+func (t *InvitationPolicyDTO) Validate() error {
+	return ozzo.ValidateStruct(t,
+		ozzo.Field(&t.TTLHours, ozzo.Required, ozzo.Min(1), ozzo.Max(24*30)),
+		ozzo.Field(&t.ExtensionHours, ozzo.Required, ozzo.Min(1), ozzo.Max(24*7)),
+		ozzo.Field(&t.MaxExtensions, ozzo.Min(0), ozzo.Max(10)),
+		ozzo.Field(&t.Roles, ozzo.Required, ozzo.Each(ozzo.In("member", "admin"))),
+		ozzo.Field(&t.Domains, ozzo.Each(is.Domain)),
+	)
+}
+
+func (t *InvitationPolicyDTO) policy() workspaces.InvitationPolicy {
+	domains := []string{}
+	for _, d := range t.Domains {
+		domains = append(domains, strings.ToLower(strings.TrimSpace(d)))
+	}
+
+	return workspaces.InvitationPolicy{
+		TTLHours:       t.TTLHours,
+		ExtensionHours: t.ExtensionHours,
+		MaxExtensions:  t.MaxExtensions,
+		Roles:          t.Roles,
+		Domains:        domains,
+	}
+}
+
+func supportedLocales() []interface{} {
+	var locales []interface{}
+	for _, l := range i18n.Locales() {
+		locales = append(locales, l)
+	}
+	return locales
+}
+
+func Workspaces(r *chi.Mux, app *config.App, templates *notification.Templates) {
+	wRepo := workspaces.NewRepo(app.DB)
+	aRepo := audit.NewRepo(app.DB)
+
+	r.Route("/workspace", func(r chi.Router) {
+		r.Get("/", getWorkspace(app.Auth, wRepo))
+		r.Patch("/name", renameWorkspace(app.Auth, wRepo, aRepo))
+		r.Patch("/region", changeRegion(app.Auth, wRepo, aRepo))
+		r.Patch("/locale", changeLocale(app.Auth, wRepo, aRepo))
+		r.Put("/branding", changeBranding(app.Auth, wRepo, aRepo))
+		r.Put("/invitation-policy", changeInvitationPolicy(app.Auth, wRepo, aRepo))
+		r.Get("/branding/preview/{template}", previewTemplate(app.Auth, wRepo, app.Env, templates))
+	})
+}
+
+func getWorkspace(auth *anansi.SessionStore, wRepo *workspaces.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+
+		workspace, err := wRepo.Get(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		anansi.SendSuccess(r, w, workspace)
+		return nil
+	})
+}
+
+func renameWorkspace(auth *anansi.SessionStore, wRepo *workspaces.Repo, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "rename_workspace"); err != nil {
+			return err
+		}
+
+		var dto WorkspaceNameDTO
+		anansi.ReadJSON(r, &dto)
+
+		workspace, err := wRepo.Get(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		previous := workspace.CompanyName
+		if workspace, err = wRepo.ChangeName(r.Context(), session.Workspace, dto.CompanyName); err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionWorkspaceRenamed,
+			Metadata:  map[string]interface{}{"from": previous, "to": workspace.CompanyName},
+		})
+
+		anansi.SendSuccess(r, w, workspace)
+		return nil
+	})
+}
+
+func changeRegion(auth *anansi.SessionStore, wRepo *workspaces.Repo, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "change_region"); err != nil {
+			return err
+		}
+
+		var dto WorkspaceRegionDTO
+		anansi.ReadJSON(r, &dto)
+
+		workspace, err := wRepo.Get(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		previous := workspace.Region
+		if workspace, err = wRepo.ChangeRegion(r.Context(), session.Workspace, dto.Region); err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionWorkspaceRegion,
+			Metadata:  map[string]interface{}{"from": previous, "to": workspace.Region},
+		})
+
+		anansi.SendSuccess(r, w, workspace)
+		return nil
+	})
+}
+
+func changeLocale(auth *anansi.SessionStore, wRepo *workspaces.Repo, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireAdmin(session, "change_locale"); err != nil {
+			return err
+		}
+
+		var dto WorkspaceLocaleDTO
+		anansi.ReadJSON(r, &dto)
+
+		workspace, err := wRepo.Get(r.Context(), session.Workspace)
+		if err != nil {
+			return err
+		}
+
+		previous := workspace.Locale
+		if workspace, err = wRepo.ChangeLocale(r.Context(), session.Workspace, dto.Locale); err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionWorkspaceLocale,
+			Metadata:  map[string]interface{}{"from": previous, "to": workspace.Locale},
+		})
+
+		anansi.SendSuccess(r, w, workspace)
+		return nil
+	})
+}
+
+func changeInvitationPolicy(auth *anansi.SessionStore, wRepo *workspaces.Repo, aRepo *audit.Repo) http.HandlerFunc {
+	return problems.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		var session sessions.Session
+		auth.Load(r, &session)
+		if err := requireOwner(session); err != nil {
+			return err
+		}
+
+		var dto InvitationPolicyDTO
+		anansi.ReadJSON(r, &dto)
+
+		workspace, err := wRepo.ChangeInvitationPolicy(r.Context(), session.Workspace, dto.policy())
+		if err != nil {
+			return err
+		}
+
+		recordEvent(r, aRepo, audit.Event{
+			Workspace: session.Workspace,
+			Actor:     session.User,
+			Action:    audit.ActionWorkspaceInvitePolicy,
+			Metadata: map[string]interface{}{
+				"ttl_hours":       workspace.InvitationPolicy.TTLHours,
+				"extension_hours": workspace.InvitationPolicy.ExtensionHours,
+				"max_extensions":  workspace.InvitationPolicy.MaxExtensions,
+				"roles":           workspace.InvitationPolicy.Roles,
+				"domains":         workspace.InvitationPolicy.Domains,
+			},
+		})
+
+		anansi.SendSuccess(r, w, workspace)
+		return nil
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/tsaron/anansi/tokens"
+	"tsaron.com/godview-starter/pkg/i18n"
 	"tsaron.com/godview-starter/pkg/users"
 	"tsaron.com/godview-starter/pkg/workspaces"
 )
@@ -17,6 +18,7 @@ type Session struct {
 	CompanyName string `json:"company_name"`
 	SessionKey  string `json:"session_key"`
 	FullName    string `json:"full_name"`
+	Locale      string `json:"locale"` // clients send it back as Accept-Language
 }
 
 type Store struct {
@@ -29,24 +31,80 @@ func NewStore(tStore *tokens.Store, wRepo *workspaces.Repo) *Store {
 }
 
 func (s *Store) Create(ctx context.Context, u *users.User) (Session, error) {
+	session, err := s.build(ctx, u)
+	if err != nil {
+		return Session{}, err
+	}
+
+	token, err := s.tStore.Commission(ctx, time.Hour*24, u.EmailAddress, session)
+	if err != nil {
+		return Session{}, err
+	}
+
+	session.SessionKey = token
+	return session, nil
+}
+
+// Refresh replaces the user details cached in the session of u, if they have one,
+// without extending it.
+func (s *Store) Refresh(ctx context.Context, u *users.User) error {
+	session, err := s.build(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	err = s.tStore.Reset(ctx, u.EmailAddress, session)
+	if err == tokens.ErrTokenNotFound {
+		return nil
+	}
+
+	return err
+}
+
+func (s *Store) build(ctx context.Context, u *users.User) (Session, error) {
 	workspace, err := s.wRepo.Get(ctx, u.Workspace)
 	if err != nil {
 		return Session{}, err
 	}
 
-	session := Session{
+	return Session{
 		Workspace:   u.Workspace,
 		User:        u.ID,
 		Role:        u.Role,
 		CompanyName: workspace.CompanyName,
 		FullName:    fmt.Sprintf("%s %s", u.FirstName, u.LastName),
+		Locale:      i18n.Resolve(u.Locale, workspace.Locale),
+	}, nil
+}
+
+// Revoke ends the session of the user with the given email address.
+func (s *Store) Revoke(ctx context.Context, email string) error {
+	err := s.tStore.Revoke(ctx, email)
+	if err == tokens.ErrTokenNotFound {
+		return nil
 	}
 
-	token, err := s.tStore.Commission(ctx, time.Hour*24, u.EmailAddress, session)
-	if err != nil {
-		return Session{}, err
+	return err
+}
+
+// Active checks whether the session behind a session key is still valid.
+func (s *Store) Active(ctx context.Context, key string) (bool, error) {
+	var session Session
+	err := s.tStore.Peek(ctx, key, &session)
+	if err == tokens.ErrTokenNotFound {
+		return false, nil
 	}
 
-	session.SessionKey = token
-	return session, nil
+	return err == nil, err
+}
+
+// End ends the session behind a session key.
+func (s *Store) End(ctx context.Context, key string) error {
+	var session Session
+	err := s.tStore.Decommission(ctx, key, &session)
+	if err == tokens.ErrTokenNotFound {
+		return nil
+	}
+
+	return err
 }
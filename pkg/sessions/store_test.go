@@ -2,8 +2,10 @@ package sessions
 
 import (
 	"context"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/go-pg/pg/v10"
 	"github.com/go-redis/redis/v8"
@@ -96,3 +98,79 @@ func TestStoreCreate(t *testing.T) {
 		t.Errorf("Expected loaded session to be the user %d, got user %d", session.User, loaded.User)
 	}
 }
+
+func TestStoreRefresh(t *testing.T) {
+	defer afterEach(t)
+
+	ctx := context.TODO()
+	wkRepo := workspaces.NewRepo(testDB)
+	sessions := NewStore(store, wkRepo)
+
+	wk, err := wkRepo.Create(ctx, faker.Company().Name(), faker.Internet().Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &users.User{
+		ID:           1,
+		FirstName:    faker.Name().FirstName(),
+		LastName:     faker.Name().LastName(),
+		Role:         users.RoleMember,
+		EmailAddress: faker.Internet().Email(),
+		Workspace:    wk.ID,
+	}
+
+	session, err := sessions.Create(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user.FirstName = faker.Name().FirstName()
+	if err := sessions.Refresh(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := new(Session)
+	if err := store.Peek(ctx, session.SessionKey, loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := user.FirstName + " " + user.LastName
+	if loaded.FullName != expected {
+		t.Errorf("Expected the session's name to be %s, got %s", expected, loaded.FullName)
+	}
+}
+
+func TestEmailChangeTokens(t *testing.T) {
+	defer afterEach(t)
+
+	ctx := context.TODO()
+	auth := anansi.NewSessionStore([]byte("secret"), "Headless", time.Hour, store)
+
+	user := &users.User{ID: 7, Workspace: 3, EmailAddress: faker.Internet().Email()}
+	_, confirm, cancel, err := users.NewEmailChange(ctx, store, user, faker.Internet().Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{"confirm": confirm, "cancel": cancel} {
+		r := httptest.NewRequest("GET", "/me", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+
+		var loaded Session
+		auth.Load(r, &loaded)
+
+		if loaded.User != 0 || loaded.Workspace != 0 {
+			t.Errorf("Expected the %s token not to sign in as the user, got %v", name, loaded)
+		}
+	}
+
+	change, err := users.ConfirmEmailChange(ctx, store, confirm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if change.User != user.ID || change.Workspace != user.Workspace {
+		t.Errorf("Expected the change of user %d, got %v", user.ID, change)
+	}
+}
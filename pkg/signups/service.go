@@ -0,0 +1,44 @@
+package signups
+
+import (
+	"context"
+
+	"tsaron.com/godview-starter/pkg/notification"
+)
+
+// SignupMail is the data of the signup template, which asks someone to confirm their
+// address before they join a workspace.
+type SignupMail struct {
+	Route string
+	Token string
+	// CompanyName is the workspace they're joining, when they started from its join link
+	CompanyName string
+}
+
+func init() {
+	notification.Declare("signup", SignupMail{
+		Route:       "https://app.example.com/onboarding/signups",
+		Token:       "sample",
+		CompanyName: "Acme",
+	})
+}
+
+// SendSignup emails the link that confirms a signup. Workspace is the workspace the
+// signup is for, if it's known yet.
+func SendSignup(ctx context.Context, mailer notification.Mailer, route, token, email, company string, workspace uint, brand notification.Branding, locale string) error {
+	data := SignupMail{
+		Route:       route,
+		Token:       token,
+		CompanyName: company,
+	}
+
+	return mailer.Send(ctx, notification.TemplateMail{
+		Sender:        notification.SenderPostmaster,
+		Branding:      brand,
+		Locale:        locale,
+		Workspace:     workspace,
+		ReceiverEmail: email,
+		Template:      "signup",
+		TemplateData:  data,
+	})
+}
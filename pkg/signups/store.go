@@ -0,0 +1,74 @@
+package signups
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/tsaron/anansi/tokens"
+)
+
+const (
+	// TTL is how long the link in a signup email can be used for.
+	TTL = time.Hour * 24
+	// Cooldown is how long an address waits between signup emails.
+	Cooldown = time.Minute
+)
+
+// ErrExpired is returned for signups that don't exist, expired or were used up.
+var ErrExpired = errors.New("signup has expired")
+
+// Signup is someone proving they own an email address before joining a workspace
+// they weren't invited to. Nothing is created for them until they do.
+type Signup struct {
+	EmailAddress string `json:"email_address"`
+	// Link is the token of the join link they're joining through, if any
+	Link string `json:"link,omitempty"`
+}
+
+type Store struct {
+	tStore *tokens.Store
+	redis  *redis.Client
+}
+
+func NewStore(tStore *tokens.Store, redis *redis.Client) *Store {
+	return &Store{tStore, redis}
+}
+
+// Start creates a signup for an address, replacing any pending one, and returns the
+// token to email it. The token is empty when the address was sent one less than
+// Cooldown ago, so signups can't be used to flood an inbox.
+func (s *Store) Start(ctx context.Context, email, link string) (string, error) {
+	ok, err := s.redis.SetNX(ctx, "signup-sent:"+email, 1, Cooldown).Result()
+	if err != nil || !ok {
+		return "", err
+	}
+
+	return s.tStore.Commission(ctx, TTL, key(email), Signup{email, link})
+}
+
+func (s *Store) View(ctx context.Context, token string) (Signup, error) {
+	var su Signup
+	err := s.tStore.Peek(ctx, token, &su)
+
+	if errors.Is(err, tokens.ErrTokenNotFound) {
+		return Signup{}, ErrExpired
+	}
+
+	return su, err
+}
+
+// End uses up the signup of an address.
+func (s *Store) End(ctx context.Context, email string) error {
+	err := s.tStore.Revoke(ctx, key(email))
+	if errors.Is(err, tokens.ErrTokenNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+func key(email string) string {
+	return "signup:" + email
+}
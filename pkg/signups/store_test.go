@@ -0,0 +1,96 @@
+package signups
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/tsaron/anansi"
+	"github.com/tsaron/anansi/tokens"
+	"tsaron.com/godview-starter/pkg/config"
+)
+
+var store *Store
+var mem *redis.Client
+
+func afterEach(t *testing.T) {
+	if _, err := mem.FlushDB(context.TODO()).Result(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	var err error
+
+	var env config.Env
+	if err = anansi.LoadEnv(&env); err != nil {
+		panic(err)
+	}
+
+	if mem, err = config.SetupRedis(context.TODO(), env); err != nil {
+		panic(err)
+	}
+	store = NewStore(tokens.NewStore(mem, env.Secret), mem)
+
+	code := m.Run()
+
+	if err := mem.Close(); err != nil {
+		panic(err)
+	}
+
+	os.Exit(code)
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("views a started signup until it ends", func(t *testing.T) {
+		defer afterEach(t)
+
+		token, err := store.Start(ctx, "jane@example.com", "link")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		su, err := store.View(ctx, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if su.EmailAddress != "jane@example.com" || su.Link != "link" {
+			t.Errorf("Expected the signup for jane through link, got %v", su)
+		}
+
+		if err := store.End(ctx, su.EmailAddress); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.View(ctx, token); err != ErrExpired {
+			t.Errorf("Expected %v, got %v", ErrExpired, err)
+		}
+	})
+
+	t.Run("doesn't start another signup during the cooldown", func(t *testing.T) {
+		defer afterEach(t)
+
+		if _, err := store.Start(ctx, "jane@example.com", ""); err != nil {
+			t.Fatal(err)
+		}
+
+		token, err := store.Start(ctx, "jane@example.com", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if token != "" {
+			t.Errorf("Expected no token during the cooldown, got %s", token)
+		}
+	})
+
+	t.Run("ends signups that don't exist", func(t *testing.T) {
+		if err := store.End(ctx, "john@example.com"); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+var validKey = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9/._-]*$`)
+
+// Info describes a stored blob.
+type Info struct {
+	Size        int64
+	ContentType string
+}
+
+// Blob stores files under slash separated keys such as "avatars/1/2/photo.png".
+type Blob interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens a blob for reading. It's the caller's job to close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, Info, error)
+	// Delete removes a blob. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL creates a URL anyone can download the blob from until ttl runs out.
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+func checkKey(key string) error {
+	if !validKey.MatchString(key) || strings.Contains(key, "..") || strings.Contains(key, "//") {
+		return ErrInvalidKey
+	}
+
+	return nil
+}
+
+// ThumbnailKey is where the thumbnail of the blob at key is kept.
+func ThumbnailKey(key string) string {
+	ext := path.Ext(key)
+	return strings.TrimSuffix(key, ext) + "-thumb" + ext
+}
@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a stand-in for an S3 compatible server, speaking just enough of the API
+// for path style requests to a single bucket. It doesn't check signatures.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		raw, err := io.ReadAll(r.Body)
+		if err == nil && strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+			raw, err = unchunk(raw)
+		}
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		f.objects[key] = raw
+		f.types[key] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodGet, http.MethodHead:
+		raw, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", f.types[key])
+		w.Header().Set("Content-Length", strconv.Itoa(len(raw)))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", `"etag"`)
+		if r.Method == http.MethodGet {
+			w.Write(raw)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// unchunk decodes a body sent with aws-chunked encoding, where every chunk is
+// "<hex size>;chunk-signature=<signature>\r\n<data>\r\n".
+func unchunk(raw []byte) ([]byte, error) {
+	var out []byte
+	for {
+		i := bytes.Index(raw, []byte("\r\n"))
+		if i < 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		header := string(raw[:i])
+		if j := strings.IndexByte(header, ';'); j >= 0 {
+			header = header[:j]
+		}
+
+		size, err := strconv.ParseInt(header, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		raw = raw[i+2:]
+		if size == 0 {
+			return out, nil
+		}
+
+		if int64(len(raw)) < size+2 {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		out = append(out, raw[:size]...)
+		raw = raw[size+2:]
+	}
+}
+
+func testBlob(t *testing.T, b Blob) {
+	ctx := context.TODO()
+	key := "avatars/1/2/photo.png"
+	content := []byte("not really a png")
+
+	if err := b.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	r, info, err := b.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(raw, content) || info.ContentType != "image/png" || info.Size != int64(len(content)) {
+		t.Errorf("Expected to read back what was stored, got %q as %v", raw, info)
+	}
+
+	signed, err := b.SignedURL(ctx, key, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u, err := url.Parse(signed); err != nil || !strings.HasSuffix(u.Path, key) || u.RawQuery == "" {
+		t.Errorf("Expected a signed URL for %s, got %s", key, signed)
+	}
+
+	if err := b.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := b.Get(ctx, key); err != ErrNotFound {
+		t.Errorf("Expected deleted blobs to be %v, got %v", ErrNotFound, err)
+	}
+
+	if err := b.Put(ctx, "../etc/passwd", bytes.NewReader(content), int64(len(content)), "text/plain"); err != ErrInvalidKey {
+		t.Errorf("Expected keys outside the store to be %v, got %v", ErrInvalidKey, err)
+	}
+}
+
+func TestLocal(t *testing.T) {
+	local, err := NewLocal(t.TempDir(), "http://localhost/api/v1/files", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testBlob(t, local)
+
+	t.Run("verifies its signed urls", func(t *testing.T) {
+		signed, err := local.SignedURL(context.TODO(), "logos/1/logo.png", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u, _ := url.Parse(signed)
+		q := u.Query()
+
+		if err := local.Verify("logos/1/logo.png", q.Get("expires"), q.Get("signature")); err != nil {
+			t.Errorf("Expected the signed URL to be valid, got %v", err)
+		}
+
+		if err := local.Verify("logos/2/logo.png", q.Get("expires"), q.Get("signature")); err != ErrInvalidSignature {
+			t.Errorf("Expected the signature to be tied to the key, got %v", err)
+		}
+	})
+
+	t.Run("rejects expired urls", func(t *testing.T) {
+		signed, err := local.SignedURL(context.TODO(), "logos/1/logo.png", -time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u, _ := url.Parse(signed)
+		q := u.Query()
+
+		if err := local.Verify("logos/1/logo.png", q.Get("expires"), q.Get("signature")); err != ErrURLExpired {
+			t.Errorf("Expected %v, got %v", ErrURLExpired, err)
+		}
+	})
+}
+
+func TestS3(t *testing.T) {
+	server := httptest.NewServer(&fakeS3{objects: make(map[string][]byte), types: make(map[string]string)})
+	defer server.Close()
+
+	s3, err := NewS3(S3Opts{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    "uploads",
+		AccessKey: "minio",
+		SecretKey: "minio123",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testBlob(t, s3)
+}
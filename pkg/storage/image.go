@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	// decoders for the formats we accept
+	_ "image/gif"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxDimension is the largest width or height of an image we are willing to decode.
+const MaxDimension = 8000
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image type")
+	ErrImageTooLarge    = errors.New("image dimensions are too large")
+)
+
+// ImageTypes are the content types of images we accept.
+var ImageTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// Image is an upload re-encoded for storage, along with its thumbnail. Re-encoding
+// strips metadata such as the location a photo was taken.
+type Image struct {
+	ContentType string
+	Extension   string
+	Original    []byte
+	Thumbnail   []byte
+}
+
+// PrepareImage checks raw is an image we accept, then scales it to fit within max pixels
+// and makes a thumbnail of thumb pixels. Thumbnails are cropped to a square when crop is
+// set. JPEGs stay JPEGs, everything else becomes a PNG to keep transparency.
+func PrepareImage(raw []byte, max, thumb int, crop bool) (Image, error) {
+	contentType := http.DetectContentType(raw)
+	if !isImageType(contentType) {
+		return Image{}, ErrUnsupportedImage
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return Image{}, ErrUnsupportedImage
+	}
+
+	if cfg.Width > MaxDimension || cfg.Height > MaxDimension {
+		return Image{}, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return Image{}, ErrUnsupportedImage
+	}
+
+	img := Image{ContentType: "image/png", Extension: ".png"}
+	if contentType == "image/jpeg" {
+		img.ContentType, img.Extension = "image/jpeg", ".jpg"
+	}
+
+	thumbSrc := src
+	if crop {
+		thumbSrc = square(src)
+	}
+
+	if img.Original, err = encode(fit(src, max), img.ContentType); err != nil {
+		return Image{}, err
+	}
+
+	if img.Thumbnail, err = encode(fit(thumbSrc, thumb), img.ContentType); err != nil {
+		return Image{}, err
+	}
+
+	return img, nil
+}
+
+func isImageType(contentType string) bool {
+	for _, t := range ImageTypes {
+		if t == contentType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fit scales src down so neither side is longer than size, keeping its aspect ratio.
+func fit(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return src
+	}
+
+	if w >= h {
+		w, h = size, h*size/w
+	} else {
+		w, h = w*size/h, size
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, max(w, 1), max(h, 1)))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+
+	return dst
+}
+
+// square crops the middle of src to a square.
+func square(src image.Image) image.Image {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), src, image.Pt(x, y), draw.Src)
+
+	return dst
+}
+
+func encode(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	var err error
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+
+	return buf.Bytes(), err
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func pngOf(w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, 0, color.RGBA{255, 0, 0, 255})
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestPrepareImage(t *testing.T) {
+	t.Run("scales the image and crops its thumbnail", func(t *testing.T) {
+		img, err := PrepareImage(pngOf(2000, 1000), 1024, 128, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if img.ContentType != "image/png" || img.Extension != ".png" {
+			t.Errorf("Expected a png, got %s", img.ContentType)
+		}
+
+		original, err := png.DecodeConfig(bytes.NewReader(img.Original))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if original.Width != 1024 || original.Height != 512 {
+			t.Errorf("Expected the original to fit in 1024px, got %dx%d", original.Width, original.Height)
+		}
+
+		thumb, err := png.DecodeConfig(bytes.NewReader(img.Thumbnail))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if thumb.Width != 128 || thumb.Height != 128 {
+			t.Errorf("Expected a 128px square thumbnail, got %dx%d", thumb.Width, thumb.Height)
+		}
+	})
+
+	t.Run("keeps the aspect ratio of uncropped thumbnails", func(t *testing.T) {
+		img, err := PrepareImage(pngOf(400, 100), 1024, 200, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		thumb, err := png.DecodeConfig(bytes.NewReader(img.Thumbnail))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if thumb.Width != 200 || thumb.Height != 50 {
+			t.Errorf("Expected a 200x50 thumbnail, got %dx%d", thumb.Width, thumb.Height)
+		}
+	})
+
+	t.Run("keeps jpegs as jpegs", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 64)), nil); err != nil {
+			t.Fatal(err)
+		}
+
+		img, err := PrepareImage(buf.Bytes(), 1024, 32, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if img.ContentType != "image/jpeg" || img.Extension != ".jpg" {
+			t.Errorf("Expected a jpeg, got %s", img.ContentType)
+		}
+	})
+
+	t.Run("rejects anything that isn't an image", func(t *testing.T) {
+		if _, err := PrepareImage([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"), 1024, 128, true); err != ErrUnsupportedImage {
+			t.Errorf("Expected %v, got %v", ErrUnsupportedImage, err)
+		}
+	})
+}
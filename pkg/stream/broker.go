@@ -0,0 +1,192 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"tsaron.com/godview-starter/pkg/events"
+)
+
+// how many events each workspace keeps around for clients resuming a stream
+const retained = 1000
+
+// Message is an event as it was written to a workspace's stream. Messages pushed to a
+// single user aren't retained, so they have no ID.
+type Message struct {
+	ID    string          `json:"id,omitempty"`
+	Type  string          `json:"type"`
+	Event json.RawMessage `json:"event"`
+}
+
+// Broker writes workspace events to a capped Redis stream and fans them out over
+// pub/sub so any replica can deliver them to its connected clients.
+type Broker struct {
+	redis *redis.Client
+}
+
+func NewBroker(client *redis.Client) *Broker {
+	return &Broker{client}
+}
+
+func key(workspace uint) string {
+	return fmt.Sprintf("events:%d", workspace)
+}
+
+func userKey(user uint) string {
+	return fmt.Sprintf("users:%d", user)
+}
+
+func (b *Broker) Handle(ctx context.Context, e events.Event) error {
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	id, err := b.redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: key(e.Workspace),
+		MaxLen: retained,
+		Approx: true,
+		Values: map[string]interface{}{"type": e.Type, "event": raw},
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	msg, err := json.Marshal(Message{ID: id, Type: e.Type, Event: raw})
+	if err != nil {
+		return err
+	}
+
+	return b.redis.Publish(ctx, key(e.Workspace), msg).Err()
+}
+
+// Push sends data to the streams of a single user. It's only delivered to clients
+// connected at the time.
+func (b *Broker) Push(ctx context.Context, user uint, eventType string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	msg, err := json.Marshal(Message{Type: eventType, Event: raw})
+	if err != nil {
+		return err
+	}
+
+	return b.redis.Publish(ctx, userKey(user), msg).Err()
+}
+
+// Subscribe streams a workspace's events, along with anything pushed to user, until the
+// context is cancelled. When lastID is set, retained events after it are replayed
+// before live ones.
+func (b *Broker) Subscribe(ctx context.Context, workspace, user uint, lastID string) (<-chan Message, error) {
+	channels := []string{key(workspace)}
+	if user != 0 {
+		channels = append(channels, userKey(user))
+	}
+
+	// subscribe before replaying so nothing published in between is lost
+	sub := b.redis.Subscribe(ctx, channels...)
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, err
+	}
+
+	var backlog []Message
+	if lastID != "" {
+		entries, err := b.redis.XRange(ctx, key(workspace), lastID, "+").Result()
+		if err != nil {
+			sub.Close()
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.ID == lastID {
+				continue
+			}
+
+			backlog = append(backlog, Message{
+				ID:    entry.ID,
+				Type:  fmt.Sprint(entry.Values["type"]),
+				Event: json.RawMessage(fmt.Sprint(entry.Values["event"])),
+			})
+		}
+	}
+
+	out := make(chan Message)
+	go func() {
+		defer close(out)
+		defer sub.Close()
+
+		last := lastID
+		for _, msg := range backlog {
+			select {
+			case out <- msg:
+				last = msg.ID
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		live := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case raw, ok := <-live:
+				if !ok {
+					return
+				}
+
+				var msg Message
+				if err := json.Unmarshal([]byte(raw.Payload), &msg); err != nil {
+					continue
+				}
+
+				// skip what the replay already covered
+				if msg.ID != "" && last != "" && !after(msg.ID, last) {
+					continue
+				}
+
+				select {
+				case out <- msg:
+					if msg.ID != "" {
+						last = msg.ID
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// after reports whether stream ID a comes after b.
+func after(a, b string) bool {
+	aMs, aSeq := splitID(a)
+	bMs, bSeq := splitID(b)
+
+	if aMs != bMs {
+		return aMs > bMs
+	}
+
+	return aSeq > bSeq
+}
+
+func splitID(id string) (uint64, uint64) {
+	parts := strings.SplitN(id, "-", 2)
+	ms, _ := strconv.ParseUint(parts[0], 10, 64)
+
+	var seq uint64
+	if len(parts) == 2 {
+		seq, _ = strconv.ParseUint(parts[1], 10, 64)
+	}
+
+	return ms, seq
+}
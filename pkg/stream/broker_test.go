@@ -0,0 +1,156 @@
+package stream
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/tsaron/anansi"
+	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/events"
+)
+
+var mem *redis.Client
+
+func afterEach(t *testing.T) {
+	if _, err := mem.FlushDB(context.TODO()).Result(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	var err error
+
+	var env config.Env
+	if err = anansi.LoadEnv(&env); err != nil {
+		panic(err)
+	}
+
+	if mem, err = config.SetupRedis(context.TODO(), env); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	if err := mem.Close(); err != nil {
+		panic(err)
+	}
+
+	os.Exit(code)
+}
+
+func receive(t *testing.T, messages <-chan Message) Message {
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(time.Second * 2):
+		t.Fatal("Timed out waiting for a message")
+		return Message{}
+	}
+}
+
+func TestBrokerSubscribe(t *testing.T) {
+	broker := NewBroker(mem)
+
+	t.Run("delivers live events", func(t *testing.T) {
+		defer afterEach(t)
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		messages, err := broker.Subscribe(ctx, 1, 0, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := broker.Handle(ctx, events.Event{ID: "1", Type: events.MemberJoined, Workspace: 1}); err != nil {
+			t.Fatal(err)
+		}
+
+		if msg := receive(t, messages); msg.Type != events.MemberJoined {
+			t.Errorf("Expected a %s event, got %s", events.MemberJoined, msg.Type)
+		}
+	})
+
+	t.Run("resumes after the last event ID", func(t *testing.T) {
+		defer afterEach(t)
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		live, err := broker.Subscribe(ctx, 1, 0, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, eType := range []string{events.InvitationSent, events.InvitationAccepted, events.MemberJoined} {
+			if err := broker.Handle(ctx, events.Event{Type: eType, Workspace: 1}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		first := receive(t, live)
+
+		resumed, err := broker.Subscribe(ctx, 1, 0, first.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if msg := receive(t, resumed); msg.Type != events.InvitationAccepted {
+			t.Errorf("Expected replay to start at %s, got %s", events.InvitationAccepted, msg.Type)
+		}
+	})
+
+	t.Run("keeps workspaces apart", func(t *testing.T) {
+		defer afterEach(t)
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		messages, err := broker.Subscribe(ctx, 1, 0, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := broker.Handle(ctx, events.Event{Type: events.MemberJoined, Workspace: 2}); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case msg := <-messages:
+			t.Errorf("Expected no events from another workspace, got %v", msg)
+		case <-time.After(time.Millisecond * 200):
+		}
+	})
+	t.Run("delivers pushes to their user", func(t *testing.T) {
+		defer afterEach(t)
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		mine, err := broker.Subscribe(ctx, 1, 1, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		theirs, err := broker.Subscribe(ctx, 1, 2, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := broker.Push(ctx, 1, "notification.created", map[string]string{"title": "Hi"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if msg := receive(t, mine); msg.Type != "notification.created" || msg.ID != "" {
+			t.Errorf("Expected a notification without an ID, got %v", msg)
+		}
+
+		select {
+		case msg := <-theirs:
+			t.Errorf("Expected no pushes for another user, got %v", msg)
+		case <-time.After(time.Millisecond * 200):
+		}
+	})
+}
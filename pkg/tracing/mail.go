@@ -0,0 +1,36 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"tsaron.com/godview-starter/pkg/notification"
+)
+
+type tracedMailer struct {
+	next   notification.Mailer
+	tracer trace.Tracer
+}
+
+// Mailer wraps a mailer so every Send is recorded as a span.
+func Mailer(next notification.Mailer, tp trace.TracerProvider) notification.Mailer {
+	return &tracedMailer{next, tp.Tracer(instrumentation)}
+}
+
+func (m *tracedMailer) Send(ctx context.Context, mail notification.TemplateMail) error {
+	ctx, span := m.tracer.Start(ctx, "mail "+mail.Template,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("mail.template", mail.Template)),
+	)
+	defer span.End()
+
+	err := m.next.Send(ctx, mail)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
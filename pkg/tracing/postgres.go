@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/go-pg/pg/v10"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryHook is a go-pg hook that records a span for every query.
+type QueryHook struct {
+	tracer trace.Tracer
+}
+
+func NewQueryHook(tp trace.TracerProvider) *QueryHook {
+	return &QueryHook{tp.Tracer(instrumentation)}
+}
+
+func (h *QueryHook) BeforeQuery(ctx context.Context, e *pg.QueryEvent) (context.Context, error) {
+	ctx, _ = h.tracer.Start(ctx, "postgres", trace.WithSpanKind(trace.SpanKindClient))
+	return ctx, nil
+}
+
+func (h *QueryHook) AfterQuery(ctx context.Context, e *pg.QueryEvent) error {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return nil
+	}
+	defer span.End()
+
+	attrs := []attribute.KeyValue{attribute.String("db.system", "postgresql")}
+	if query, err := e.UnformattedQuery(); err == nil {
+		attrs = append(attrs, attribute.String("db.statement", string(query)))
+	}
+	if e.Result != nil {
+		attrs = append(attrs, attribute.Int("db.rows_affected", e.Result.RowsAffected()))
+	}
+	span.SetAttributes(attrs...)
+
+	if e.Err != nil && e.Err != pg.ErrNoRows {
+		span.RecordError(e.Err)
+		span.SetStatus(codes.Error, e.Err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"tsaron.com/godview-starter/pkg/notification"
+)
+
+type tracedSMS struct {
+	next   notification.SMSSender
+	tracer trace.Tracer
+}
+
+// SMS wraps an SMS sender so every message is recorded as a span.
+func SMS(next notification.SMSSender, tp trace.TracerProvider) notification.SMSSender {
+	return &tracedSMS{next, tp.Tracer(instrumentation)}
+}
+
+func (s *tracedSMS) SendSMS(ctx context.Context, m notification.SMS) error {
+	ctx, span := s.tracer.Start(ctx, "sms", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	err := s.next.SendSMS(ctx, m)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,145 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-redis/redis/v8"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"tsaron.com/godview-starter/pkg/notification"
+)
+
+func newProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)), exporter
+}
+
+type failingMailer struct{}
+
+func (failingMailer) Send(ctx context.Context, m notification.TemplateMail) error {
+	return errors.New("sendgrid is down")
+}
+
+func TestMiddleware(t *testing.T) {
+	tp, exporter := newProvider()
+
+	var inner trace.SpanContext
+	router := chi.NewRouter()
+	router.Use(Middleware(tp))
+	router.Get("/invitations/{token}", func(w http.ResponseWriter, r *http.Request) {
+		inner = trace.SpanContextFromContext(r.Context())
+	})
+
+	t.Run("names the span after the route", func(t *testing.T) {
+		exporter.Reset()
+
+		req := httptest.NewRequest(http.MethodGet, "/invitations/some-token", nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("Expected 1 span, got %d", len(spans))
+		}
+
+		if spans[0].Name != "GET /invitations/{token}" {
+			t.Errorf("Expected span to be named after the route, got %q", spans[0].Name)
+		}
+	})
+
+	t.Run("continues the trace from the request headers", func(t *testing.T) {
+		exporter.Reset()
+
+		traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+		req := httptest.NewRequest(http.MethodGet, "/invitations/some-token", nil)
+		req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if inner.TraceID().String() != traceID {
+			t.Errorf("Expected handler to run in trace %s, got %s", traceID, inner.TraceID())
+		}
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 || spans[0].Parent.SpanID().String() != "00f067aa0ba902b7" {
+			t.Errorf("Expected span to be a child of the incoming span")
+		}
+	})
+}
+
+func TestQueryHook(t *testing.T) {
+	tp, exporter := newProvider()
+	hook := NewQueryHook(tp)
+
+	ctx, err := hook.BeforeQuery(context.TODO(), &pg.QueryEvent{Query: "select 1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := hook.AfterQuery(ctx, &pg.QueryEvent{Query: "select 1", Err: errors.New("boom")}); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	if spans[0].Status.Description != "boom" {
+		t.Errorf("Expected span to record the query error, got %q", spans[0].Status.Description)
+	}
+}
+
+func TestRedisHook(t *testing.T) {
+	tp, exporter := newProvider()
+	hook := NewRedisHook(tp)
+
+	cmd := redis.NewStringCmd(context.TODO(), "get", "some-key")
+	cmd.SetErr(redis.Nil)
+
+	ctx, err := hook.BeforeProcess(context.TODO(), cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := hook.AfterProcess(ctx, cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	if spans[0].Name != "redis get" {
+		t.Errorf("Expected span to be named after the command, got %q", spans[0].Name)
+	}
+
+	if len(spans[0].Events) != 0 {
+		t.Errorf("Expected redis.Nil not to be recorded as an error")
+	}
+}
+
+func TestMailer(t *testing.T) {
+	tp, exporter := newProvider()
+	mailer := Mailer(failingMailer{}, tp)
+
+	err := mailer.Send(context.TODO(), notification.TemplateMail{Template: "invitation"})
+	if err == nil {
+		t.Fatal("Expected send error to be returned")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	if spans[0].Name != "mail invitation" {
+		t.Errorf("Expected span to be named after the template, got %q", spans[0].Name)
+	}
+}
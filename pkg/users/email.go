@@ -0,0 +1,166 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tsaron/anansi/tokens"
+	"tsaron.com/godview-starter/pkg/notification"
+)
+
+var (
+	emailChangeDuration = time.Hour * 24
+
+	ErrEmailChangeExpired = errors.New("email change has expired")
+)
+
+// EmailChange is a pending move of a user to a new email address. It takes effect once
+// the new address is confirmed, and can be cancelled from the old one until then.
+type EmailChange struct {
+	User         uint      `json:"user"`
+	Workspace    uint      `json:"workspace"`
+	EmailAddress string    `json:"email_address"`
+	NewAddress   string    `json:"new_email_address"`
+	Expires      time.Time `json:"expires_at"`
+}
+
+// heldChange is what the confirm and cancel tokens hold. The change is nested so the
+// tokens don't decode into a session, which shares its user and workspace keys, when
+// sent as a bearer token.
+type heldChange struct {
+	Change EmailChange `json:"email_change"`
+}
+
+func confirmKey(user uint) string {
+	return fmt.Sprintf("email-change:%d:confirm", user)
+}
+
+func cancelKey(user uint) string {
+	return fmt.Sprintf("email-change:%d:cancel", user)
+}
+
+// PlanEmailChange describes moving a user to a new address without starting it, for
+// responses that must look the same whether or not the change was started.
+func PlanEmailChange(user *User, address string) EmailChange {
+	return EmailChange{
+		User:         user.ID,
+		Workspace:    user.Workspace,
+		EmailAddress: user.EmailAddress,
+		NewAddress:   address,
+		Expires:      time.Now().Add(emailChangeDuration),
+	}
+}
+
+// NewEmailChange starts moving a user to a new address, replacing any pending change.
+// It returns the tokens that confirm and cancel the change.
+func NewEmailChange(ctx context.Context, tStore *tokens.Store, user *User, address string) (EmailChange, string, string, error) {
+	change := PlanEmailChange(user, address)
+
+	confirm, err := tStore.Commission(ctx, emailChangeDuration, confirmKey(user.ID), heldChange{change})
+	if err != nil {
+		return EmailChange{}, "", "", err
+	}
+
+	cancel, err := tStore.Commission(ctx, emailChangeDuration, cancelKey(user.ID), heldChange{change})
+	if err != nil {
+		return EmailChange{}, "", "", err
+	}
+
+	return change, confirm, cancel, nil
+}
+
+// ConfirmEmailChange uses up a confirm token, making its cancel token useless.
+func ConfirmEmailChange(ctx context.Context, tStore *tokens.Store, token string) (EmailChange, error) {
+	return endEmailChange(ctx, tStore, token, cancelKey)
+}
+
+// CancelEmailChange uses up a cancel token, making its confirm token useless.
+func CancelEmailChange(ctx context.Context, tStore *tokens.Store, token string) (EmailChange, error) {
+	return endEmailChange(ctx, tStore, token, confirmKey)
+}
+
+func endEmailChange(ctx context.Context, tStore *tokens.Store, token string, other func(uint) string) (EmailChange, error) {
+	var held heldChange
+	if err := tStore.Decommission(ctx, token, &held); err != nil {
+		if errors.Is(err, tokens.ErrTokenNotFound) {
+			return EmailChange{}, ErrEmailChangeExpired
+		}
+		return EmailChange{}, err
+	}
+
+	// some other kind of token
+	change := held.Change
+	if change.User == 0 {
+		return EmailChange{}, ErrEmailChangeExpired
+	}
+
+	if err := tStore.Revoke(ctx, other(change.User)); err != nil && !errors.Is(err, tokens.ErrTokenNotFound) {
+		return EmailChange{}, err
+	}
+
+	return change, nil
+}
+
+// EmailChangeMail is the data of the email-change and email-change-notice templates.
+type EmailChangeMail struct {
+	Route        string
+	Token        string
+	FirstName    string
+	EmailAddress string
+	NewAddress   string
+}
+
+func init() {
+	sample := EmailChangeMail{
+		Route:        "https://app.example.com/account/email",
+		Token:        "sample",
+		FirstName:    "Jane",
+		EmailAddress: "jane@example.com",
+		NewAddress:   "jane.doe@example.com",
+	}
+	notification.Declare("email-change", sample)
+	notification.Declare("email-change-notice", sample)
+}
+
+// SendEmailChange asks the new address to confirm the change, and tells the old
+// address how to cancel it.
+func SendEmailChange(ctx context.Context, mailer notification.Mailer, route string, change EmailChange, confirm, cancel string, user *User, brand notification.Branding, locale string) error {
+	name := fmt.Sprintf("%s %s", user.FirstName, user.LastName)
+	data := EmailChangeMail{
+		Route:        route,
+		Token:        confirm,
+		FirstName:    user.FirstName,
+		EmailAddress: change.EmailAddress,
+		NewAddress:   change.NewAddress,
+	}
+
+	err := mailer.Send(ctx, notification.TemplateMail{
+		Sender:        notification.SenderPostmaster,
+		Branding:      brand,
+		Locale:        locale,
+		Workspace:     user.Workspace,
+		Category:      notification.CategorySecurity,
+		ReceiverName:  name,
+		ReceiverEmail: change.NewAddress,
+		Template:      "email-change",
+		TemplateData:  data,
+	})
+	if err != nil {
+		return err
+	}
+
+	data.Token = cancel
+	return mailer.Send(ctx, notification.TemplateMail{
+		Sender:        notification.SenderPostmaster,
+		Branding:      brand,
+		Locale:        locale,
+		Workspace:     user.Workspace,
+		Category:      notification.CategorySecurity,
+		ReceiverName:  name,
+		ReceiverEmail: change.EmailAddress,
+		Template:      "email-change-notice",
+		TemplateData:  data,
+	})
+}
@@ -3,6 +3,7 @@ package users
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/go-pg/pg/v10"
@@ -32,15 +33,28 @@ type Registration struct {
 }
 
 type User struct {
-	ID           uint      `json:"id"`
-	CreatedAt    time.Time `json:"created_at"`
-	FirstName    string    `json:"first_name,omitempty"`
-	LastName     string    `json:"last_name,omitempty"`
-	Role         string    `json:"role"`
-	Password     []byte    `json:"-"`
-	EmailAddress string    `json:"email_address"`
-	PhoneNumber  string    `json:"phone_number,omitempty"`
-	Workspace    uint      `json:"workspace"`
+	ID              uint       `json:"id"`
+	CreatedAt       time.Time  `json:"created_at"`
+	FirstName       string     `json:"first_name,omitempty"`
+	LastName        string     `json:"last_name,omitempty"`
+	Role            string     `json:"role"`
+	Password        []byte     `json:"-"`
+	EmailAddress    string     `json:"email_address"`
+	PhoneNumber     string     `json:"phone_number,omitempty"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at,omitempty"`
+	Timezone        string     `json:"timezone,omitempty"`
+	Locale          string     `json:"locale,omitempty"`
+	Avatar          string     `json:"-"`
+	Workspace       uint       `json:"workspace"`
+}
+
+// Profile is the part of a user's details they can change themselves.
+type Profile struct {
+	FirstName   string
+	LastName    string
+	PhoneNumber string
+	Timezone    string
+	Locale      string
 }
 
 type UserRequest struct {
@@ -48,6 +62,10 @@ type UserRequest struct {
 	Role         string
 }
 
+// Claim takes a place in a workspace inside the transaction that creates the user
+// filling it, returning the workspace and the role the user joins with.
+type Claim func(tx *pg.Tx) (workspace uint, role string, err error)
+
 type Repo struct {
 	db *pg.DB
 }
@@ -75,6 +93,63 @@ func (r *Repo) Create(ctx context.Context, workspace uint, req UserRequest) (*Us
 	return user, err
 }
 
+// Join creates a user in the place claim takes, so the place is only used up if the
+// user is created.
+func (r *Repo) Join(ctx context.Context, email string, claim Claim) (*User, error) {
+	return r.join(ctx, &User{EmailAddress: email}, claim)
+}
+
+// JoinNamed is Join for someone who has told us their name but not set up a profile.
+func (r *Repo) JoinNamed(ctx context.Context, email, firstName, lastName string, claim Claim) (*User, error) {
+	return r.join(ctx, &User{EmailAddress: email, FirstName: firstName, LastName: lastName}, claim)
+}
+
+// JoinRegistered is Join for someone who has proved they own the address, setting up
+// their profile in the same go.
+func (r *Repo) JoinRegistered(ctx context.Context, email string, reg Registration, claim Claim) (*User, error) {
+	pwdBytes, err := bcrypt.GenerateFromPassword([]byte(reg.Password), 10)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.join(ctx, &User{
+		EmailAddress: email,
+		Password:     pwdBytes,
+		FirstName:    reg.FirstName,
+		LastName:     reg.LastName,
+		PhoneNumber:  reg.PhoneNumber,
+	}, claim)
+}
+
+func (r *Repo) join(ctx context.Context, user *User, claim Claim) (*User, error) {
+	err := r.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		var err error
+		if user.Workspace, user.Role, err = claim(tx); err != nil {
+			return err
+		}
+
+		_, err = tx.
+			ModelContext(ctx, user).
+			Returning("*").
+			Insert(user)
+
+		return err
+	})
+
+	if err != nil && postgres.ErrDuplicate.MatchString(err.Error()) {
+		if strings.Contains(err.Error(), "phone_number") {
+			return nil, ErrExistingPhoneNumber
+		}
+		return nil, ErrEmail(user.EmailAddress)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
 func (r *Repo) CreateMany(ctx context.Context, workspace uint, reqs []UserRequest) ([]User, error) {
 	var users []User
 
@@ -146,3 +221,164 @@ func (r *Repo) ChangePassword(ctx context.Context, wkID, id uint, password strin
 
 	return user, err
 }
+
+// Get returns the user with the given ID in a workspace. Returns nil if the user doesn't exist
+func (r *Repo) Get(ctx context.Context, wkID, id uint) (*User, error) {
+	user := new(User)
+	err := r.db.
+		ModelContext(ctx, user).
+		Where("id = ?", id).
+		Where("workspace = ?", wkID).
+		Select()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return user, err
+}
+
+// GetByEmail returns the user with the given email address. Returns nil if the user doesn't exist
+func (r *Repo) GetByEmail(ctx context.Context, email string) (*User, error) {
+	user := new(User)
+	err := r.db.
+		ModelContext(ctx, user).
+		Where("email_address = ?", email).
+		Select()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return user, err
+}
+
+// Admins returns the admins and owner of a workspace.
+func (r *Repo) Admins(ctx context.Context, wkID uint) ([]User, error) {
+	var users []User
+	err := r.db.
+		ModelContext(ctx, &users).
+		Where("workspace = ?", wkID).
+		Where("role in (?)", pg.In([]string{RoleAdmin, RoleOwner})).
+		Order("id").
+		Select()
+
+	return users, err
+}
+
+// ChangeRole updates the role of a user in a workspace. Returns nil if the user doesn't exist
+func (r *Repo) ChangeRole(ctx context.Context, wkID, id uint, role string) (*User, error) {
+	user := &User{Role: role}
+	_, err := r.db.
+		ModelContext(ctx, user).
+		Where("id = ?", id).
+		Where("workspace = ?", wkID).
+		Column("role").
+		Returning("*").
+		Update(user)
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return user, err
+}
+
+// VerifyPhone marks the phone number of a user as verified. It's a no-op, returning nil,
+// if the user has since changed their phone number.
+func (r *Repo) VerifyPhone(ctx context.Context, wkID, id uint, number string) (*User, error) {
+	user := new(User)
+	_, err := r.db.
+		ModelContext(ctx, user).
+		Set("phone_verified_at = now()").
+		Where("id = ?", id).
+		Where("workspace = ?", wkID).
+		Where("phone_number = ?", number).
+		Returning("*").
+		Update()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return user, err
+}
+
+// ChangeEmail moves a user from one email address to another. Returns nil if the user
+// no longer has the old address.
+func (r *Repo) ChangeEmail(ctx context.Context, wkID, id uint, from, to string) (*User, error) {
+	user := &User{EmailAddress: to}
+	_, err := r.db.
+		ModelContext(ctx, user).
+		Where("id = ?", id).
+		Where("workspace = ?", wkID).
+		Where("email_address = ?", from).
+		Column("email_address").
+		Returning("*").
+		Update(user)
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil && postgres.ErrDuplicate.MatchString(err.Error()) {
+		return nil, ErrEmail(to)
+	}
+
+	return user, err
+}
+
+// UpdateProfile replaces the profile of a user. Changing the phone number means it has to be
+// verified again. Returns nil if the user doesn't exist
+func (r *Repo) UpdateProfile(ctx context.Context, wkID, id uint, p Profile) (*User, error) {
+	user := new(User)
+	_, err := r.db.
+		ModelContext(ctx, user).
+		Set("first_name = ?", p.FirstName).
+		Set("last_name = ?", p.LastName).
+		Set("phone_verified_at = CASE WHEN phone_number IS NOT DISTINCT FROM ? THEN phone_verified_at END", nullable(p.PhoneNumber)).
+		Set("phone_number = ?", nullable(p.PhoneNumber)).
+		Set("timezone = ?", nullable(p.Timezone)).
+		Set("locale = ?", nullable(p.Locale)).
+		Where("id = ?", id).
+		Where("workspace = ?", wkID).
+		Returning("*").
+		Update()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil && postgres.ErrDuplicate.MatchString(err.Error()) {
+		return nil, ErrExistingPhoneNumber
+	}
+
+	return user, err
+}
+
+// SetAvatar changes the blob key of a user's avatar. An empty key removes it. Returns nil if
+// the user doesn't exist
+func (r *Repo) SetAvatar(ctx context.Context, wkID, id uint, key string) (*User, error) {
+	user := new(User)
+	_, err := r.db.
+		ModelContext(ctx, user).
+		Set("avatar = ?", nullable(key)).
+		Where("id = ?", id).
+		Where("workspace = ?", wkID).
+		Returning("*").
+		Update()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return user, err
+}
+
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
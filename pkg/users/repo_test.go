@@ -136,7 +136,7 @@ func TestRepoRegister(t *testing.T) {
 		faker.Name().FirstName(),
 		faker.Name().LastName(),
 		faker.Lorem().Word(),
-		faker.PhoneNumber().PhoneNumber(),
+		"+234803" + faker.Number().Number(7),
 	}
 
 	_, err = repo.Register(ctx, reqs[0].EmailAddress, reg)
@@ -155,3 +155,165 @@ func TestRepoRegister(t *testing.T) {
 		t.Errorf("Expected registeration with \"%v\", got %v", ErrExistingPhoneNumber, err)
 	}
 }
+
+func TestRepoJoinRegistered(t *testing.T) {
+	defer afterEach(t)
+
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+
+	wk, err := workspaces.NewRepo(testDB).Create(ctx, faker.Company().Name(), faker.Internet().Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claim := func(tx *pg.Tx) (uint, string, error) {
+		return wk.ID, RoleMember, nil
+	}
+
+	reg := Registration{
+		faker.Name().FirstName(),
+		faker.Name().LastName(),
+		faker.Lorem().Word(),
+		"+234803" + faker.Number().Number(7),
+	}
+
+	user, err := repo.JoinRegistered(ctx, faker.Internet().Email(), reg, claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.Workspace != wk.ID || user.Role != RoleMember || user.FirstName != reg.FirstName || len(user.Password) == 0 {
+		t.Errorf("Expected a registered member of the workspace, got %v", user)
+	}
+
+	_, err = repo.JoinRegistered(ctx, faker.Internet().Email(), reg, claim)
+	if err != ErrExistingPhoneNumber {
+		t.Errorf("Expected joining with \"%v\", got %v", ErrExistingPhoneNumber, err)
+	}
+
+	reg.PhoneNumber = "+234803" + faker.Number().Number(7)
+	_, err = repo.JoinRegistered(ctx, user.EmailAddress, reg, claim)
+	if _, ok := err.(ErrEmail); !ok {
+		t.Errorf("Expected error to be of type ErrEmail, got %T", err)
+	}
+}
+
+func TestRepoVerifyPhone(t *testing.T) {
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+
+	setup := func(t *testing.T) *User {
+		wk, err := workspaces.NewRepo(testDB).Create(ctx, faker.Company().Name(), faker.Internet().Email())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		user, err := repo.Create(ctx, wk.ID, UserRequest{faker.Internet().Email(), RoleMember})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reg := Registration{
+			faker.Name().FirstName(),
+			faker.Name().LastName(),
+			faker.Lorem().Word(),
+			"+234803" + faker.Number().Number(7),
+		}
+		if user, err = repo.Register(ctx, user.EmailAddress, reg); err != nil {
+			t.Fatal(err)
+		}
+
+		return user
+	}
+
+	t.Run("marks the phone number as verified", func(t *testing.T) {
+		defer afterEach(t)
+		user := setup(t)
+
+		verified, err := repo.VerifyPhone(ctx, user.Workspace, user.ID, user.PhoneNumber)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if verified == nil || verified.PhoneVerifiedAt == nil {
+			t.Errorf("Expected the phone number to be verified, got %v", verified)
+		}
+	})
+
+	t.Run("ignores numbers the user no longer has", func(t *testing.T) {
+		defer afterEach(t)
+		user := setup(t)
+
+		verified, err := repo.VerifyPhone(ctx, user.Workspace, user.ID, "+2348000000000")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if verified != nil {
+			t.Errorf("Expected nothing to be verified, got %v", verified)
+		}
+	})
+}
+
+func TestRepoChangeEmail(t *testing.T) {
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+
+	wkRepo := workspaces.NewRepo(testDB)
+
+	t.Run("moves the user to the new address", func(t *testing.T) {
+		defer afterEach(t)
+
+		wk, err := wkRepo.Create(ctx, faker.Company().Name(), faker.Internet().Email())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		user, err := repo.Create(ctx, wk.ID, UserRequest{faker.Internet().Email(), RoleMember})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		to := faker.Internet().Email()
+		changed, err := repo.ChangeEmail(ctx, wk.ID, user.ID, user.EmailAddress, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if changed == nil || changed.EmailAddress != to {
+			t.Errorf("Expected email address to be %s, got %v", to, changed)
+		}
+
+		stale, err := repo.ChangeEmail(ctx, wk.ID, user.ID, user.EmailAddress, faker.Internet().Email())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stale != nil {
+			t.Errorf("Expected a change from an old address to be ignored, got %v", stale)
+		}
+	})
+
+	t.Run("rejects addresses that are in use", func(t *testing.T) {
+		defer afterEach(t)
+
+		wk, err := wkRepo.Create(ctx, faker.Company().Name(), faker.Internet().Email())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ux, err := repo.CreateMany(ctx, wk.ID, []UserRequest{
+			{faker.Internet().Email(), RoleMember},
+			{faker.Internet().Email(), RoleMember},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = repo.ChangeEmail(ctx, wk.ID, ux[0].ID, ux[0].EmailAddress, ux[1].EmailAddress)
+		if _, ok := err.(ErrEmail); !ok {
+			t.Errorf("Expected error to be of type ErrEmail, got %T: %v", err, err)
+		}
+	})
+}
@@ -51,7 +51,24 @@ func NewResetToken(ctx context.Context, tStore *tokens.Store, user *User) (Reset
 	return rToken, nil
 }
 
-func SendResetToken(mailer notification.Mailer, route string, token ResetToken, user *User) error {
+// ResetMail is the data of the password-reset template.
+type ResetMail struct {
+	Route     string
+	Token     string
+	Expires   string
+	FirstName string
+}
+
+func init() {
+	notification.Declare("password-reset", ResetMail{
+		Route:     "https://app.example.com/reset-password",
+		Token:     "sample",
+		Expires:   "3:04 pm today",
+		FirstName: "Jane",
+	})
+}
+
+func SendResetToken(ctx context.Context, mailer notification.Mailer, route string, token ResetToken, user *User, brand notification.Branding, locale string) error {
 	var day string
 	if token.Expires.Day() == time.Now().Day() {
 		day = "today"
@@ -59,20 +76,18 @@ func SendResetToken(mailer notification.Mailer, route string, token ResetToken,
 		day = "tomorrow"
 	}
 
-	data := struct {
-		Route     string
-		Token     string
-		Expires   string
-		FirstName string
-	}{
-		route,
-		token.Key,
-		fmt.Sprintf("%s %s", token.Expires.Format("3:04 pm"), day),
-		user.FirstName,
+	data := ResetMail{
+		Route:     route,
+		Token:     token.Key,
+		Expires:   fmt.Sprintf("%s %s", token.Expires.Format("3:04 pm"), day),
+		FirstName: user.FirstName,
 	}
-	return mailer.Send(notification.TemplateMail{
+	return mailer.Send(ctx, notification.TemplateMail{
 		Sender:        notification.SenderPostmaster,
-		Subject:       "Reset your password",
+		Branding:      brand,
+		Locale:        locale,
+		Workspace:     user.Workspace,
+		Category:      notification.CategorySecurity,
 		ReceiverName:  fmt.Sprintf("%s %s", user.FirstName, user.LastName),
 		ReceiverEmail: user.EmailAddress,
 		Template:      "password-reset",
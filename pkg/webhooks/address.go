@@ -0,0 +1,101 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned for endpoints that point into a private network,
+// so webhooks can't be used to reach our own services.
+var ErrPrivateAddress = errors.New("endpoint resolves to a private address")
+
+// Resolver looks up the addresses of a host. net.DefaultResolver is one.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// reserved are ranges net.IP doesn't have a method for.
+var reserved = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",     // this network
+		"100.64.0.0/10", // carrier-grade NAT
+		"192.0.0.0/24",  // IETF protocol assignments
+		"198.18.0.0/15", // benchmarking
+		"240.0.0.0/4",   // reserved, including broadcast
+		"64:ff9b::/96",  // NAT64, which can reach any of the above
+	} {
+		_, n, _ := net.ParseCIDR(cidr)
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// Public reports whether ip is routable on the public internet.
+func Public(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+
+	for _, n := range reserved {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CheckURL fails with ErrPrivateAddress when the host of an endpoint URL is, or
+// resolves to, an address that isn't public.
+func CheckURL(ctx context.Context, resolver Resolver, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		if !Public(ip) {
+			return ErrPrivateAddress
+		}
+		return nil
+	}
+
+	addrs, err := resolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return err
+	}
+
+	for _, addr := range addrs {
+		if !Public(addr.IP) {
+			return ErrPrivateAddress
+		}
+	}
+
+	return nil
+}
+
+// dialer refuses to connect to addresses that aren't public. It checks the address
+// actually dialed, so a host can't pass CheckURL and later resolve somewhere else.
+func dialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout: time.Second * 5,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			if ip := net.ParseIP(host); ip == nil || !Public(ip) {
+				return ErrPrivateAddress
+			}
+
+			return nil
+		},
+	}
+}
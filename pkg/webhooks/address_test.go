@@ -0,0 +1,60 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+type stubResolver map[string][]string
+
+func (s stubResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	var addrs []net.IPAddr
+	for _, ip := range s[host] {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
+
+func TestCheckURL(t *testing.T) {
+	resolver := stubResolver{
+		"hooks.example.com":  {"93.184.216.34"},
+		"rebind.example.com": {"93.184.216.34", "10.0.0.12"},
+		"localhost":          {"127.0.0.1", "::1"},
+	}
+
+	urls := map[string]error{
+		"https://hooks.example.com/events":          nil,
+		"https://93.184.216.34/events":              nil,
+		"https://rebind.example.com/events":         ErrPrivateAddress,
+		"http://localhost:8080/events":              ErrPrivateAddress,
+		"http://169.254.169.254/latest/meta-data":   ErrPrivateAddress,
+		"http://192.168.1.1/":                       ErrPrivateAddress,
+		"http://[::1]/":                             ErrPrivateAddress,
+		"http://[fd00::1]/":                         ErrPrivateAddress,
+		"http://100.64.0.1/":                        ErrPrivateAddress,
+		"http://[::ffff:127.0.0.1]/":                ErrPrivateAddress,
+		"http://0.0.0.0/":                           ErrPrivateAddress,
+		"https://hooks.example.com:8443/169.254.x/": nil,
+	}
+
+	for u, want := range urls {
+		if err := CheckURL(context.TODO(), resolver, u); !errors.Is(err, want) {
+			t.Errorf("Expected %s to give %v, got %v", u, want, err)
+		}
+	}
+}
+
+func TestDialer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	_, err = dialer().DialContext(context.TODO(), "tcp", l.Addr().String())
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("Expected dialing loopback to fail with %v, got %v", ErrPrivateAddress, err)
+	}
+}
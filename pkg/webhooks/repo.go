@@ -0,0 +1,259 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+type Endpoint struct {
+	tableName struct{} `pg:"webhook_endpoints"`
+
+	ID         uint       `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Workspace  uint       `json:"workspace"`
+	URL        string     `json:"url"`
+	Secret     string     `json:"-"`
+	Events     []string   `json:"events" pg:",array"`
+	Failures   int        `json:"failures" pg:",use_zero"`
+	DisabledAt *time.Time `json:"disabled_at"`
+}
+
+type Delivery struct {
+	tableName struct{} `pg:"webhook_deliveries"`
+
+	ID             uint       `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	Endpoint       uint       `json:"endpoint"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        string     `json:"payload" pg:"type:jsonb"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts" pg:",use_zero"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+}
+
+type Repo struct {
+	db *pg.DB
+}
+
+func NewRepo(db *pg.DB) *Repo {
+	return &Repo{db}
+}
+
+func (r *Repo) CreateEndpoint(ctx context.Context, e *Endpoint) (*Endpoint, error) {
+	_, err := r.db.
+		ModelContext(ctx, e).
+		Returning("*").
+		Insert(e)
+
+	return e, err
+}
+
+func (r *Repo) ListEndpoints(ctx context.Context, workspace uint) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	err := r.db.
+		ModelContext(ctx, &endpoints).
+		Where("workspace = ?", workspace).
+		Order("id").
+		Select()
+
+	return endpoints, err
+}
+
+// GetEndpoint returns an endpoint in a workspace. Returns nil if the endpoint doesn't exist
+func (r *Repo) GetEndpoint(ctx context.Context, workspace, id uint) (*Endpoint, error) {
+	e := new(Endpoint)
+	err := r.db.
+		ModelContext(ctx, e).
+		Where("id = ?", id).
+		Where("workspace = ?", workspace).
+		Select()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return e, err
+}
+
+// UpdateEndpoint saves the URL, events and disabled state of an endpoint. Re-enabling an
+// endpoint clears its failure count.
+func (r *Repo) UpdateEndpoint(ctx context.Context, e *Endpoint) (*Endpoint, error) {
+	if e.DisabledAt == nil {
+		e.Failures = 0
+	}
+
+	_, err := r.db.
+		ModelContext(ctx, e).
+		Where("id = ?", e.ID).
+		Where("workspace = ?", e.Workspace).
+		Column("url", "events", "failures", "disabled_at").
+		Returning("*").
+		Update(e)
+
+	return e, err
+}
+
+func (r *Repo) DeleteEndpoint(ctx context.Context, workspace, id uint) error {
+	_, err := r.db.
+		ModelContext(ctx, (*Endpoint)(nil)).
+		Where("id = ?", id).
+		Where("workspace = ?", workspace).
+		Delete()
+
+	return err
+}
+
+// Subscribers returns the enabled endpoints of a workspace listening for an event type.
+func (r *Repo) Subscribers(ctx context.Context, workspace uint, eventType string) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	err := r.db.
+		ModelContext(ctx, &endpoints).
+		Where("workspace = ?", workspace).
+		Where("disabled_at is null").
+		Where("? = any(events)", eventType).
+		Select()
+
+	return endpoints, err
+}
+
+func (r *Repo) Enqueue(ctx context.Context, deliveries []Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	_, err := r.db.
+		ModelContext(ctx, &deliveries).
+		Insert(&deliveries)
+
+	return err
+}
+
+// Claim locks up to limit due deliveries for the duration of the lease so no other
+// worker picks them up while they are being attempted.
+func (r *Repo) Claim(ctx context.Context, limit int, lease time.Duration) ([]Delivery, error) {
+	var deliveries []Delivery
+
+	err := r.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		err := tx.
+			ModelContext(ctx, &deliveries).
+			Where("status = ?", StatusPending).
+			Where("next_attempt_at <= now()").
+			Order("next_attempt_at").
+			Limit(limit).
+			For("UPDATE SKIP LOCKED").
+			Select()
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+
+		_, err = tx.
+			ModelContext(ctx, (*Delivery)(nil)).
+			Set("next_attempt_at = ?", time.Now().Add(lease)).
+			Where("id in (?)", pg.In(ids)).
+			Update()
+
+		return err
+	})
+
+	return deliveries, err
+}
+
+// SaveAttempt records the outcome of a delivery attempt.
+func (r *Repo) SaveAttempt(ctx context.Context, d *Delivery) error {
+	_, err := r.db.
+		ModelContext(ctx, d).
+		WherePK().
+		Column("status", "attempts", "next_attempt_at", "last_attempt_at", "response_status", "last_error").
+		Update()
+
+	return err
+}
+
+// endpointByID loads an endpoint regardless of its workspace, for the worker.
+func (r *Repo) endpointByID(ctx context.Context, id uint) (*Endpoint, error) {
+	e := &Endpoint{ID: id}
+	err := r.db.ModelContext(ctx, e).WherePK().Select()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return e, err
+}
+
+// RecordFailure counts a failed attempt against an endpoint, disabling it once
+// it reaches the threshold of consecutive failures.
+func (r *Repo) RecordFailure(ctx context.Context, id uint, threshold int) (*Endpoint, error) {
+	e := new(Endpoint)
+	_, err := r.db.
+		ModelContext(ctx, e).
+		Set("failures = failures + 1").
+		Set("disabled_at = case when failures + 1 >= ? then now() else disabled_at end", threshold).
+		Where("id = ?", id).
+		Returning("*").
+		Update()
+
+	return e, err
+}
+
+func (r *Repo) ResetFailures(ctx context.Context, id uint) error {
+	_, err := r.db.
+		ModelContext(ctx, (*Endpoint)(nil)).
+		Set("failures = 0").
+		Where("id = ?", id).
+		Where("failures > 0").
+		Update()
+
+	return err
+}
+
+func (r *Repo) ListDeliveries(ctx context.Context, endpoint uint, offset, limit int) ([]Delivery, error) {
+	var deliveries []Delivery
+	err := r.db.
+		ModelContext(ctx, &deliveries).
+		Where("endpoint = ?", endpoint).
+		Order("created_at desc", "id desc").
+		Offset(offset).
+		Limit(limit).
+		Select()
+
+	return deliveries, err
+}
+
+// Redeliver queues a delivery to be attempted again right away with a fresh set of retries.
+// Returns nil if the delivery doesn't exist
+func (r *Repo) Redeliver(ctx context.Context, endpoint, id uint) (*Delivery, error) {
+	d := new(Delivery)
+	_, err := r.db.
+		ModelContext(ctx, d).
+		Set("status = ?", StatusPending).
+		Set("attempts = 0").
+		Set("next_attempt_at = now()").
+		Where("id = ?", id).
+		Where("endpoint = ?", endpoint).
+		Returning("*").
+		Update()
+
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return d, err
+}
@@ -0,0 +1,54 @@
+package webhooks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	secret := "whsec_test"
+	payload := []byte(`{"type":"member.joined"}`)
+
+	t.Run("accepts its own signature", func(t *testing.T) {
+		header := Sign(secret, time.Now(), payload)
+		if err := Verify(secret, header, payload, time.Minute*5); err != nil {
+			t.Errorf("Expected signature to be valid, got %v", err)
+		}
+	})
+
+	t.Run("rejects a tampered payload", func(t *testing.T) {
+		header := Sign(secret, time.Now(), payload)
+		if err := Verify(secret, header, []byte(`{"type":"member.left"}`), time.Minute*5); err != ErrInvalidSignature {
+			t.Errorf("Expected %v, got %v", ErrInvalidSignature, err)
+		}
+	})
+
+	t.Run("rejects the wrong secret", func(t *testing.T) {
+		header := Sign("whsec_other", time.Now(), payload)
+		if err := Verify(secret, header, payload, time.Minute*5); err != ErrInvalidSignature {
+			t.Errorf("Expected %v, got %v", ErrInvalidSignature, err)
+		}
+	})
+
+	t.Run("rejects old signatures", func(t *testing.T) {
+		header := Sign(secret, time.Now().Add(-time.Hour), payload)
+		if err := Verify(secret, header, payload, time.Minute*5); err != ErrInvalidSignature {
+			t.Errorf("Expected %v, got %v", ErrInvalidSignature, err)
+		}
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  time.Second * 30,
+		2:  time.Minute,
+		3:  time.Minute * 2,
+		20: time.Hour * 6,
+	}
+
+	for attempts, expected := range cases {
+		if got := Backoff(attempts); got != expected {
+			t.Errorf("Expected backoff after %d attempts to be %v, got %v", attempts, expected, got)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+	"tsaron.com/godview-starter/pkg/events"
+)
+
+const (
+	maxAttempts      = 8
+	disableThreshold = 20
+	batchSize        = 20
+	baseBackoff      = time.Second * 30
+	maxBackoff       = time.Hour * 6
+	attemptTimeout   = time.Second * 10
+)
+
+// Dispatcher queues a delivery for every endpoint subscribed to a published event.
+type Dispatcher struct {
+	repo *Repo
+}
+
+func NewDispatcher(repo *Repo) *Dispatcher {
+	return &Dispatcher{repo}
+}
+
+func (d *Dispatcher) Handle(ctx context.Context, e events.Event) error {
+	endpoints, err := d.repo.Subscribers(ctx, e.Workspace, e.Type)
+	if err != nil || len(endpoints) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []Delivery
+	for _, ep := range endpoints {
+		deliveries = append(deliveries, Delivery{
+			Endpoint:      ep.ID,
+			EventID:       e.ID,
+			EventType:     e.Type,
+			Payload:       string(payload),
+			Status:        StatusPending,
+			NextAttemptAt: time.Now(),
+		})
+	}
+
+	return d.repo.Enqueue(ctx, deliveries)
+}
+
+// Worker attempts queued deliveries, retrying failures with exponential backoff.
+type Worker struct {
+	repo     *Repo
+	client   *http.Client
+	interval time.Duration
+	log      zerolog.Logger
+}
+
+func NewWorker(repo *Repo, log zerolog.Logger) *Worker {
+	return &Worker{
+		repo:     repo,
+		client:   client(),
+		interval: time.Second * 2,
+		log:      log.With().Str("worker", "webhooks").Logger(),
+	}
+}
+
+// client posts to public addresses only and doesn't follow redirects, which could
+// lead anywhere.
+func client() *http.Client {
+	return &http.Client{
+		Timeout: attemptTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer().DialContext,
+			TLSHandshakeTimeout: time.Second * 5,
+			MaxIdleConnsPerHost: 2,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Run polls for due deliveries until the context is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.deliverDue(ctx); err != nil && ctx.Err() == nil {
+				w.log.Err(err).Msg("failed to deliver webhooks")
+			}
+		}
+	}
+}
+
+func (w *Worker) deliverDue(ctx context.Context) error {
+	// don't let a slow batch hold deliveries past their lease
+	deliveries, err := w.repo.Claim(ctx, batchSize, attemptTimeout*batchSize*2)
+	if err != nil {
+		return err
+	}
+
+	for i := range deliveries {
+		if err := w.deliver(ctx, &deliveries[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *Worker) deliver(ctx context.Context, d *Delivery) error {
+	ep, err := w.repo.endpointByID(ctx, d.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	d.Attempts++
+	d.LastAttemptAt = &now
+
+	if ep == nil || ep.DisabledAt != nil {
+		d.Status = StatusFailed
+		d.LastError = "endpoint has been disabled"
+		return w.repo.SaveAttempt(ctx, d)
+	}
+
+	d.ResponseStatus, err = w.post(ctx, ep, d)
+	if err == nil {
+		d.Status = StatusSucceeded
+		d.LastError = ""
+		if err := w.repo.SaveAttempt(ctx, d); err != nil {
+			return err
+		}
+
+		return w.repo.ResetFailures(ctx, ep.ID)
+	}
+
+	d.LastError = err.Error()
+	if d.Attempts >= maxAttempts {
+		d.Status = StatusFailed
+	} else {
+		d.NextAttemptAt = now.Add(Backoff(d.Attempts))
+	}
+
+	if err := w.repo.SaveAttempt(ctx, d); err != nil {
+		return err
+	}
+
+	ep, err = w.repo.RecordFailure(ctx, ep.ID, disableThreshold)
+	if err != nil {
+		return err
+	}
+
+	if ep.DisabledAt != nil {
+		w.log.Warn().Uint("endpoint", ep.ID).Uint("workspace", ep.Workspace).Msg("disabled failing webhook endpoint")
+	}
+
+	return nil
+}
+
+func (w *Worker) post(ctx context.Context, ep *Endpoint, d *Delivery) (int, error) {
+	payload := []byte(d.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "godview-starter-webhooks")
+	req.Header.Set("X-Webhook-ID", d.EventID)
+	req.Header.Set("X-Webhook-Event", d.EventType)
+	req.Header.Set(SignatureHeader, Sign(ep.Secret, time.Now(), payload))
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	// drain a bit of the body so the connection can be reused
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(res.Body, 4096))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("endpoint responded with %s", res.Status)
+	}
+
+	return res.StatusCode, nil
+}
+
+// Backoff returns how long to wait before the next attempt after the given number of attempts.
+func Backoff(attempts int) time.Duration {
+	wait := baseBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+
+	return wait
+}
@@ -0,0 +1,28 @@
+package workspaces
+
+import (
+	"fmt"
+	"strings"
+
+	"tsaron.com/godview-starter/pkg/notification"
+)
+
+// Branding is how mail sent for the workspace should look. baseURL is the public URL
+// of the API, where the logo is served at /workspaces/{id}/logo.
+func (w *Workspace) Branding(baseURL string) notification.Branding {
+	b := notification.Branding{
+		Name:        w.BrandName,
+		AccentColor: w.AccentColor,
+		ReplyTo:     w.ReplyTo,
+	}
+
+	if b.Name == "" {
+		b.Name = w.CompanyName
+	}
+
+	if w.Logo != "" {
+		b.LogoURL = fmt.Sprintf("%s/workspaces/%d/logo", strings.TrimSuffix(baseURL, "/"), w.ID)
+	}
+
+	return b
+}
@@ -0,0 +1,69 @@
+package workspaces
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultInvitationPolicy is what new workspaces start with.
+var DefaultInvitationPolicy = InvitationPolicy{
+	TTLHours:       48,
+	ExtensionHours: 1,
+	MaxExtensions:  3,
+	Roles:          []string{"member", "admin"},
+	Domains:        []string{},
+}
+
+// InvitationPolicy is how the owner of a workspace lets admins invite people.
+type InvitationPolicy struct {
+	// TTLHours is how long an invitation lasts before it's extended
+	TTLHours int `json:"ttl_hours"`
+	// ExtensionHours is how long each extension keeps an invitation alive
+	ExtensionHours int `json:"extension_hours"`
+	// MaxExtensions is how many times an invitation can be extended
+	MaxExtensions int `json:"max_extensions"`
+	// Roles admins may grant invitees
+	Roles []string `json:"roles"`
+	// Domains invitees' addresses must be at, any domain when empty
+	Domains []string `json:"domains"`
+}
+
+func (p InvitationPolicy) TTL() time.Duration {
+	return time.Duration(p.TTLHours) * time.Hour
+}
+
+func (p InvitationPolicy) Extension() time.Duration {
+	return time.Duration(p.ExtensionHours) * time.Hour
+}
+
+// AllowsRole checks whether admins may invite people as role.
+func (p InvitationPolicy) AllowsRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowsEmail checks whether an address is at one of the allowed domains.
+func (p InvitationPolicy) AllowsEmail(email string) bool {
+	if len(p.Domains) == 0 {
+		return true
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+
+	domain := strings.ToLower(email[at+1:])
+	for _, d := range p.Domains {
+		if strings.EqualFold(d, domain) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,26 @@
+package workspaces
+
+import "testing"
+
+func TestInvitationPolicyAllowsEmail(t *testing.T) {
+	cases := []struct {
+		domains []string
+		email   string
+		allowed bool
+	}{
+		{nil, "jane@example.com", true},
+		{[]string{"example.com"}, "jane@example.com", true},
+		{[]string{"example.com"}, "Jane@Example.COM", true},
+		{[]string{"example.com"}, "jane@mail.example.com", false},
+		{[]string{"example.com"}, "jane@example.com.ng", false},
+		{[]string{"example.com", "example.org"}, "jane@example.org", true},
+		{[]string{"example.com"}, "jane", false},
+	}
+
+	for _, c := range cases {
+		p := InvitationPolicy{Domains: c.domains}
+		if allowed := p.AllowsEmail(c.email); allowed != c.allowed {
+			t.Errorf("Expected AllowsEmail(%q) with %v to be %v, got %v", c.email, c.domains, c.allowed, allowed)
+		}
+	}
+}
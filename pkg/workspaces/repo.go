@@ -12,6 +12,14 @@ type Workspace struct {
 	CreatedAt    time.Time `json:"created_at"`
 	CompanyName  string    `json:"company_name"`
 	EmailAddress string    `json:"email_address"`
+	Region       string    `json:"region"`
+	Locale       string    `json:"locale"`
+	Logo         string    `json:"-"`
+	BrandName    string    `json:"brand_name"`
+	AccentColor  string    `json:"accent_color"`
+	ReplyTo      string    `json:"reply_to"`
+
+	InvitationPolicy InvitationPolicy `json:"invitation_policy"`
 }
 
 type Repo struct {
@@ -24,7 +32,11 @@ func NewRepo(db *pg.DB) *Repo {
 
 // Create a workspace
 func (r *Repo) Create(ctx context.Context, name, email string) (*Workspace, error) {
-	workspace := &Workspace{CompanyName: name, EmailAddress: email}
+	workspace := &Workspace{
+		CompanyName:      name,
+		EmailAddress:     email,
+		InvitationPolicy: DefaultInvitationPolicy,
+	}
 
 	_, err := r.db.
 		ModelContext(ctx, workspace).
@@ -62,3 +74,94 @@ func (r *Repo) ChangeName(ctx context.Context, id uint, name string) (*Workspace
 
 	return workspace, err
 }
+
+// ChangeRegion updates the region used to read national phone numbers for a workspace.
+func (r *Repo) ChangeRegion(ctx context.Context, id uint, region string) (*Workspace, error) {
+	workspace := &Workspace{
+		ID:     id,
+		Region: region,
+	}
+
+	_, err := r.db.
+		ModelContext(ctx, workspace).
+		WherePK().
+		Column("region").
+		Returning("*").
+		Update(workspace)
+
+	return workspace, err
+}
+
+// ChangeLocale updates the language used for the workspace's mail when its members
+// haven't chosen one.
+func (r *Repo) ChangeLocale(ctx context.Context, id uint, locale string) (*Workspace, error) {
+	workspace := &Workspace{
+		ID:     id,
+		Locale: locale,
+	}
+
+	_, err := r.db.
+		ModelContext(ctx, workspace).
+		WherePK().
+		Column("locale").
+		Returning("*").
+		Update(workspace)
+
+	return workspace, err
+}
+
+// ChangeBranding sets how the workspace's emails look. Empty values go back to the
+// defaults.
+func (r *Repo) ChangeBranding(ctx context.Context, id uint, name, accent, replyTo string) (*Workspace, error) {
+	workspace := new(Workspace)
+
+	_, err := r.db.
+		ModelContext(ctx, workspace).
+		Set("brand_name = ?", nullable(name)).
+		Set("accent_color = ?", nullable(accent)).
+		Set("reply_to = ?", nullable(replyTo)).
+		Where("id = ?", id).
+		Returning("*").
+		Update()
+
+	return workspace, err
+}
+
+// ChangeInvitationPolicy replaces how admins of the workspace invite people.
+func (r *Repo) ChangeInvitationPolicy(ctx context.Context, id uint, policy InvitationPolicy) (*Workspace, error) {
+	workspace := &Workspace{
+		ID:               id,
+		InvitationPolicy: policy,
+	}
+
+	_, err := r.db.
+		ModelContext(ctx, workspace).
+		WherePK().
+		Column("invitation_policy").
+		Returning("*").
+		Update(workspace)
+
+	return workspace, err
+}
+
+// SetLogo changes the blob key of a workspace's logo. An empty key removes it.
+func (r *Repo) SetLogo(ctx context.Context, id uint, key string) (*Workspace, error) {
+	workspace := new(Workspace)
+
+	_, err := r.db.
+		ModelContext(ctx, workspace).
+		Set("logo = ?", nullable(key)).
+		Where("id = ?", id).
+		Returning("*").
+		Update()
+
+	return workspace, err
+}
+
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
@@ -3,13 +3,16 @@ package workspaces
 import (
 	"context"
 	"os"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/go-pg/pg/v10"
 	"github.com/tsaron/anansi"
 	"github.com/tsaron/anansi/postgres"
 	"syreclabs.com/go/faker"
 	"tsaron.com/godview-starter/pkg/config"
+	"tsaron.com/godview-starter/pkg/phone"
 )
 
 var testDB *pg.DB
@@ -79,3 +82,181 @@ func TestRepoGetByID(t *testing.T) {
 		}
 	})
 }
+
+func TestRepoChangeRegion(t *testing.T) {
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+
+	t.Run("defaults new workspaces to the default region", func(t *testing.T) {
+		defer afterEach(t)
+
+		wk, err := repo.Create(ctx, faker.Company().Name(), faker.Internet().Email())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if wk.Region != phone.DefaultRegion {
+			t.Errorf("Expected region to be %s, got %s", phone.DefaultRegion, wk.Region)
+		}
+	})
+
+	t.Run("updates the region of a workspace", func(t *testing.T) {
+		defer afterEach(t)
+
+		wk, err := repo.Create(ctx, faker.Company().Name(), faker.Internet().Email())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := repo.ChangeRegion(ctx, wk.ID, "FR"); err != nil {
+			t.Fatal(err)
+		}
+
+		wk2, err := repo.Get(ctx, wk.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if wk2.Region != "FR" {
+			t.Errorf("Expected region to be FR, got %s", wk2.Region)
+		}
+	})
+}
+
+func TestRepoSetLogo(t *testing.T) {
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+
+	t.Run("sets and clears the logo of a workspace", func(t *testing.T) {
+		defer afterEach(t)
+
+		wk, err := repo.Create(ctx, faker.Company().Name(), faker.Internet().Email())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		key := "logos/1/logo.png"
+		if wk, err = repo.SetLogo(ctx, wk.ID, key); err != nil {
+			t.Fatal(err)
+		}
+
+		if wk.Logo != key {
+			t.Errorf("Expected logo to be %s, got %s", key, wk.Logo)
+		}
+
+		if wk, err = repo.SetLogo(ctx, wk.ID, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if wk.Logo != "" {
+			t.Errorf("Expected logo to be cleared, got %s", wk.Logo)
+		}
+	})
+}
+
+func TestRepoChangeBranding(t *testing.T) {
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+
+	t.Run("uses the company name until a brand name is set", func(t *testing.T) {
+		defer afterEach(t)
+
+		wk, err := repo.Create(ctx, faker.Company().Name(), faker.Internet().Email())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b := wk.Branding("https://api.example.com/v1"); b.Name != wk.CompanyName || b.LogoURL != "" {
+			t.Errorf("Expected branding to fall back to the company name, got %v", b)
+		}
+
+		if wk, err = repo.ChangeBranding(ctx, wk.ID, "Acme", "#ff6600", "help@acme.com"); err != nil {
+			t.Fatal(err)
+		}
+
+		b := wk.Branding("https://api.example.com/v1")
+		if b.Name != "Acme" || b.AccentColor != "#ff6600" || b.ReplyTo != "help@acme.com" {
+			t.Errorf("Expected the new branding, got %v", b)
+		}
+	})
+
+	t.Run("clears branding with empty values", func(t *testing.T) {
+		defer afterEach(t)
+
+		wk, err := repo.Create(ctx, faker.Company().Name(), faker.Internet().Email())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = repo.ChangeBranding(ctx, wk.ID, "Acme", "#ff6600", "help@acme.com"); err != nil {
+			t.Fatal(err)
+		}
+
+		if wk, err = repo.ChangeBranding(ctx, wk.ID, "", "", ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if wk.BrandName != "" || wk.AccentColor != "" || wk.ReplyTo != "" {
+			t.Errorf("Expected branding to be cleared, got %v", wk)
+		}
+	})
+}
+
+func TestRepoChangeLocale(t *testing.T) {
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+
+	defer afterEach(t)
+
+	wk, err := repo.Create(ctx, faker.Company().Name(), faker.Internet().Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wk.Locale != "en" {
+		t.Errorf("Expected new workspaces to be in en, got %s", wk.Locale)
+	}
+
+	if wk, err = repo.ChangeLocale(ctx, wk.ID, "fr"); err != nil {
+		t.Fatal(err)
+	}
+
+	if wk.Locale != "fr" {
+		t.Errorf("Expected the workspace to be in fr, got %s", wk.Locale)
+	}
+}
+
+func TestRepoChangeInvitationPolicy(t *testing.T) {
+	repo := NewRepo(testDB)
+	ctx := context.TODO()
+
+	defer afterEach(t)
+
+	wk, err := repo.Create(ctx, faker.Company().Name(), faker.Internet().Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wk.InvitationPolicy.TTL() != time.Hour*48 || !wk.InvitationPolicy.AllowsRole("admin") {
+		t.Errorf("Expected new workspaces to have the default policy, got %v", wk.InvitationPolicy)
+	}
+
+	policy := InvitationPolicy{
+		TTLHours:       24,
+		ExtensionHours: 2,
+		MaxExtensions:  1,
+		Roles:          []string{"member"},
+		Domains:        []string{"example.com"},
+	}
+	if _, err = repo.ChangeInvitationPolicy(ctx, wk.ID, policy); err != nil {
+		t.Fatal(err)
+	}
+
+	if wk, err = repo.Get(ctx, wk.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(wk.InvitationPolicy, policy) {
+		t.Errorf("Expected the policy to be %v, got %v", policy, wk.InvitationPolicy)
+	}
+}
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS godview_starter.notifications (
+  id bigserial primary key,
+  created_at timestamptz not null default current_timestamp,
+  workspace integer not null references workspaces(id),
+  recipient integer not null references users(id) on delete cascade,
+  kind text not null,
+  title text not null,
+  body text,
+  link text,
+  data jsonb not null default '{}',
+  read_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS notifications_recipient_idx
+  ON godview_starter.notifications (recipient, (read_at is not null), created_at desc);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS godview_starter.notification_preferences (
+  "user" integer not null references users(id) on delete cascade,
+  category text not null,
+  channel text not null,
+  enabled boolean not null,
+  updated_at timestamptz not null default current_timestamp,
+  primary key ("user", category, channel)
+);
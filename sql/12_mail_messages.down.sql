@@ -0,0 +1,2 @@
+drop table if exists godview_starter.mail_suppressions;
+drop table if exists godview_starter.mail_messages;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS godview_starter.mail_messages (
+  id bigserial primary key,
+  created_at timestamptz not null default current_timestamp,
+  workspace integer references workspaces(id) on delete set null,
+  template text not null,
+  category text,
+  receiver text not null,
+  status text not null,
+  status_at timestamptz not null default current_timestamp,
+  reason text
+);
+
+CREATE INDEX IF NOT EXISTS mail_messages_workspace_idx
+  ON godview_starter.mail_messages (workspace, template, created_at desc);
+
+CREATE TABLE IF NOT EXISTS godview_starter.mail_suppressions (
+  email_address text primary key,
+  created_at timestamptz not null default current_timestamp,
+  reason text not null
+);
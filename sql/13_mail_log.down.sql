@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS godview_starter.mail_messages_created_at_idx;
+DROP INDEX IF EXISTS godview_starter.mail_messages_provider_id_idx;
+
+ALTER TABLE godview_starter.mail_messages
+  DROP COLUMN IF EXISTS redacted_at,
+  DROP COLUMN IF EXISTS resent_from,
+  DROP COLUMN IF EXISTS error,
+  DROP COLUMN IF EXISTS provider_id,
+  DROP COLUMN IF EXISTS data,
+  DROP COLUMN IF EXISTS locale,
+  DROP COLUMN IF EXISTS receiver_name,
+  DROP COLUMN IF EXISTS sender;
@@ -0,0 +1,14 @@
+ALTER TABLE godview_starter.mail_messages
+  ADD COLUMN IF NOT EXISTS sender text,
+  ADD COLUMN IF NOT EXISTS receiver_name text,
+  ADD COLUMN IF NOT EXISTS locale text,
+  ADD COLUMN IF NOT EXISTS data jsonb,
+  ADD COLUMN IF NOT EXISTS provider_id text,
+  ADD COLUMN IF NOT EXISTS error text,
+  ADD COLUMN IF NOT EXISTS resent_from bigint references mail_messages(id) on delete set null,
+  ADD COLUMN IF NOT EXISTS redacted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS mail_messages_provider_id_idx
+  ON godview_starter.mail_messages (provider_id);
+CREATE INDEX IF NOT EXISTS mail_messages_created_at_idx
+  ON godview_starter.mail_messages (created_at) WHERE redacted_at IS NULL;
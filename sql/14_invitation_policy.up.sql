@@ -0,0 +1,3 @@
+ALTER TABLE godview_starter.workspaces
+  ADD COLUMN IF NOT EXISTS invitation_policy jsonb not null
+    default '{"ttl_hours": 48, "extension_hours": 1, "max_extensions": 3, "roles": ["member", "admin"], "domains": []}';
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS godview_starter.join_links (
+  id bigserial primary key,
+  created_at timestamptz not null default current_timestamp,
+  workspace integer not null references workspaces(id) on delete cascade,
+  created_by integer references users(id) on delete set null,
+  token text not null unique,
+  role varchar(20) not null,
+  max_uses integer not null,
+  uses integer not null default 0,
+  domain text,
+  expires_at timestamptz not null,
+  revoked_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS join_links_workspace_idx
+  ON godview_starter.join_links (workspace, created_at desc);
@@ -0,0 +1 @@
+drop table if exists godview_starter.workspace_domains;
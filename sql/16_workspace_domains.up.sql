@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS godview_starter.workspace_domains (
+  id bigserial primary key,
+  created_at timestamptz not null default current_timestamp,
+  workspace integer not null references workspaces(id) on delete cascade,
+  name text not null,
+  token text not null,
+  verified_at timestamptz,
+  join_mode varchar(20) not null default 'approval',
+  role varchar(20) not null default 'member',
+  unique (workspace, name)
+);
+
+-- many workspaces can claim a domain, but only one can prove it owns it
+CREATE UNIQUE INDEX IF NOT EXISTS workspace_domains_verified_idx
+  ON godview_starter.workspace_domains (name) WHERE verified_at IS NOT NULL;
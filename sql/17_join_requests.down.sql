@@ -0,0 +1 @@
+drop table if exists godview_starter.join_requests;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS godview_starter.join_requests (
+  id bigserial primary key,
+  created_at timestamptz not null default current_timestamp,
+  workspace integer not null references workspaces(id) on delete cascade,
+  email_address text not null,
+  first_name text not null,
+  last_name text not null,
+  status varchar(20) not null default 'pending',
+  role varchar(20),
+  decided_by integer references users(id) on delete set null,
+  decided_at timestamptz
+);
+
+-- someone can only wait on one request per workspace
+CREATE UNIQUE INDEX IF NOT EXISTS join_requests_pending_idx
+  ON godview_starter.join_requests (workspace, email_address) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS join_requests_workspace_idx
+  ON godview_starter.join_requests (workspace, status, created_at desc);
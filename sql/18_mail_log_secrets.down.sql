@@ -0,0 +1,3 @@
+-- the tokens are gone for good and lower case receivers are still valid, so there's
+-- nothing to undo
+SELECT 1;
@@ -0,0 +1,8 @@
+-- mail logged before tokens were left out of the log
+UPDATE godview_starter.mail_messages
+  SET data = data - ARRAY(SELECT k FROM jsonb_object_keys(data) k WHERE lower(k) LIKE '%token')
+  WHERE jsonb_typeof(data) = 'object';
+
+UPDATE godview_starter.mail_messages
+  SET receiver = lower(receiver)
+  WHERE receiver <> lower(receiver);
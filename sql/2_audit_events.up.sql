@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS godview_starter.audit_events (
+  id bigserial primary key,
+  created_at timestamptz not null default current_timestamp,
+  workspace integer not null references workspaces(id),
+  actor integer references users(id),
+  action text not null,
+  target text,
+  ip_address text,
+  user_agent text,
+  metadata jsonb not null default '{}'
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_workspace_created_at_idx
+  ON godview_starter.audit_events (workspace, created_at desc);
@@ -0,0 +1,2 @@
+drop table if exists godview_starter.webhook_deliveries;
+drop table if exists godview_starter.webhook_endpoints;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS godview_starter.webhook_endpoints (
+  id serial primary key,
+  created_at timestamptz not null default current_timestamp,
+  workspace integer not null references workspaces(id),
+  url text not null,
+  secret text not null,
+  events text[] not null,
+  failures integer not null default 0,
+  disabled_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS godview_starter.webhook_deliveries (
+  id bigserial primary key,
+  created_at timestamptz not null default current_timestamp,
+  endpoint integer not null references webhook_endpoints(id) on delete cascade,
+  event_id text not null,
+  event_type text not null,
+  payload jsonb not null,
+  status text not null default 'pending',
+  attempts integer not null default 0,
+  next_attempt_at timestamptz not null default current_timestamp,
+  last_attempt_at timestamptz,
+  response_status integer,
+  last_error text
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx
+  ON godview_starter.webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_endpoint_idx
+  ON godview_starter.webhook_deliveries (endpoint, created_at desc);
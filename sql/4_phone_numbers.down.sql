@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS godview_starter.users_phone_number_key;
+
+ALTER TABLE godview_starter.users
+  DROP CONSTRAINT IF EXISTS users_phone_number_e164;
+
+-- put back the numbers the up migration changed, unless their users have changed
+-- them since
+UPDATE godview_starter.users u
+  SET phone_number = b.original
+  FROM godview_starter.phone_numbers_backup b
+  WHERE u.id = b.user_id AND u.phone_number IS NOT DISTINCT FROM b.migrated;
+
+DROP TABLE IF EXISTS godview_starter.phone_numbers_backup;
+
+ALTER TABLE godview_starter.users
+  ADD CONSTRAINT users_phone_number_key UNIQUE (phone_number);
+
+ALTER TABLE godview_starter.workspaces
+  DROP COLUMN IF EXISTS region;
@@ -0,0 +1,48 @@
+ALTER TABLE godview_starter.workspaces
+  ADD COLUMN IF NOT EXISTS region varchar(2) not null default 'NG';
+
+ALTER TABLE godview_starter.users
+  DROP CONSTRAINT IF EXISTS users_phone_number_key;
+
+-- every number as it was before, so the ones rewritten or dropped below can be
+-- recovered by hand and put back by the down migration
+CREATE TABLE IF NOT EXISTS godview_starter.phone_numbers_backup (
+  user_id integer primary key references godview_starter.users(id) on delete cascade,
+  original varchar(20) not null,
+  migrated varchar(20)
+);
+
+INSERT INTO godview_starter.phone_numbers_backup (user_id, original)
+  SELECT id, phone_number FROM godview_starter.users WHERE phone_number IS NOT NULL;
+
+-- the old validator only looked for a Nigerian number somewhere in the input, so
+-- that's the part worth keeping
+UPDATE godview_starter.users
+  SET phone_number = '+234' || substring(substring(phone_number from '0[789][01][0-9]{8}') from 2)
+  WHERE phone_number ~ '0[789][01][0-9]{8}';
+
+UPDATE godview_starter.users
+  SET phone_number = null
+  WHERE phone_number !~ '^\+[1-9][0-9]{6,14}$';
+
+-- different spellings of the same number used to slip past the unique constraint.
+-- The first user to register it keeps it.
+UPDATE godview_starter.users u
+  SET phone_number = null
+  FROM godview_starter.users other
+  WHERE u.phone_number = other.phone_number AND u.id > other.id;
+
+-- only keep the numbers we changed
+UPDATE godview_starter.phone_numbers_backup b
+  SET migrated = u.phone_number
+  FROM godview_starter.users u
+  WHERE u.id = b.user_id;
+
+DELETE FROM godview_starter.phone_numbers_backup
+  WHERE migrated IS NOT DISTINCT FROM original;
+
+ALTER TABLE godview_starter.users
+  ADD CONSTRAINT users_phone_number_e164 CHECK (phone_number ~ '^\+[1-9][0-9]{6,14}$');
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_phone_number_key
+  ON godview_starter.users (phone_number);
@@ -0,0 +1,2 @@
+ALTER TABLE godview_starter.users
+  DROP COLUMN IF EXISTS phone_verified_at;
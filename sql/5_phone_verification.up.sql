@@ -0,0 +1,2 @@
+ALTER TABLE godview_starter.users
+  ADD COLUMN IF NOT EXISTS phone_verified_at timestamptz;
@@ -0,0 +1,3 @@
+ALTER TABLE godview_starter.users
+  DROP COLUMN IF EXISTS timezone,
+  DROP COLUMN IF EXISTS locale;
@@ -0,0 +1,3 @@
+ALTER TABLE godview_starter.users
+  ADD COLUMN IF NOT EXISTS timezone text,
+  ADD COLUMN IF NOT EXISTS locale text;
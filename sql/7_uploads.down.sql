@@ -0,0 +1,5 @@
+ALTER TABLE godview_starter.users
+  DROP COLUMN IF EXISTS avatar;
+
+ALTER TABLE godview_starter.workspaces
+  DROP COLUMN IF EXISTS logo;
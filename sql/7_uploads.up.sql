@@ -0,0 +1,5 @@
+ALTER TABLE godview_starter.users
+  ADD COLUMN IF NOT EXISTS avatar text;
+
+ALTER TABLE godview_starter.workspaces
+  ADD COLUMN IF NOT EXISTS logo text;
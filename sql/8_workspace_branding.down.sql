@@ -0,0 +1,4 @@
+ALTER TABLE godview_starter.workspaces
+  DROP COLUMN IF EXISTS brand_name,
+  DROP COLUMN IF EXISTS accent_color,
+  DROP COLUMN IF EXISTS reply_to;
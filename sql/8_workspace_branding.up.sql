@@ -0,0 +1,4 @@
+ALTER TABLE godview_starter.workspaces
+  ADD COLUMN IF NOT EXISTS brand_name text,
+  ADD COLUMN IF NOT EXISTS accent_color varchar(7),
+  ADD COLUMN IF NOT EXISTS reply_to text;
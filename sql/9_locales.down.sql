@@ -0,0 +1,2 @@
+ALTER TABLE godview_starter.workspaces
+  DROP COLUMN IF EXISTS locale;
@@ -0,0 +1,2 @@
+ALTER TABLE godview_starter.workspaces
+  ADD COLUMN IF NOT EXISTS locale varchar(35) not null default 'en';